@@ -1,9 +1,11 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -13,30 +15,75 @@ import (
 )
 
 const (
-	FlagBackend               = "backend"
-	FlagBucket                = "bucket"
-	FlagS3Provider            = "s3-provider"
-	FlagEndpoint              = "endpoint"
-	FlagRegion                = "region"
-	FlagAccessKey             = "access-key"
-	FlagSecretKey             = "secret-key"
-	FlagStorageAccount        = "storage-account"
-	FlagStorageKey            = "storage-key"
-	FlagGCSServiceAccountFile = "gcs-service-account-file"
-	FlagGCSBucketPolicyOnly   = "gcs-bucket-policy-only"
-	FlagName                  = "name"
-	FlagPrefix                = "prefix"
-	FlagRcloneConfig          = "rclone-config"
-	FlagRemote                = "remote"
-	FlagPath                  = "path"
-	FlagRcloneExtraArgs       = "rclone-extra-args"
-	FlagDeleteExtraneousFiles = "delete-extraneous-files"
+	FlagBackend                   = "backend"
+	FlagBucket                    = "bucket"
+	FlagS3Provider                = "s3-provider"
+	FlagEndpoint                  = "endpoint"
+	FlagRegion                    = "region"
+	FlagAccessKey                 = "access-key"
+	FlagSecretKey                 = "secret-key"
+	FlagStorageAccount            = "storage-account"
+	FlagStorageKey                = "storage-key"
+	FlagGCSServiceAccountFile     = "gcs-service-account-file"
+	FlagGCSBucketPolicyOnly       = "gcs-bucket-policy-only"
+	FlagSFTPHost                  = "sftp-host"
+	FlagSFTPPort                  = "sftp-port"
+	FlagSFTPUser                  = "sftp-user"
+	FlagSFTPPassword              = "sftp-password"
+	FlagSFTPKeyFile               = "sftp-key-file"
+	FlagWebDAVURL                 = "webdav-url"
+	FlagWebDAVVendor              = "webdav-vendor"
+	FlagWebDAVUser                = "webdav-user"
+	FlagWebDAVPassword            = "webdav-password"
+	FlagWebDAVBearerToken         = "webdav-bearer-token"
+	FlagSwiftAuthURL              = "swift-auth-url"
+	FlagSwiftUser                 = "swift-user"
+	FlagSwiftKey                  = "swift-key"
+	FlagSwiftTenant               = "swift-tenant"
+	FlagSwiftDomain               = "swift-domain"
+	FlagB2Account                 = "b2-account"
+	FlagB2Key                     = "b2-key"
+	FlagName                      = "name"
+	FlagPrefix                    = "prefix"
+	FlagRcloneConfig              = "rclone-config"
+	FlagRcloneConfigRemote        = "rclone-config-remote"
+	FlagRemote                    = "remote"
+	FlagPath                      = "path"
+	FlagRcloneExtraArgs           = "rclone-extra-args"
+	FlagDeleteExtraneousFiles     = "delete-extraneous-files"
+	FlagVerify                    = "verify"
+	FlagInclude                   = "include"
+	FlagExclude                   = "exclude"
+	FlagFilterFrom                = "filter-from"
+	FlagVerifyMode                = "verify-mode"
+	FlagCredentialsSecret         = "credentials-secret"
+	FlagCredentialsKubeconfig     = "credentials-kubeconfig"
+	FlagCredentialsContext        = "credentials-context"
+	FlagCredentialsNamespace      = "credentials-namespace"
+	FlagFromLatest                = "from-latest"
+	FlagNoManifest                = "no-manifest"
+	FlagArchive                   = "archive"
+	FlagServiceAccount            = "service-account"
+	FlagServiceAccountAnnotations = "service-account-annotations"
+	FlagAWSRoleARN                = "aws-role-arn"
+	FlagAzureClientID             = "azure-client-id"
+	FlagAzureTenantID             = "azure-tenant-id"
+	FlagGCPServiceAccount         = "gcp-service-account"
+	FlagEnvAuth                   = "env-auth"
+	FlagSourceSelector            = "source-selector"
+	FlagDestSelector              = "dest-selector"
 
 	envS3AccessKey           = "PV_MIGRATE_S3_ACCESS_KEY"
 	envS3SecretKey           = "PV_MIGRATE_S3_SECRET_KEY" //nolint:gosec // Environment variable name, not a secret.
 	envAzureStorageAccount   = "PV_MIGRATE_AZURE_STORAGE_ACCOUNT"
 	envAzureStorageKey       = "PV_MIGRATE_AZURE_STORAGE_KEY"
 	envGCSServiceAccountJSON = "PV_MIGRATE_GCS_SERVICE_ACCOUNT_JSON"
+	envSFTPPassword          = "PV_MIGRATE_SFTP_PASSWORD"       //nolint:gosec // Environment variable name, not a secret.
+	envWebDAVPassword        = "PV_MIGRATE_WEBDAV_PASSWORD"     //nolint:gosec // Environment variable name, not a secret.
+	envWebDAVBearerToken     = "PV_MIGRATE_WEBDAV_BEARER_TOKEN" //nolint:gosec // Environment variable name, not a secret.
+	envSwiftKey              = "PV_MIGRATE_SWIFT_KEY"
+	envB2Account             = "PV_MIGRATE_B2_ACCOUNT"
+	envB2Key                 = "PV_MIGRATE_B2_KEY"
 	defaultHelmTimeout       = 1 * time.Minute
 )
 
@@ -51,7 +98,7 @@ func buildBackupCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobra
 		Use:   "backup --source <pvc-name> --backend <backend> --bucket <bucket>",
 		Short: "Back up a PVC to bucket storage",
 		Long: "Back up data from a Kubernetes PersistentVolumeClaim to S3-compatible, " +
-			"Azure Blob, or GCS bucket storage using rclone.",
+			"Azure Blob, GCS, Swift, or Backblaze B2 bucket storage, or to an SFTP or WebDAV server, using rclone.",
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			backup.GCSBucketPolicyOnly = &gcsBucketPolicyOnly
@@ -60,10 +107,24 @@ func buildBackupCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobra
 		},
 	}
 
-	if err := setBackupPVCFlags(cmd, &backup.PVC); err != nil {
+	if err := setBackupFlags(cmd, &backup, &gcsBucketPolicyOnly); err != nil {
 		return nil, err
 	}
 
+	scheduleCmd, err := buildBackupScheduleCmd(logger, imageTag)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.AddCommand(scheduleCmd)
+
+	return cmd, nil
+}
+
+// setBackupFlags registers the flags of backup, which backup schedule takes as well.
+func setBackupFlags(cmd *cobra.Command, backup *pvmigrate.Backup, gcsBucketPolicyOnly *bool) error {
+	setBackupPVCFlags(cmd, &backup.PVC, &backup.PVCNames, &backup.PVCSelector)
+
 	setBackupRestoreFlags(cmd, &backup.ID, &backup.IgnoreMounted, &backup.NonRoot,
 		&backup.Detach, &backup.NoCleanup, &backup.NoCleanupOnFailure,
 		&backup.HelmTimeout, &backup.HelmValuesFiles, &backup.HelmValues,
@@ -73,16 +134,31 @@ func buildBackupCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobra
 		cmd,
 		&backup.Backend, &backup.Bucket, &backup.S3Provider, &backup.Endpoint, &backup.Region,
 		&backup.AccessKey, &backup.SecretKey, &backup.StorageAccount, &backup.StorageKey,
-		&gcsBucketPolicyOnly, &backup.Name, &backup.Prefix, &backup.Path, &backup.RcloneExtraArgs,
+		gcsBucketPolicyOnly, &backup.Name, &backup.Prefix, &backup.Path, &backup.RcloneExtraArgs,
 	)
 
-	setRawConfigFlags(cmd, &backup.RcloneConfigFile, &backup.Remote)
-
-	if err := setBucketStorageFlagCompletions(cmd); err != nil {
-		return nil, err
+	setSFTPFlags(cmd, &backup.SFTPHost, &backup.SFTPUser, &backup.SFTPPassword, &backup.SFTPPort)
+	setWebDAVFlags(cmd, &backup.WebDAVURL, &backup.WebDAVVendor, &backup.WebDAVUser,
+		&backup.WebDAVPassword, &backup.WebDAVBearerToken)
+	setSwiftFlags(cmd, &backup.SwiftAuthURL, &backup.SwiftUser, &backup.SwiftKey,
+		&backup.SwiftTenant, &backup.SwiftDomain)
+	setB2Flags(cmd, &backup.B2Account, &backup.B2Key)
+	setCredentialsSecretFlag(cmd, &backup.CredentialsSecret)
+	setWorkloadIdentityFlags(cmd, &backup.ServiceAccountName, &backup.ServiceAccountAnnotations,
+		&backup.AWSRoleARN, &backup.AzureClientID, &backup.AzureTenantID, &backup.GCPServiceAccount, &backup.EnvAuth)
+	setRawConfigFlags(cmd, &backup.RcloneConfigFile, &backup.RcloneConfigRemote, &backup.Remote)
+	setRcloneBWLimitFlag(cmd, &backup.BWLimit)
+	cmd.Flags().BoolVar(&backup.Archive, FlagArchive, false,
+		"Store the backup as one zstd-compressed tarball per PVC instead of a tree of files, "+
+			"which restore detects from the metadata")
+	cmd.Flags().BoolVar(&backup.NoManifest, FlagNoManifest, false,
+		"Do not upload the integrity manifest, the SHA-256 of every file, which takes reading the data a second time")
+
+	if err := setHookFlags(cmd, &backup.Hooks, "in the namespace of the PVC"); err != nil {
+		return err
 	}
 
-	return cmd, nil
+	return setBucketStorageFlagCompletions(cmd, "")
 }
 
 func runBackup(cmd *cobra.Command, backup *pvmigrate.Backup, logger *slog.Logger) error {
@@ -92,18 +168,32 @@ func runBackup(cmd *cobra.Command, backup *pvmigrate.Backup, logger *slog.Logger
 	backup.StructuredLogs = structuredLogsRequested(cmd)
 	backup.ColorOutput = colorOutputWanted(cmd, backup.Writer)
 
-	if err := readGCSServiceAccountFile(cmd, &backup.GCSServiceAccountJSON); err != nil {
+	if err := resolveBackupCredentials(cmd, backup); err != nil {
 		return err
 	}
 
-	applyBucketStorageEnvDefaults(&backup.AccessKey, &backup.SecretKey,
-		&backup.StorageAccount, &backup.StorageKey, &backup.GCSServiceAccountJSON)
+	singlePVC(&backup.PVC, &backup.PVCNames, backup.PVCSelector)
 
 	logger.Info("📦 Starting backup")
 
 	return pvmigrate.RunBackup(ctx, *backup)
 }
 
+// resolveBackupCredentials reads the credential files and fills the credentials
+// not given as flags from the environment.
+func resolveBackupCredentials(cmd *cobra.Command, backup *pvmigrate.Backup) error {
+	if err := readBackendFiles(cmd, &backup.GCSServiceAccountJSON, &backup.SFTPKeyPEM); err != nil {
+		return err
+	}
+
+	applyBucketStorageEnvDefaults(&backup.AccessKey, &backup.SecretKey,
+		&backup.StorageAccount, &backup.StorageKey, &backup.GCSServiceAccountJSON)
+	applyBackendSecretEnvDefaults(&backup.SFTPPassword, &backup.WebDAVPassword, &backup.WebDAVBearerToken,
+		&backup.SwiftKey, &backup.B2Account, &backup.B2Key)
+
+	return nil
+}
+
 func buildRestoreCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobra.Command, error) {
 	restore := pvmigrate.Restore{
 		ImageTag:     imageTag,
@@ -114,8 +204,8 @@ func buildRestoreCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobr
 	cmd := &cobra.Command{
 		Use:   "restore --dest <pvc-name> --backend <backend> --bucket <bucket>",
 		Short: "Restore a PVC from bucket storage",
-		Long: "Restore data from S3-compatible, Azure Blob, or GCS bucket storage " +
-			"to a Kubernetes PersistentVolumeClaim using rclone.",
+		Long: "Restore data from S3-compatible, Azure Blob, GCS, Swift, or Backblaze B2 bucket storage, " +
+			"or from an SFTP or WebDAV server, to a Kubernetes PersistentVolumeClaim using rclone.",
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			restore.GCSBucketPolicyOnly = &gcsBucketPolicyOnly
@@ -124,10 +214,32 @@ func buildRestoreCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobr
 		},
 	}
 
-	if err := setRestorePVCFlags(cmd, &restore.PVC); err != nil {
+	setRestoreFlags(cmd, &restore, &gcsBucketPolicyOnly)
+	setRestoreDeleteFlags(cmd, &restore.DeleteExtraneousFiles)
+	setRcloneBWLimitFlag(cmd, &restore.BWLimit)
+	cmd.Flags().BoolVar(&restore.Verify, FlagVerify, false,
+		"Check the PVC against the backup with rclone check once the restore is done")
+
+	if err := setVerifyModeFlag(cmd, &restore.VerifyMode); err != nil {
+		return nil, err
+	}
+
+	if err := setHookFlags(cmd, &restore.Hooks, "in the namespace of the PVC"); err != nil {
 		return nil, err
 	}
 
+	if err := setBucketStorageFlagCompletions(cmd, ""); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// setRestoreFlags registers the flags that say where to restore from and to,
+// which backups verify takes as well.
+func setRestoreFlags(cmd *cobra.Command, restore *pvmigrate.Restore, gcsBucketPolicyOnly *bool) {
+	setRestorePVCFlags(cmd, &restore.PVC, &restore.PVCNames, &restore.PVCSelector)
+
 	setBackupRestoreFlags(cmd, &restore.ID, &restore.IgnoreMounted, &restore.NonRoot,
 		&restore.Detach, &restore.NoCleanup, &restore.NoCleanupOnFailure,
 		&restore.HelmTimeout, &restore.HelmValuesFiles, &restore.HelmValues,
@@ -137,51 +249,145 @@ func buildRestoreCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobr
 		cmd,
 		&restore.Backend, &restore.Bucket, &restore.S3Provider, &restore.Endpoint, &restore.Region,
 		&restore.AccessKey, &restore.SecretKey, &restore.StorageAccount, &restore.StorageKey,
-		&gcsBucketPolicyOnly, &restore.Name, &restore.Prefix, &restore.Path, &restore.RcloneExtraArgs,
+		gcsBucketPolicyOnly, &restore.Name, &restore.Prefix, &restore.Path, &restore.RcloneExtraArgs,
 	)
 
-	setRawConfigFlags(cmd, &restore.RcloneConfigFile, &restore.Remote)
-	setRestoreDeleteFlags(cmd, &restore.DeleteExtraneousFiles)
+	setSFTPFlags(cmd, &restore.SFTPHost, &restore.SFTPUser, &restore.SFTPPassword, &restore.SFTPPort)
+	setWebDAVFlags(cmd, &restore.WebDAVURL, &restore.WebDAVVendor, &restore.WebDAVUser,
+		&restore.WebDAVPassword, &restore.WebDAVBearerToken)
+	setSwiftFlags(cmd, &restore.SwiftAuthURL, &restore.SwiftUser, &restore.SwiftKey,
+		&restore.SwiftTenant, &restore.SwiftDomain)
+	setB2Flags(cmd, &restore.B2Account, &restore.B2Key)
+	setCredentialsSecretFlag(cmd, &restore.CredentialsSecret)
+	setCredentialsClusterFlags(cmd, &restore.CredentialsKubeconfigPath, &restore.CredentialsContext,
+		&restore.CredentialsNamespace)
+	setWorkloadIdentityFlags(cmd, &restore.ServiceAccountName, &restore.ServiceAccountAnnotations,
+		&restore.AWSRoleARN, &restore.AzureClientID, &restore.AzureTenantID, &restore.GCPServiceAccount, &restore.EnvAuth)
+	setRawConfigFlags(cmd, &restore.RcloneConfigFile, &restore.RcloneConfigRemote, &restore.Remote)
+	setRestoreSelectionFlags(cmd, &restore.SourcePath, &restore.Include, &restore.Exclude)
+
+	cmd.Flags().BoolVar(&restore.FromLatest, FlagFromLatest, false,
+		"Use the newest of the backups named --name or <name>-<version>, by the backup time in their metadata")
+}
 
-	if err := setBucketStorageFlagCompletions(cmd); err != nil {
-		return nil, err
+// setRestoreSelectionFlags registers the flags that restore part of a backup. The
+// patterns are string arrays rather than slices, since a comma is part of rclone's
+// pattern syntax, as in "*.{jpg,png}".
+func setRestoreSelectionFlags(cmd *cobra.Command, sourcePath *string, include, exclude *[]string) {
+	flags := cmd.Flags()
+
+	flags.StringVar(sourcePath, FlagSourcePath, "",
+		"Subdirectory inside the backup to restore from, instead of all of it (combines with --path)")
+	flags.StringArrayVar(include, FlagInclude, nil,
+		"Only restore the files matching this rclone filter pattern; repeat it for several")
+	flags.StringArrayVar(exclude, FlagExclude, nil,
+		"Do not restore the files matching this rclone filter pattern; repeat it for several")
+	flags.String(FlagFilterFrom, "",
+		"Path to an rclone filter file whose \"+ pattern\" and \"- pattern\" rules select the files to restore, "+
+			"applied in order (cannot be combined with --include or --exclude)")
+}
+
+// readFilterFile reads the rules of the local --filter-from file, which go to the
+// job as flags since the file does not exist in the cluster.
+func readFilterFile(cmd *cobra.Command, rules *[]string) error {
+	var content string
+
+	if err := readFileFlag(cmd, FlagFilterFrom, "filter file", &content); err != nil {
+		return err
 	}
 
-	return cmd, nil
+	if content == "" {
+		return nil
+	}
+
+	parsed, err := rclone.ParseFilterRules(content)
+	if err != nil {
+		return fmt.Errorf("invalid filter file: %w", err)
+	}
+
+	// Restoring everything is not what a filter file that selects nothing asks for.
+	if len(parsed) == 0 {
+		return errors.New("the filter file holds no rules")
+	}
+
+	*rules = parsed
+
+	return nil
+}
+
+func setVerifyModeFlag(cmd *cobra.Command, verifyMode *string) error {
+	cmd.Flags().StringVar(verifyMode, FlagVerifyMode, rclone.CheckModeSize,
+		"How to compare files when verifying: "+strings.Join(rclone.CheckModes, " or "))
+
+	if err := cmd.RegisterFlagCompletionFunc(FlagVerifyMode,
+		buildStaticSliceCompletionFunc(rclone.CheckModes)); err != nil {
+		return fmt.Errorf("failed to register completion for flag %q: %w", FlagVerifyMode, err)
+	}
+
+	return nil
 }
 
 func runRestore(cmd *cobra.Command, restore *pvmigrate.Restore, logger *slog.Logger) error {
-	ctx := cmd.Context()
+	if err := prepareRestore(cmd, restore, logger); err != nil {
+		return err
+	}
+
+	logger.Info("📥 Starting restore")
+
+	return pvmigrate.RunRestore(cmd.Context(), *restore)
+}
+
+// prepareRestore fills in what the flags of a restore leave to the command's
+// environment: the output, the credential files and the credential variables.
+func prepareRestore(cmd *cobra.Command, restore *pvmigrate.Restore, logger *slog.Logger) error {
 	restore.Writer = cmd.ErrOrStderr()
 	restore.Logger = logger
 	restore.StructuredLogs = structuredLogsRequested(cmd)
 	restore.ColorOutput = colorOutputWanted(cmd, restore.Writer)
 
-	if err := readGCSServiceAccountFile(cmd, &restore.GCSServiceAccountJSON); err != nil {
+	if err := readBackendFiles(cmd, &restore.GCSServiceAccountJSON, &restore.SFTPKeyPEM); err != nil {
 		return err
 	}
 
+	if err := readFilterFile(cmd, &restore.FilterRules); err != nil {
+		return err
+	}
+
+	singlePVC(&restore.PVC, &restore.PVCNames, restore.PVCSelector)
+
 	applyBucketStorageEnvDefaults(&restore.AccessKey, &restore.SecretKey,
 		&restore.StorageAccount, &restore.StorageKey, &restore.GCSServiceAccountJSON)
+	applyBackendSecretEnvDefaults(&restore.SFTPPassword, &restore.WebDAVPassword, &restore.WebDAVBearerToken,
+		&restore.SwiftKey, &restore.B2Account, &restore.B2Key)
 
-	logger.Info("📥 Starting restore")
+	return nil
+}
+
+// readBackendFiles reads the credentials that are passed as local file paths. The
+// contents, not the paths, go into the generated config, since the files do not
+// exist in the cluster.
+func readBackendFiles(cmd *cobra.Command, gcsServiceAccountJSON, sftpKeyPEM *string) error {
+	if err := readFileFlag(cmd, FlagGCSServiceAccountFile, "GCS service account file",
+		gcsServiceAccountJSON); err != nil {
+		return err
+	}
 
-	return pvmigrate.RunRestore(ctx, *restore)
+	return readFileFlag(cmd, FlagSFTPKeyFile, "SFTP key file", sftpKeyPEM)
 }
 
-func readGCSServiceAccountFile(cmd *cobra.Command, target *string) error {
-	saFile, err := cmd.Flags().GetString(FlagGCSServiceAccountFile)
+func readFileFlag(cmd *cobra.Command, flag, description string, target *string) error {
+	filePath, err := cmd.Flags().GetString(flag)
 	if err != nil {
-		return fmt.Errorf("failed to get flag %s: %w", FlagGCSServiceAccountFile, err)
+		return fmt.Errorf("failed to get flag %s: %w", flag, err)
 	}
 
-	if saFile == "" {
+	if filePath == "" {
 		return nil
 	}
 
-	data, err := os.ReadFile(saFile)
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read GCS service account file %s: %w", saFile, err)
+		return fmt.Errorf("failed to read %s %s: %w", description, filePath, err)
 	}
 
 	*target = string(data)
@@ -189,34 +395,43 @@ func readGCSServiceAccountFile(cmd *cobra.Command, target *string) error {
 	return nil
 }
 
-func setBackupPVCFlags(cmd *cobra.Command, pvc *pvmigrate.PVC) error {
+func setBackupPVCFlags(cmd *cobra.Command, pvc *pvmigrate.PVC, names *[]string, selector *string) {
 	flags := cmd.Flags()
 
 	flags.StringVarP(&pvc.KubeconfigPath, FlagSourceKubeconfig, "k", "", "Path to the kubeconfig file")
 	flags.StringVarP(&pvc.Context, FlagSourceContext, "c", "", "Kubernetes context to use")
 	flags.StringVarP(&pvc.Namespace, FlagSourceNamespace, "n", "", "Namespace of the source PVC")
-	flags.StringVar(&pvc.Name, FlagSource, "", "Source PVC name")
-
-	if err := cmd.MarkFlagRequired(FlagSource); err != nil {
-		return fmt.Errorf("failed to mark flag %q as required: %w", FlagSource, err)
-	}
+	flags.StringSliceVar(names, FlagSource, nil,
+		"Source PVC name; repeat it to back up several PVCs as one set, each under <name>/<pvc>/")
+	flags.StringVar(selector, FlagSourceSelector, "",
+		"Label selector of the source PVCs to back up as one set, instead of --source")
 
-	return nil
+	cmd.MarkFlagsOneRequired(FlagSource, FlagSourceSelector)
+	cmd.MarkFlagsMutuallyExclusive(FlagSource, FlagSourceSelector)
 }
 
-func setRestorePVCFlags(cmd *cobra.Command, pvc *pvmigrate.PVC) error {
+func setRestorePVCFlags(cmd *cobra.Command, pvc *pvmigrate.PVC, names *[]string, selector *string) {
 	flags := cmd.Flags()
 
 	flags.StringVarP(&pvc.KubeconfigPath, FlagDestKubeconfig, "K", "", "Path to the kubeconfig file")
 	flags.StringVarP(&pvc.Context, FlagDestContext, "C", "", "Kubernetes context to use")
 	flags.StringVarP(&pvc.Namespace, FlagDestNamespace, "N", "", "Namespace of the destination PVC")
-	flags.StringVar(&pvc.Name, FlagDest, "", "Destination PVC name")
+	flags.StringSliceVar(names, FlagDest, nil,
+		"Destination PVC name; repeat it to restore a set of PVCs, each from <name>/<pvc>/")
+	flags.StringVar(selector, FlagDestSelector, "",
+		"Label selector of the destination PVCs to restore a set to, instead of --dest")
 
-	if err := cmd.MarkFlagRequired(FlagDest); err != nil {
-		return fmt.Errorf("failed to mark flag %q as required: %w", FlagDest, err)
-	}
+	cmd.MarkFlagsOneRequired(FlagDest, FlagDestSelector)
+	cmd.MarkFlagsMutuallyExclusive(FlagDest, FlagDestSelector)
+}
 
-	return nil
+// singlePVC moves a lone PVC name from the set into pvc, so that a single
+// --source or --dest keeps the layout of one PVC directly under <name>/.
+func singlePVC(pvc *pvmigrate.PVC, names *[]string, selector string) {
+	if len(*names) == 1 && selector == "" {
+		pvc.Name = (*names)[0]
+		*names = nil
+	}
 }
 
 func setBackupRestoreFlags(
@@ -225,12 +440,26 @@ func setBackupRestoreFlags(
 	ignoreMounted, nonRoot, detach, noCleanup, noCleanupOnFailure *bool,
 	helmTimeout *time.Duration,
 	helmValuesFiles, helmValues, helmStringValues, helmFileValues *[]string,
+) {
+	cmd.Flags().BoolVarP(ignoreMounted, FlagIgnoreMounted, "i", false, "Do not fail if the PVC is mounted")
+
+	setOperationFlags(cmd, id, nonRoot, detach, noCleanup, noCleanupOnFailure,
+		helmTimeout, helmValuesFiles, helmValues, helmStringValues, helmFileValues)
+}
+
+// setOperationFlags registers the flags of the rclone job that do not concern a
+// PVC, which backups copy takes as well.
+func setOperationFlags(
+	cmd *cobra.Command,
+	id *string,
+	nonRoot, detach, noCleanup, noCleanupOnFailure *bool,
+	helmTimeout *time.Duration,
+	helmValuesFiles, helmValues, helmStringValues, helmFileValues *[]string,
 ) {
 	flags := cmd.Flags()
 
 	flags.StringVar(id, FlagID, "", fmt.Sprintf(
 		"Custom operation ID (lowercase alphanumeric with optional hyphens, max %d chars)", pvmigrate.MaxIDLength))
-	flags.BoolVarP(ignoreMounted, FlagIgnoreMounted, "i", false, "Do not fail if the PVC is mounted")
 	flags.BoolVar(nonRoot, FlagNonRoot, false, "Run rclone container as non-root")
 	flags.BoolVar(detach, FlagDetach, false, "Detach after the rclone job starts running")
 	flags.BoolVarP(noCleanup, FlagNoCleanup, "x", false, "Do not clean up after the operation")
@@ -254,11 +483,12 @@ func setBucketStorageFlags(
 ) {
 	flags := cmd.Flags()
 
-	flags.StringVar(backend, FlagBackend, "", "Storage backend: s3, azure, or gcs")
-	flags.StringVar(bucket, FlagBucket, "", "Bucket (or container) name")
+	flags.StringVar(backend, FlagBackend, "", "Storage backend: "+strings.Join(rclone.Backends, ", "))
+	flags.StringVar(bucket, FlagBucket, "",
+		"Bucket (or container) name; the top-level directory for the sftp and webdav backends")
 	flags.StringVar(s3Provider, FlagS3Provider, rclone.DefaultS3Provider, "Rclone S3 provider")
 	flags.StringVar(endpoint, FlagEndpoint, "", "S3-compatible endpoint URL")
-	flags.StringVar(region, FlagRegion, "", "S3 region")
+	flags.StringVar(region, FlagRegion, "", "S3 or Swift region")
 	flags.StringVar(accessKey, FlagAccessKey, "", "S3 access key")
 	flags.StringVar(secretKey, FlagSecretKey, "",
 		"S3 secret key (prefer env PV_MIGRATE_S3_SECRET_KEY)")
@@ -269,7 +499,7 @@ func setBucketStorageFlags(
 		"Path to GCS service account JSON file (env PV_MIGRATE_GCS_SERVICE_ACCOUNT_JSON expects JSON contents)")
 	flags.BoolVar(gcsBucketPolicyOnly, FlagGCSBucketPolicyOnly, true,
 		"Set rclone GCS bucket_policy_only")
-	flags.StringVar(name, FlagName, "", "Backup name (identity in the bucket, required unless using --rclone-config)")
+	flags.StringVar(name, FlagName, "", "Backup name (identity in the bucket, required unless using --remote)")
 	flags.StringVar(prefix, FlagPrefix, pvmigrate.DefaultPrefix,
 		"Global prefix in the bucket (can contain '/' for nesting)")
 	flags.StringVarP(pvcPath, FlagPath, "p", "", "Subdirectory inside the PVC to back up or restore")
@@ -277,25 +507,134 @@ func setBucketStorageFlags(
 		"Extra rclone flags appended after the built-in progress flags (use at your own risk)")
 }
 
-func setRawConfigFlags(cmd *cobra.Command, rcloneConfig, remote *string) {
+func setSFTPFlags(cmd *cobra.Command, host, user, password *string, port *int) {
+	flags := cmd.Flags()
+
+	flags.StringVar(host, FlagSFTPHost, "", "SFTP server host")
+	flags.IntVar(port, FlagSFTPPort, 0, "SFTP server port (rclone's default of 22 if unset)")
+	flags.StringVar(user, FlagSFTPUser, "", "SFTP user name")
+	flags.StringVar(password, FlagSFTPPassword, "",
+		"SFTP password (prefer env "+envSFTPPassword+")")
+	flags.String(FlagSFTPKeyFile, "", "Path to a PEM-encoded SSH private key file for SFTP")
+}
+
+func setWebDAVFlags(cmd *cobra.Command, webdavURL, vendor, user, password, bearerToken *string) {
+	flags := cmd.Flags()
+
+	flags.StringVar(webdavURL, FlagWebDAVURL, "", "WebDAV server URL")
+	flags.StringVar(vendor, FlagWebDAVVendor, rclone.DefaultWebDAVVendor,
+		"WebDAV server vendor, one of "+strings.Join(rclone.WebDAVVendors, ", "))
+	flags.StringVar(user, FlagWebDAVUser, "", "WebDAV user name")
+	flags.StringVar(password, FlagWebDAVPassword, "",
+		"WebDAV password (prefer env "+envWebDAVPassword+")")
+	flags.StringVar(bearerToken, FlagWebDAVBearerToken, "",
+		"WebDAV bearer token, instead of a user and password (prefer env "+envWebDAVBearerToken+")")
+}
+
+func setSwiftFlags(cmd *cobra.Command, authURL, user, key, tenant, domain *string) {
+	flags := cmd.Flags()
+
+	flags.StringVar(authURL, FlagSwiftAuthURL, "", "Swift (OpenStack Keystone) authentication URL")
+	flags.StringVar(user, FlagSwiftUser, "", "Swift user name")
+	flags.StringVar(key, FlagSwiftKey, "", "Swift API key or password (prefer env "+envSwiftKey+")")
+	flags.StringVar(tenant, FlagSwiftTenant, "", "Swift tenant (project) name")
+	flags.StringVar(domain, FlagSwiftDomain, "", "Swift user domain name (Keystone v3)")
+}
+
+func setB2Flags(cmd *cobra.Command, account, key *string) {
+	flags := cmd.Flags()
+
+	flags.StringVar(account, FlagB2Account, "", "Backblaze B2 account or application key ID")
+	flags.StringVar(key, FlagB2Key, "", "Backblaze B2 application key (prefer env "+envB2Key+")")
+}
+
+func setCredentialsSecretFlag(cmd *cobra.Command, credentialsSecret *string) {
+	cmd.Flags().StringVar(credentialsSecret, FlagCredentialsSecret, "",
+		"Name of a secret in the PVC's namespace holding the backend credentials. "+
+			"Each key is passed to rclone as RCLONE_CONFIG_<REMOTE>_<KEY>, e.g. SECRET_ACCESS_KEY")
+}
+
+// setCredentialsClusterFlags registers the flags that read the credentials secret
+// from another cluster than the PVC's, such as the one the backups are taken in.
+func setCredentialsClusterFlags(cmd *cobra.Command, kubeconfig, kubeContext, namespace *string) {
+	flags := cmd.Flags()
+
+	flags.StringVar(kubeconfig, FlagCredentialsKubeconfig, "",
+		"Path to the kubeconfig file of the cluster to read --credentials-secret from (default: the PVC's)")
+	flags.StringVar(kubeContext, FlagCredentialsContext, "",
+		"Kubernetes context of the cluster to read --credentials-secret from "+
+			"(default: the PVC's, or the current one of --credentials-kubeconfig)")
+	flags.StringVar(namespace, FlagCredentialsNamespace, "",
+		"Namespace to read --credentials-secret from in that cluster (default: the namespace of its context)")
+}
+
+func setWorkloadIdentityFlags(
+	cmd *cobra.Command,
+	serviceAccount *string,
+	annotations *map[string]string,
+	awsRoleARN, azureClientID, azureTenantID, gcpServiceAccount *string,
+	envAuth *bool,
+) {
+	flags := cmd.Flags()
+
+	flags.StringVar(serviceAccount, FlagServiceAccount, "",
+		"Existing service account in the PVC's namespace to run the rclone job as, "+
+			"instead of the one pv-migrate creates")
+	flags.StringToStringVar(annotations, FlagServiceAccountAnnotations, nil,
+		"Annotations to set on the service account pv-migrate creates for the rclone job")
+	flags.StringVar(awsRoleARN, FlagAWSRoleARN, "",
+		"IAM role for the rclone job to assume via IRSA on EKS (s3 backend)")
+	flags.StringVar(azureClientID, FlagAzureClientID, "",
+		"Client ID of the managed identity for Azure Workload Identity (azure backend)")
+	flags.StringVar(azureTenantID, FlagAzureTenantID, "",
+		"Tenant ID of the managed identity, if it differs from the cluster's (azure backend)")
+	flags.StringVar(gcpServiceAccount, FlagGCPServiceAccount, "",
+		"Google service account for GKE Workload Identity (gcs backend)")
+	flags.BoolVar(envAuth, FlagEnvAuth, false,
+		"Use what the node provides (e.g. an EC2 instance profile) without credentials or workload identity, "+
+			"instead of warning about it")
+}
+
+func setRawConfigFlags(cmd *cobra.Command, rcloneConfig, rcloneConfigRemote, remote *string) {
 	flags := cmd.Flags()
 
 	flags.StringVar(rcloneConfig, FlagRcloneConfig, "",
 		"Path to a raw rclone.conf file (overrides --backend and credential flags)")
+	flags.StringVar(rcloneConfigRemote, FlagRcloneConfigRemote, "",
+		"Name of a remote in --rclone-config to use with --bucket, --prefix and --name, "+
+			"keeping the managed layout and metadata")
 	flags.StringVar(remote, FlagRemote, "",
 		"Remote spec for raw config mode (e.g., myremote:bucket/path)")
 }
 
+func setRcloneBWLimitFlag(cmd *cobra.Command, bwLimit *string) {
+	cmd.Flags().StringVar(bwLimit, FlagBWLimit, "",
+		"Bandwidth limit for rclone, such as 10M, or a timetable such as \"08:00,512k 18:00,off\" (rclone --bwlimit)")
+}
+
 func setRestoreDeleteFlags(cmd *cobra.Command, deleteExtraneousFiles *bool) {
 	cmd.Flags().BoolVarP(deleteExtraneousFiles, FlagDeleteExtraneousFiles, "d", false,
 		"Delete extraneous files on the destination using rclone sync instead of copy")
 }
 
-func setBucketStorageFlagCompletions(cmd *cobra.Command) error {
+// setBucketStorageFlagCompletions registers the completions of the backend flags,
+// whose names carry flagPrefix when they belong to the destination of a copy.
+func setBucketStorageFlagCompletions(cmd *cobra.Command, flagPrefix string) error {
 	s3Providers := []string{"AWS", "Other", "Minio", "Ceph", "Cloudflare", "DigitalOcean", "Scaleway", "Wasabi"}
 
-	if err := cmd.RegisterFlagCompletionFunc(FlagS3Provider, buildStaticSliceCompletionFunc(s3Providers)); err != nil {
-		return fmt.Errorf("failed to register completion for flag %q: %w", FlagS3Provider, err)
+	completions := []struct {
+		flag string
+		fn   cobra.CompletionFunc
+	}{
+		{FlagBackend, buildStaticSliceCompletionFunc(rclone.Backends)},
+		{FlagS3Provider, buildStaticSliceCompletionFunc(s3Providers)},
+		{FlagWebDAVVendor, buildStaticSliceCompletionFunc(rclone.WebDAVVendors)},
+	}
+
+	for _, c := range completions {
+		if err := cmd.RegisterFlagCompletionFunc(flagPrefix+c.flag, c.fn); err != nil {
+			return fmt.Errorf("failed to register completion for flag %q: %w", flagPrefix+c.flag, err)
+		}
 	}
 
 	return nil
@@ -309,6 +648,20 @@ func applyBucketStorageEnvDefaults(accessKey, secretKey, storageAccount, storage
 	setStringFromEnvIfEmpty(gcsServiceAccountJSON, envGCSServiceAccountJSON)
 }
 
+// applyBackendSecretEnvDefaults fills the credentials of the sftp, webdav, swift
+// and b2 backends from the environment, the same way applyBucketStorageEnvDefaults
+// does for the others.
+func applyBackendSecretEnvDefaults(
+	sftpPassword, webdavPassword, webdavBearerToken, swiftKey, b2Account, b2Key *string,
+) {
+	setStringFromEnvIfEmpty(sftpPassword, envSFTPPassword)
+	setStringFromEnvIfEmpty(webdavPassword, envWebDAVPassword)
+	setStringFromEnvIfEmpty(webdavBearerToken, envWebDAVBearerToken)
+	setStringFromEnvIfEmpty(swiftKey, envSwiftKey)
+	setStringFromEnvIfEmpty(b2Account, envB2Account)
+	setStringFromEnvIfEmpty(b2Key, envB2Key)
+}
+
 func setStringFromEnvIfEmpty(target *string, envName string) {
 	if *target != "" {
 		return
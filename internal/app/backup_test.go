@@ -3,6 +3,8 @@ package app_test
 import (
 	"context"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -59,6 +61,84 @@ func TestRestoreCmd_UsesDestinationFlags(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to read rclone config file")
 }
 
+func TestRestoreCmd_ReadsFilterFile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content string
+		wantErr string
+	}{
+		"invalid rule": {
+			content: "# keep the database\ndb/**\n",
+			wantErr: `invalid filter file: line 2: invalid filter rule "db/**"`,
+		},
+		"no rules": {content: "# nothing yet\n", wantErr: "the filter file holds no rules"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			filterFile := filepath.Join(t.TempDir(), "filter.txt")
+			require.NoError(t, os.WriteFile(filterFile, []byte(tt.content), 0o600))
+
+			cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+			require.NoError(t, err)
+
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			cmd.SetArgs([]string{
+				"restore",
+				"--dest", "test-pvc",
+				"--dest-kubeconfig", "/tmp/missing-kubeconfig",
+				"--backend", "s3",
+				"--bucket", "bucket",
+				"--name", "db",
+				"--filter-from", filterFile,
+			})
+
+			err = cmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRestoreCmd_KeepsCommasInPatterns(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"restore",
+		"--dest", "test-pvc",
+		"--dest-kubeconfig", "/tmp/missing-kubeconfig",
+		"--backend", "s3",
+		"--bucket", "bucket",
+		"--name", "db",
+		"--access-key", "AKID",
+		"--secret-key", "SKEY",
+		"--include", "*.{jpg,png}",
+		"--exclude", "cache/**",
+	})
+
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--include and --exclude cannot be used together")
+
+	restoreCmd, _, err := cmd.Find([]string{"restore"})
+	require.NoError(t, err)
+
+	include, err := restoreCmd.Flags().GetStringArray("include")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.{jpg,png}"}, include)
+}
+
 func TestRestoreCmd_RequiresDestination(t *testing.T) {
 	t.Parallel()
 
@@ -77,7 +157,7 @@ func TestRestoreCmd_RequiresDestination(t *testing.T) {
 
 	err = cmd.Execute()
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), `required flag(s) "dest" not set`)
+	assert.Contains(t, err.Error(), "at least one of the flags in the group [dest dest-selector] is required")
 }
 
 func TestApplyBucketStorageEnvDefaults(t *testing.T) {
@@ -112,3 +192,74 @@ func TestApplyBucketStorageEnvDefaults_DoesNotOverrideExplicitValues(t *testing.
 	assert.Equal(t, "flag-access", accessKey)
 	assert.Equal(t, "flag-secret", secretKey)
 }
+
+func TestApplyBackendSecretEnvDefaults(t *testing.T) {
+	t.Setenv(app.EnvSFTPPassword, "sftp")
+	t.Setenv(app.EnvWebDAVPassword, "webdav")
+	t.Setenv(app.EnvWebDAVBearerToken, "token")
+	t.Setenv(app.EnvSwiftKey, "swift")
+	t.Setenv(app.EnvB2Account, "b2-account")
+	t.Setenv(app.EnvB2Key, "b2-key")
+
+	sftpPassword := "flag-sftp"
+
+	var webdavPassword, webdavBearerToken, swiftKey, b2Account, b2Key string
+
+	app.ApplyBackendSecretEnvDefaults(&sftpPassword, &webdavPassword, &webdavBearerToken,
+		&swiftKey, &b2Account, &b2Key)
+
+	assert.Equal(t, "flag-sftp", sftpPassword)
+	assert.Equal(t, "webdav", webdavPassword)
+	assert.Equal(t, "token", webdavBearerToken)
+	assert.Equal(t, "swift", swiftKey)
+	assert.Equal(t, "b2-account", b2Account)
+	assert.Equal(t, "b2-key", b2Key)
+}
+
+func TestBackupCmd_SFTPBackendValidatedBeforeContactingCluster(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup",
+		"--source", "test-pvc",
+		"--source-kubeconfig", "/tmp/missing-kubeconfig",
+		"--backend", "sftp",
+		"--sftp-host", "backup.example.com",
+		"--bucket", "backups",
+		"--name", "app",
+	})
+
+	err = cmd.Execute()
+	require.ErrorContains(t, err, "either --sftp-password or --sftp-key-file must be provided")
+}
+
+func TestBackupCmd_IdentityFlagMustMatchBackend(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup",
+		"--source", "test-pvc",
+		"--source-kubeconfig", "/tmp/missing-kubeconfig",
+		"--backend", "gcs",
+		"--bucket", "backups",
+		"--name", "app",
+		"--aws-role-arn", "arn:aws:iam::123456789012:role/backup",
+	})
+
+	err = cmd.Execute()
+	require.ErrorContains(t, err, "--aws-role-arn only applies to the s3 backend")
+}
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/utkuozdemir/pv-migrate/pvmigrate"
+)
+
+const (
+	FlagKubeconfig = "kubeconfig"
+	FlagContext    = "context"
+	FlagNamespace  = "namespace"
+
+	// copyDestFlagPrefix names the flags of the destination of a copy after the
+	// backend flags of backup, and copyDestEnvPrefix its environment variables.
+	copyDestFlagPrefix = "to-"
+	copyDestEnvPrefix  = "PV_MIGRATE_TO_"
+	envPrefix          = "PV_MIGRATE_"
+)
+
+// copyJobFlags are backend flags of backup that concern the rclone job instead,
+// so a copy takes them once rather than once for each backend. --path has no
+// meaning without a PVC.
+var copyJobFlags = []string{FlagPath, FlagRcloneExtraArgs, FlagServiceAccount, FlagServiceAccountAnnotations}
+
+func buildBackupsCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:   "backups",
+		Short: "Manage backups in bucket storage",
+		Args:  cobra.NoArgs,
+	}
+
+	copyCmd, err := buildBackupsCopyCmd(logger, imageTag, chartVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	verifyCmd, err := buildBackupsVerifyCmd(logger, imageTag, chartVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.AddCommand(copyCmd, verifyCmd)
+
+	return cmd, nil
+}
+
+func buildBackupsCopyCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobra.Command, error) {
+	backupCopy := pvmigrate.Copy{
+		ImageTag:     imageTag,
+		ChartVersion: chartVersion,
+	}
+	sourceGCSBucketPolicyOnly := true
+	destGCSBucketPolicyOnly := true
+
+	cmd := &cobra.Command{
+		Use:   "copy --backend <backend> --bucket <bucket> --name <name> --to-backend <backend> --to-bucket <bucket>",
+		Short: "Copy a backup to another bucket or backend",
+		Long: "Copy a backup, its data and then its metadata, from one bucket storage backend to another, " +
+			"e.g. from S3 to GCS, with an rclone job in the cluster. The source takes the backend flags of backup. " +
+			"The destination takes the same flags prefixed with --" + copyDestFlagPrefix +
+			", and reads its credentials from the environment variables prefixed with " + copyDestEnvPrefix +
+			" instead of " + envPrefix + ".",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			backupCopy.Source.GCSBucketPolicyOnly = &sourceGCSBucketPolicyOnly
+			backupCopy.Dest.GCSBucketPolicyOnly = &destGCSBucketPolicyOnly
+
+			return runBackupsCopy(cmd, &backupCopy, *logger)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&backupCopy.KubeconfigPath, FlagKubeconfig, "k", "", "Path to the kubeconfig file")
+	flags.StringVarP(&backupCopy.Context, FlagContext, "c", "", "Kubernetes context to use")
+	flags.StringVarP(&backupCopy.Namespace, FlagNamespace, "n", "", "Namespace to run the copy job in")
+	flags.StringVar(&backupCopy.RcloneExtraArgs, FlagRcloneExtraArgs, "",
+		"Extra rclone flags appended after the built-in progress flags (use at your own risk)")
+	flags.StringVar(&backupCopy.ServiceAccountName, FlagServiceAccount, "",
+		"Existing service account to run the rclone job as, instead of the one pv-migrate creates")
+	flags.StringToStringVar(&backupCopy.ServiceAccountAnnotations, FlagServiceAccountAnnotations, nil,
+		"Annotations to set on the service account pv-migrate creates for the rclone job")
+
+	setOperationFlags(cmd, &backupCopy.ID, &backupCopy.NonRoot,
+		&backupCopy.Detach, &backupCopy.NoCleanup, &backupCopy.NoCleanupOnFailure,
+		&backupCopy.HelmTimeout, &backupCopy.HelmValuesFiles, &backupCopy.HelmValues,
+		&backupCopy.HelmStringValues, &backupCopy.HelmFileValues)
+
+	setCopyStorageFlags(cmd, &backupCopy.Source, &sourceGCSBucketPolicyOnly, "", envPrefix,
+		"Name of the backup to copy")
+	setCopyStorageFlags(cmd, &backupCopy.Dest, &destGCSBucketPolicyOnly, copyDestFlagPrefix, copyDestEnvPrefix,
+		"Name of the copy (default: the value of --"+FlagName+")")
+
+	for _, flag := range []string{
+		FlagBackend, FlagBucket, FlagName, copyDestFlagPrefix + FlagBackend, copyDestFlagPrefix + FlagBucket,
+	} {
+		if err := cmd.MarkFlagRequired(flag); err != nil {
+			return nil, fmt.Errorf("failed to mark flag %q as required: %w", flag, err)
+		}
+	}
+
+	for _, flagPrefix := range []string{"", copyDestFlagPrefix} {
+		if err := setBucketStorageFlagCompletions(cmd, flagPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmd, nil
+}
+
+func buildBackupsVerifyCmd(logger **slog.Logger, imageTag, chartVersion string) (*cobra.Command, error) {
+	restore := pvmigrate.Restore{
+		ImageTag:     imageTag,
+		ChartVersion: chartVersion,
+	}
+	gcsBucketPolicyOnly := true
+
+	cmd := &cobra.Command{
+		Use:   "verify --dest <pvc-name> --backend <backend> --bucket <bucket>",
+		Short: "Check a PVC against a backup",
+		Long: "Check that a PVC holds the files of a backup, by size or checksum, with rclone check in a job " +
+			"that mounts the PVC read-only. It takes the flags of restore and reports the paths that differ, " +
+			"leaving out the files the PVC has on top of the backup.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			restore.GCSBucketPolicyOnly = &gcsBucketPolicyOnly
+
+			return runBackupsVerify(cmd, &restore, *logger)
+		},
+	}
+
+	setRestoreFlags(cmd, &restore, &gcsBucketPolicyOnly)
+
+	if err := setVerifyModeFlag(cmd, &restore.VerifyMode); err != nil {
+		return nil, err
+	}
+
+	if err := setBucketStorageFlagCompletions(cmd, ""); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+func runBackupsVerify(cmd *cobra.Command, restore *pvmigrate.Restore, logger *slog.Logger) error {
+	if err := prepareRestore(cmd, restore, logger); err != nil {
+		return err
+	}
+
+	logger.Info("🔍 Starting verification")
+
+	return pvmigrate.RunVerify(cmd.Context(), *restore)
+}
+
+// setCopyStorageFlags registers the backend flags of backup for one side of a
+// copy, named with flagPrefix and pointing at the environment variables named
+// with envVarPrefix. The flags about the job rather than the backend are left out.
+func setCopyStorageFlags(
+	cmd *cobra.Command,
+	storage *pvmigrate.Storage,
+	gcsBucketPolicyOnly *bool,
+	flagPrefix, envVarPrefix, nameUsage string,
+) {
+	var job pvmigrate.Backup
+
+	backend := &cobra.Command{}
+
+	setBucketStorageFlags(
+		backend,
+		&storage.Backend, &storage.Bucket, &storage.S3Provider, &storage.Endpoint, &storage.Region,
+		&storage.AccessKey, &storage.SecretKey, &storage.StorageAccount, &storage.StorageKey,
+		gcsBucketPolicyOnly, &storage.Name, &storage.Prefix, &job.Path, &job.RcloneExtraArgs,
+	)
+
+	setSFTPFlags(backend, &storage.SFTPHost, &storage.SFTPUser, &storage.SFTPPassword, &storage.SFTPPort)
+	setWebDAVFlags(backend, &storage.WebDAVURL, &storage.WebDAVVendor, &storage.WebDAVUser,
+		&storage.WebDAVPassword, &storage.WebDAVBearerToken)
+	setSwiftFlags(backend, &storage.SwiftAuthURL, &storage.SwiftUser, &storage.SwiftKey,
+		&storage.SwiftTenant, &storage.SwiftDomain)
+	setB2Flags(backend, &storage.B2Account, &storage.B2Key)
+	setCredentialsSecretFlag(backend, &storage.CredentialsSecret)
+	setWorkloadIdentityFlags(backend, &job.ServiceAccountName, &job.ServiceAccountAnnotations,
+		&storage.AWSRoleARN, &storage.AzureClientID, &storage.AzureTenantID, &storage.GCPServiceAccount,
+		&storage.EnvAuth)
+
+	backend.Flags().VisitAll(func(flag *pflag.Flag) {
+		if slices.Contains(copyJobFlags, flag.Name) {
+			return
+		}
+
+		prefixed := *flag
+		prefixed.Name = flagPrefix + flag.Name
+		prefixed.Shorthand = ""
+		prefixed.Usage = strings.NewReplacer(envPrefix, envVarPrefix, "the PVC's namespace", "the job's namespace").
+			Replace(flag.Usage)
+
+		if flag.Name == FlagName {
+			prefixed.Usage = nameUsage
+		}
+
+		cmd.Flags().AddFlag(&prefixed)
+	})
+}
+
+func runBackupsCopy(cmd *cobra.Command, backupCopy *pvmigrate.Copy, logger *slog.Logger) error {
+	ctx := cmd.Context()
+	backupCopy.Writer = cmd.ErrOrStderr()
+	backupCopy.Logger = logger
+	backupCopy.StructuredLogs = structuredLogsRequested(cmd)
+	backupCopy.ColorOutput = colorOutputWanted(cmd, backupCopy.Writer)
+
+	if err := resolveCopyCredentials(cmd, &backupCopy.Source, "", envPrefix); err != nil {
+		return err
+	}
+
+	if err := resolveCopyCredentials(cmd, &backupCopy.Dest, copyDestFlagPrefix, copyDestEnvPrefix); err != nil {
+		return err
+	}
+
+	logger.Info("📦 Starting copy")
+
+	return pvmigrate.RunCopy(ctx, *backupCopy)
+}
+
+// resolveCopyCredentials does for one side of a copy what resolveBackupCredentials
+// does for a backup, with the flag and environment variable names of that side.
+func resolveCopyCredentials(cmd *cobra.Command, storage *pvmigrate.Storage, flagPrefix, envVarPrefix string) error {
+	if err := readFileFlag(cmd, flagPrefix+FlagGCSServiceAccountFile, "GCS service account file",
+		&storage.GCSServiceAccountJSON); err != nil {
+		return err
+	}
+
+	if err := readFileFlag(cmd, flagPrefix+FlagSFTPKeyFile, "SFTP key file", &storage.SFTPKeyPEM); err != nil {
+		return err
+	}
+
+	for _, credential := range []struct {
+		target *string
+		env    string
+	}{
+		{&storage.AccessKey, envS3AccessKey},
+		{&storage.SecretKey, envS3SecretKey},
+		{&storage.StorageAccount, envAzureStorageAccount},
+		{&storage.StorageKey, envAzureStorageKey},
+		{&storage.GCSServiceAccountJSON, envGCSServiceAccountJSON},
+		{&storage.SFTPPassword, envSFTPPassword},
+		{&storage.WebDAVPassword, envWebDAVPassword},
+		{&storage.WebDAVBearerToken, envWebDAVBearerToken},
+		{&storage.SwiftKey, envSwiftKey},
+		{&storage.B2Account, envB2Account},
+		{&storage.B2Key, envB2Key},
+	} {
+		setStringFromEnvIfEmpty(credential.target, envVarPrefix+strings.TrimPrefix(credential.env, envPrefix))
+	}
+
+	return nil
+}
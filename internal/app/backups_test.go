@@ -0,0 +1,169 @@
+package app_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+func TestBackupsCopyCmd_RequiresDestination(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backups", "copy",
+		"--backend", "s3",
+		"--bucket", "source-bucket",
+		"--name", "db",
+	})
+
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `required flag(s) "to-backend", "to-bucket" not set`)
+}
+
+func TestBackupsCopyCmd_ReadsDestinationFlags(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backups", "copy",
+		"--kubeconfig", "/tmp/missing-kubeconfig",
+		"--backend", "s3",
+		"--bucket", "source-bucket",
+		"--name", "db",
+		"--access-key", "AKID",
+		"--secret-key", "SKEY",
+		"--to-backend", "gcs",
+		"--to-bucket", "dest-bucket",
+		"--to-gcs-service-account-file", "/tmp/missing-service-account.json",
+	})
+
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read GCS service account file /tmp/missing-service-account.json")
+}
+
+func TestBackupsCopyCmd_ValidatesDestination(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backups", "copy",
+		"--kubeconfig", "/tmp/missing-kubeconfig",
+		"--backend", "s3",
+		"--bucket", "source-bucket",
+		"--name", "db",
+		"--access-key", "AKID",
+		"--secret-key", "SKEY",
+		"--to-backend", "gcs",
+		"--to-bucket", "dest-bucket",
+		"--to-aws-role-arn", "arn:aws:iam::123456789012:role/backup",
+	})
+
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "destination: --aws-role-arn only applies to the s3 backend")
+}
+
+func TestBackupsVerifyCmd_TakesNoDeleteFlag(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backups", "verify",
+		"--dest", "data",
+		"--backend", "s3",
+		"--bucket", "bucket",
+		"--name", "db",
+		"--delete-extraneous-files",
+	})
+
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown flag: --delete-extraneous-files")
+}
+
+func TestBackupsVerifyCmd_ValidatesMode(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backups", "verify",
+		"--dest-kubeconfig", "/tmp/missing-kubeconfig",
+		"--dest", "data",
+		"--backend", "s3",
+		"--bucket", "bucket",
+		"--name", "db",
+		"--access-key", "AKID",
+		"--secret-key", "SKEY",
+		"--verify-mode", "bytes",
+	})
+
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `verify failed: invalid verification mode: "bytes"`)
+}
+
+func TestRestoreCmd_RejectsVerifiedDryRun(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"restore",
+		"--dest-kubeconfig", "/tmp/missing-kubeconfig",
+		"--dest", "data",
+		"--backend", "s3",
+		"--bucket", "bucket",
+		"--name", "db",
+		"--access-key", "AKID",
+		"--secret-key", "SKEY",
+		"--verify",
+		"--rclone-extra-args=--dry-run",
+	})
+
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a restore cannot be verified when rclone only does a dry run")
+}
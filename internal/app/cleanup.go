@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -14,6 +15,7 @@ import (
 	"helm.sh/helm/v4/pkg/kube"
 	"helm.sh/helm/v4/pkg/release"
 	"helm.sh/helm/v4/pkg/storage/driver"
+	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -21,26 +23,93 @@ import (
 	"github.com/utkuozdemir/pv-migrate/internal/opid"
 )
 
+// cleanupOptions say which of the operations found a cleanup removes, and how.
+type cleanupOptions struct {
+	// olderThan, succeededOnly and failedOnly narrow the cleanup down, for running
+	// it as a janitor. An operation still running is skipped once they are set,
+	// rather than failing the cleanup of the others.
+	olderThan     time.Duration
+	succeededOnly bool
+	failedOnly    bool
+
+	force  bool
+	dryRun bool
+}
+
+func (o *cleanupOptions) filtered() bool {
+	return o.olderThan > 0 || o.succeededOnly || o.failedOnly
+}
+
+func (o *cleanupOptions) validate() error {
+	if o.succeededOnly && o.failedOnly {
+		return errors.New("--succeeded-only and --failed-only cannot be used together")
+	}
+
+	if o.olderThan < 0 {
+		return errors.New("--older-than cannot be negative")
+	}
+
+	return nil
+}
+
+// cleanupOperation is the releases of one operation, in every cluster, with the
+// jobs they hold.
+type cleanupOperation struct {
+	id           string
+	releases     []cleanupRelease
+	jobs         []batchv1.Job
+	lastDeployed time.Time
+}
+
+type cleanupRelease struct {
+	cluster   *cluster
+	name      string
+	namespace string
+}
+
+// state is the state of the newest job of the operation, or empty when it has
+// none, as a migration whose data mover never started.
+func (o *cleanupOperation) state() string {
+	var newest *batchv1.Job
+
+	for i := range o.jobs {
+		if newest == nil || newest.CreationTimestamp.Before(&o.jobs[i].CreationTimestamp) {
+			newest = &o.jobs[i]
+		}
+	}
+
+	if newest == nil {
+		return ""
+	}
+
+	return jobState(newest)
+}
+
 func buildCleanupCmd(logger **slog.Logger) *cobra.Command {
 	var (
-		kubeconfig  string
-		kubeContext string
-		namespace   string
-		all         bool
-		force       bool
+		kubeconfig string
+		contexts   []string
+		namespace  string
+		all        bool
+		options    cleanupOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "cleanup [operation-id]",
 		Short: "Clean up resources from a detached operation",
 		Long: "Remove Helm releases created by a detached operation. " +
-			"Provide the operation ID printed by --detach, or use --all to remove all pv-migrate releases.",
+			"Provide the operation ID printed by --detach, or use --all to remove all pv-migrate releases. " +
+			"--older-than, --succeeded-only and --failed-only narrow the cleanup down to the operations they match.",
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !all && len(args) == 0 {
 				return errors.New("provide an operation ID or use --all")
 			}
 
+			if err := options.validate(); err != nil {
+				return err
+			}
+
 			var filterPrefix string
 			if all {
 				filterPrefix = opid.ReleasePrefix
@@ -56,21 +125,28 @@ func buildCleanupCmd(logger **slog.Logger) *cobra.Command {
 				cmd.Context(),
 				*logger,
 				kubeconfig,
-				kubeContext,
+				contexts,
 				namespace,
 				filterPrefix,
 				all,
-				force,
+				&options,
 			)
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
-	flags.StringVar(&kubeContext, "context", "", "Kubernetes context to use")
+	addContextsFlag(flags, &contexts, "Kubernetes context to clean up in, "+
+		"repeat it for the clusters of a migration between clusters")
 	flags.StringVarP(&namespace, "namespace", "n", "", "Namespace to search for releases (default: all namespaces)")
 	flags.BoolVar(&all, "all", false, "Remove all pv-migrate releases")
-	flags.BoolVar(&force, "force", false, "Clean up even if the operation is still running")
+	flags.BoolVar(&options.force, "force", false,
+		"Clean up even if the operation is still running, or without a job to tell whether it is")
+	flags.DurationVar(&options.olderThan, "older-than", 0,
+		"Only clean up operations whose releases were all installed at least this long ago, such as 24h")
+	flags.BoolVar(&options.succeededOnly, "succeeded-only", false, "Only clean up operations whose job succeeded")
+	flags.BoolVar(&options.failedOnly, "failed-only", false, "Only clean up operations whose job failed")
+	flags.BoolVar(&options.dryRun, "dry-run", false, "List the releases that would be removed, without removing them")
 
 	return cmd
 }
@@ -78,33 +154,29 @@ func buildCleanupCmd(logger **slog.Logger) *cobra.Command {
 func runCleanup(
 	ctx context.Context,
 	logger *slog.Logger,
-	kubeconfig, kubeContext, namespace, filterPrefix string,
-	all, force bool,
+	kubeconfig string,
+	contexts []string,
+	namespace, filterPrefix string,
+	all bool,
+	options *cleanupOptions,
 ) error {
-	client, err := k8s.GetClusterClient(kubeconfig, kubeContext, logger)
+	clusters, err := connectClusters(kubeconfig, contexts, logger)
 	if err != nil {
 		return err
 	}
 
-	searchNs := namespace
-	allNamespaces := namespace == ""
-
-	ac := new(action.Configuration)
-	if err := ac.Init(client.RESTClientGetter, searchNs, os.Getenv("HELM_DRIVER")); err != nil {
-		return fmt.Errorf("failed to initialize helm: %w", err)
-	}
-
-	list := action.NewList(ac)
-	list.Filter = "^" + regexp.QuoteMeta(filterPrefix)
-	list.AllNamespaces = allNamespaces
-	list.StateMask = action.ListAll
+	// Every cluster is checked before anything is removed, so that a running
+	// operation is left whole rather than losing the half in one of them.
+	var operations []cleanupOperation
 
-	releases, err := list.Run()
-	if err != nil {
-		return fmt.Errorf("failed to list releases: %w", err)
+	for i := range clusters {
+		operations, err = collectCleanupOperations(ctx, &clusters[i], namespace, filterPrefix, operations)
+		if err != nil {
+			return contextError(clusters[i].context, err)
+		}
 	}
 
-	if len(releases) == 0 {
+	if len(operations) == 0 {
 		if all {
 			logger.Info("No pv-migrate releases found")
 
@@ -114,58 +186,181 @@ func runCleanup(
 		return fmt.Errorf("no releases found matching %q", filterPrefix)
 	}
 
-	logger.Info("Found releases to clean up", "count", len(releases))
+	if options.filtered() {
+		operations = selectForCleanup(operations, options, time.Now(), logger)
+		if len(operations) == 0 {
+			logger.Info("No operation matches the filters")
+
+			return nil
+		}
+	}
+
+	count := 0
+	for i := range operations {
+		count += len(operations[i].releases)
+	}
+
+	logger.Info("Found releases to clean up", "count", count)
 
-	if !force {
-		if err = checkNoActiveJobs(ctx, client.KubeClient, releases, logger); err != nil {
+	if !options.force && !options.filtered() {
+		if err = checkNoActiveJobs(operations); err != nil {
 			return err
 		}
+
+		logger.Info("No active jobs found, proceeding with cleanup")
 	}
 
-	return uninstallReleases(releases, client, logger)
+	return uninstallReleases(operations, options.dryRun, len(clusters) > 1, logger)
 }
 
-func checkNoActiveJobs(
-	ctx context.Context, cli kubernetes.Interface, releases []release.Releaser, logger *slog.Logger,
-) error {
+// listReleases lists the releases in any state whose name starts with
+// filterPrefix, in namespace or in all namespaces when it is empty.
+func listReleases(client *k8s.ClusterClient, namespace, filterPrefix string) ([]release.Releaser, error) {
+	ac := new(action.Configuration)
+	if err := ac.Init(client.RESTClientGetter, namespace, os.Getenv("HELM_DRIVER")); err != nil {
+		return nil, fmt.Errorf("failed to initialize helm: %w", err)
+	}
+
+	list := action.NewList(ac)
+	list.Filter = "^" + regexp.QuoteMeta(filterPrefix)
+	list.AllNamespaces = namespace == ""
+	list.StateMask = action.ListAll
+
+	releases, err := list.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	return releases, nil
+}
+
+// collectCleanupOperations adds the releases in one cluster to the operations
+// they belong to, along with the jobs of each release.
+func collectCleanupOperations(
+	ctx context.Context, cluster *cluster, namespace, filterPrefix string, operations []cleanupOperation,
+) ([]cleanupOperation, error) {
+	releases, err := listReleases(cluster.client, namespace, filterPrefix)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, rel := range releases {
 		acc, err := release.NewAccessor(rel)
 		if err != nil {
 			continue
 		}
 
-		jobs, err := cli.BatchV1().Jobs(acc.Namespace()).List(ctx, metav1.ListOptions{
-			LabelSelector: "app.kubernetes.io/instance=" + acc.Name(),
-		})
+		jobs, err := releaseJobs(ctx, cluster.client.KubeClient, acc.Namespace(), acc.Name())
 		if err != nil {
-			return fmt.Errorf("failed to list jobs for release %s: %w", acc.Name(), err)
+			return nil, err
 		}
 
-		for i := range jobs.Items {
-			if jobs.Items[i].Status.Active > 0 {
-				return fmt.Errorf("operation job %s/%s is still running; use --force to clean up anyway",
-					jobs.Items[i].Namespace, jobs.Items[i].Name)
-			}
+		// A release not named like an operation's is an operation of its own.
+		id := acc.Name()
+		if parsed, ok := opid.ParseReleaseName(acc.Name()); ok {
+			id = parsed.ID
+		}
+
+		i := slices.IndexFunc(operations, func(o cleanupOperation) bool { return o.id == id })
+		if i < 0 {
+			i = len(operations)
+			operations = append(operations, cleanupOperation{id: id})
+		}
+
+		operation := &operations[i]
+		operation.releases = append(operation.releases,
+			cleanupRelease{cluster: cluster, name: acc.Name(), namespace: acc.Namespace()})
+		operation.jobs = append(operation.jobs, jobs...)
+
+		if acc.DeployedAt().After(operation.lastDeployed) {
+			operation.lastDeployed = acc.DeployedAt()
 		}
 	}
 
-	logger.Info("No active jobs found, proceeding with cleanup")
+	return operations, nil
+}
 
-	return nil
+func releaseJobs(ctx context.Context, cli kubernetes.Interface, namespace, releaseName string) ([]batchv1.Job, error) {
+	jobs, err := cli.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: instanceLabel + "=" + releaseName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs for release %s: %w", releaseName, err)
+	}
+
+	return jobs.Items, nil
 }
 
-func uninstallReleases(releases []release.Releaser, client *k8s.ClusterClient, logger *slog.Logger) error {
-	for _, rel := range releases {
-		acc, err := release.NewAccessor(rel)
-		if err != nil {
+// selectForCleanup keeps the operations the filters of options match. Age is
+// measured from the release installed last, so that an operation is only
+// removed once all of it is old enough.
+func selectForCleanup(
+	operations []cleanupOperation, options *cleanupOptions, now time.Time, logger *slog.Logger,
+) []cleanupOperation {
+	var selected []cleanupOperation
+
+	for _, operation := range operations {
+		state := operation.state()
+
+		switch {
+		case options.olderThan > 0 && now.Sub(operation.lastDeployed) < options.olderThan:
+			continue
+		case options.succeededOnly && state != jobStateSucceeded:
+			continue
+		case options.failedOnly && state != jobStateFailed:
+			continue
+		case (state == jobStateRunning || state == jobStatePending) && !options.force:
+			logger.Info("Skipping operation that is still running", "id", operation.id)
+
+			continue
+		case state == "" && !options.force:
+			// Such as the sshd half of a migration between clusters, whose job
+			// runs in a cluster that was not searched: whether it is done cannot
+			// be told from here.
+			logger.Info("Skipping operation without a job in the searched clusters, its state is unknown",
+				"id", operation.id)
+
 			continue
 		}
 
-		if err = uninstallRelease(acc.Name(), acc.Namespace(), client); err != nil {
-			return err
+		selected = append(selected, operation)
+	}
+
+	return selected
+}
+
+func checkNoActiveJobs(operations []cleanupOperation) error {
+	for _, operation := range operations {
+		for i := range operation.jobs {
+			if operation.jobs[i].Status.Active > 0 {
+				return fmt.Errorf("operation job %s/%s is still running; use --force to clean up anyway",
+					operation.jobs[i].Namespace, operation.jobs[i].Name)
+			}
 		}
+	}
+
+	return nil
+}
+
+func uninstallReleases(operations []cleanupOperation, dryRun, severalClusters bool, logger *slog.Logger) error {
+	for _, operation := range operations {
+		for _, rel := range operation.releases {
+			releaseLogger := rel.cluster.logger(logger, severalClusters)
 
-		logger.Info("Uninstalled release", "release", acc.Name(), "namespace", acc.Namespace())
+			if dryRun {
+				releaseLogger.Info("Would uninstall release", "release", rel.name, "namespace", rel.namespace,
+					"operation", operation.id, "state", operation.state(),
+					"age", time.Since(operation.lastDeployed).Truncate(time.Second).String())
+
+				continue
+			}
+
+			if err := uninstallRelease(rel.name, rel.namespace, rel.cluster.client); err != nil {
+				return contextError(rel.cluster.context, err)
+			}
+
+			releaseLogger.Info("Uninstalled release", "release", rel.name, "namespace", rel.namespace)
+		}
 	}
 
 	return nil
@@ -0,0 +1,76 @@
+package app_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+func TestSelectForCleanup(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 4, 11, 10, 0, 0, 0, time.UTC)
+
+	job := func(created time.Time, status batchv1.JobStatus) batchv1.Job {
+		return batchv1.Job{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}, Status: status}
+	}
+
+	candidates := []app.CleanupCandidate{
+		{ID: "old-succeeded", LastDeployed: now.Add(-48 * time.Hour), Jobs: []batchv1.Job{
+			job(now.Add(-49*time.Hour), batchv1.JobStatus{Failed: 1}),
+			job(now.Add(-48*time.Hour), batchv1.JobStatus{Succeeded: 1}),
+		}},
+		{ID: "old-failed", LastDeployed: now.Add(-30 * time.Hour), Jobs: []batchv1.Job{
+			job(now.Add(-30*time.Hour), batchv1.JobStatus{Failed: 1}),
+		}},
+		{ID: "old-running", LastDeployed: now.Add(-72 * time.Hour), Jobs: []batchv1.Job{
+			job(now.Add(-72*time.Hour), batchv1.JobStatus{Active: 1}),
+		}},
+		{ID: "old-retrying", LastDeployed: now.Add(-26 * time.Hour), Jobs: []batchv1.Job{
+			job(now.Add(-26*time.Hour), batchv1.JobStatus{Failed: 1, Active: 1}),
+		}},
+		{ID: "old-sshd-only", LastDeployed: now.Add(-25 * time.Hour)},
+		{ID: "new-succeeded", LastDeployed: now.Add(-time.Hour), Jobs: []batchv1.Job{
+			job(now.Add(-time.Hour), batchv1.JobStatus{Succeeded: 1}),
+		}},
+	}
+
+	tests := []struct {
+		name          string
+		olderThan     time.Duration
+		succeededOnly bool
+		failedOnly    bool
+		force         bool
+		want          []string
+	}{
+		{
+			name:      "older than",
+			olderThan: 24 * time.Hour,
+			want:      []string{"old-succeeded", "old-failed"},
+		},
+		{
+			name:      "older than with force",
+			olderThan: 24 * time.Hour,
+			force:     true,
+			want:      []string{"old-succeeded", "old-failed", "old-running", "old-retrying", "old-sshd-only"},
+		},
+		{name: "succeeded only", succeededOnly: true, want: []string{"old-succeeded", "new-succeeded"}},
+		{name: "failed only", failedOnly: true, want: []string{"old-failed"}},
+		{name: "old and succeeded", olderThan: 24 * time.Hour, succeededOnly: true, want: []string{"old-succeeded"}},
+		{name: "none old enough", olderThan: 100 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := app.SelectForCleanup(candidates, tt.olderThan, tt.succeededOnly, tt.failedOnly, tt.force, now)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/spf13/pflag"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+)
+
+// cluster is one of the clusters a command given --context more than once works
+// on, such as both sides of a migration between clusters.
+type cluster struct {
+	context string
+	client  *k8s.ClusterClient
+}
+
+// addContextsFlag adds a --context that can be repeated, for commands that find
+// the releases of an operation wherever it installed them.
+func addContextsFlag(flags *pflag.FlagSet, contexts *[]string, usage string) {
+	flags.StringArrayVar(contexts, "context", nil, usage+", can be repeated (default: the current context)")
+}
+
+// connectClusters builds a client for each context, or for the current context
+// when none is given.
+func connectClusters(kubeconfig string, contexts []string, logger *slog.Logger) ([]cluster, error) {
+	if len(contexts) == 0 {
+		contexts = []string{""}
+	}
+
+	clusters := make([]cluster, 0, len(contexts))
+
+	for _, kubeContext := range contexts {
+		if slices.ContainsFunc(clusters, func(c cluster) bool { return c.context == kubeContext }) {
+			continue
+		}
+
+		client, err := k8s.GetClusterClient(kubeconfig, kubeContext, logger)
+		if err != nil {
+			return nil, contextError(kubeContext, err)
+		}
+
+		clusters = append(clusters, cluster{context: kubeContext, client: client})
+	}
+
+	return clusters, nil
+}
+
+// logger adds the context to the records of a command that works on several.
+func (c *cluster) logger(logger *slog.Logger, several bool) *slog.Logger {
+	if !several || c.context == "" {
+		return logger
+	}
+
+	return logger.With("context", c.context)
+}
+
+func contextError(kubeContext string, err error) error {
+	if kubeContext == "" {
+		return err
+	}
+
+	return fmt.Errorf("context %s: %w", kubeContext, err)
+}
@@ -0,0 +1,364 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	FlagConfig  = "config"
+	FlagProfile = "profile"
+
+	// hiddenValue stands in for a credential in config view.
+	hiddenValue = "<hidden>"
+
+	sourceProfile     = "profile"
+	sourceEnvironment = "environment"
+)
+
+// configFile is the file of named profiles, each a set of flag values by flag
+// name, shared by every command.
+type configFile struct {
+	// DefaultProfile is the profile used when --profile is not given.
+	DefaultProfile string                    `json:"defaultProfile,omitempty"`
+	Profiles       map[string]map[string]any `json:"profiles"`
+}
+
+// profileValue is where a flag of a command gets its value from, when not from
+// the command line or its default.
+type profileValue struct {
+	flag   string
+	values []string
+	source string
+}
+
+// credentialEnvVars maps the flags of the credentials that can be given through
+// the environment to their variables. The copy flags of the destination have
+// their own, derived from these.
+var credentialEnvVars = map[string]string{
+	FlagAccessKey:             envS3AccessKey,
+	FlagSecretKey:             envS3SecretKey,
+	FlagStorageAccount:        envAzureStorageAccount,
+	FlagStorageKey:            envAzureStorageKey,
+	FlagGCSServiceAccountFile: envGCSServiceAccountJSON,
+	FlagSFTPPassword:          envSFTPPassword,
+	FlagWebDAVPassword:        envWebDAVPassword,
+	FlagWebDAVBearerToken:     envWebDAVBearerToken,
+	FlagSwiftKey:              envSwiftKey,
+	FlagB2Account:             envB2Account,
+	FlagB2Key:                 envB2Key,
+}
+
+// credentialEnvVar returns the environment variable a credential flag falls
+// back to.
+func credentialEnvVar(flag string) (string, bool) {
+	if env, ok := credentialEnvVars[flag]; ok {
+		return env, true
+	}
+
+	name, ok := strings.CutPrefix(flag, copyDestFlagPrefix)
+	if !ok {
+		return "", false
+	}
+
+	env, ok := credentialEnvVars[name]
+	if !ok {
+		return "", false
+	}
+
+	return copyDestEnvPrefix + strings.TrimPrefix(env, envPrefix), true
+}
+
+// setConfigFlags registers the flags that pick the profile, for every command.
+func setConfigFlags(flags *pflag.FlagSet, configPath, profile *string) {
+	flags.StringVar(configPath, FlagConfig, "",
+		"Path of the config file holding the profiles (default: $XDG_CONFIG_HOME/pv-migrate/config.yaml, "+
+			"or ~/.config/pv-migrate/config.yaml)")
+	flags.StringVar(profile, FlagProfile, "",
+		"Profile of the config file to take flag values from (default: the defaultProfile of the config file)")
+}
+
+func defaultConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, appName, "config.yaml"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory for the config file: %w", err)
+	}
+
+	return filepath.Join(home, ".config", appName, "config.yaml"), nil
+}
+
+// loadProfile reads the config file and returns the selected profile with its
+// name, or no name when there is no profile to use. Only a config file given
+// with --config has to exist.
+func loadProfile(configPath, profileName string) (string, map[string]any, error) {
+	explicit := configPath != ""
+
+	if !explicit {
+		var err error
+		if configPath, err = defaultConfigPath(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			if profileName != "" {
+				return "", nil, fmt.Errorf("profile %q is given, but there is no config file at %s",
+					profileName, configPath)
+			}
+
+			return "", nil, nil
+		}
+
+		return "", nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config configFile
+	if err = yaml.UnmarshalStrict(data, &config); err != nil {
+		return "", nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+
+	if profileName == "" {
+		profileName = config.DefaultProfile
+	}
+
+	if profileName == "" {
+		return "", nil, nil
+	}
+
+	profile, ok := config.Profiles[profileName]
+	if !ok {
+		return "", nil, fmt.Errorf("profile %q not found in %s", profileName, configPath)
+	}
+
+	return profileName, profile, nil
+}
+
+// resolveProfile returns the values the profile and the environment give the
+// flags of cmd that the command line does not, in the order of the flag names.
+// A key of the profile has to be the name of a flag of some command, and the
+// flags of other commands than cmd are left out.
+func resolveProfile(cmd *cobra.Command, profileName string, profile map[string]any) ([]profileValue, error) {
+	known := map[string]bool{}
+	collectFlagNames(cmd.Root(), known)
+
+	var resolved []profileValue
+
+	for _, key := range slices.Sorted(maps.Keys(profile)) {
+		if !known[key] || key == FlagConfig || key == FlagProfile {
+			return nil, fmt.Errorf("unknown key %q in profile %q, the keys are the names of flags", key, profileName)
+		}
+
+		values, err := profileFlagValues(profile[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q in profile %q: %w", key, profileName, err)
+		}
+
+		resolved = append(resolved, profileValue{flag: key, values: values, source: sourceProfile})
+	}
+
+	// The environment comes before the profile. A credential is read from it by
+	// the command when the flag is empty, so the profile must not fill the flag
+	// of a credential the environment gives.
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !boundFlag(flag) || flag.Name == FlagConfig || flag.Name == FlagProfile {
+			return
+		}
+
+		env, readByCommand := flagEnvVar(flag.Name)
+
+		value, ok := os.LookupEnv(env)
+		if !ok || (readByCommand && value == "") {
+			return
+		}
+
+		resolved = slices.DeleteFunc(resolved, func(value profileValue) bool { return value.flag == flag.Name })
+		resolved = append(resolved, profileValue{
+			flag: flag.Name, values: []string{value}, source: sourceEnvironment + " (" + env + ")",
+		})
+	})
+
+	resolved = slices.DeleteFunc(resolved, func(value profileValue) bool {
+		flag := cmd.Flags().Lookup(value.flag)
+
+		return flag == nil || flag.Changed
+	})
+
+	slices.SortFunc(resolved, func(a, b profileValue) int { return strings.Compare(a.flag, b.flag) })
+
+	return resolved, nil
+}
+
+// applyProfile sets the flags of cmd that the command line does not give to the
+// values of the selected profile. The environment variables of credentials are
+// read later, by the commands, and win over the profile.
+func applyProfile(cmd *cobra.Command, configPath, profileName string) error {
+	name, profile, err := loadProfile(configPath, profileName)
+	if err != nil || name == "" {
+		return err
+	}
+
+	resolved, err := resolveProfile(cmd, name, profile)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range resolved {
+		if value.source != sourceProfile {
+			continue
+		}
+
+		for _, item := range value.values {
+			if err = cmd.Flags().Set(value.flag, item); err != nil {
+				return fmt.Errorf("invalid value of %q in profile %q: %w", value.flag, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// profileFlagValues turns the value of a key into the arguments of its flag: one
+// for a scalar, one per item for a list, as a repeated flag takes them.
+func profileFlagValues(value any) ([]string, error) {
+	items, ok := value.([]any)
+	if !ok {
+		items = []any{value}
+	}
+
+	values := make([]string, 0, len(items))
+
+	for _, item := range items {
+		switch typed := item.(type) {
+		case string:
+			values = append(values, typed)
+		case bool:
+			values = append(values, strconv.FormatBool(typed))
+		case float64:
+			values = append(values, strconv.FormatFloat(typed, 'f', -1, 64))
+		default:
+			return nil, fmt.Errorf("must be a string, number, boolean or a list of those, not %T", item)
+		}
+	}
+
+	return values, nil
+}
+
+func collectFlagNames(cmd *cobra.Command, names map[string]bool) {
+	for _, flags := range []*pflag.FlagSet{cmd.PersistentFlags(), cmd.Flags()} {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			names[flag.Name] = true
+		})
+	}
+
+	for _, child := range cmd.Commands() {
+		collectFlagNames(child, names)
+	}
+}
+
+func buildConfigCmd(options *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the configuration taken from the config file",
+		Args:  cobra.NoArgs,
+		// In place of the one of the root command, which applies the environment and
+		// the profile: the flags they set would count as given on the command line,
+		// and be left out of the view. The persistent ones are shared with every
+		// other command. Only the variables that pick the profile are applied.
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+
+			return applyEnv(cmd, FlagConfig, FlagProfile)
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "view [command...]",
+		Short: "Show the flag values a command takes from the profile and the environment",
+		Long: "Show the flag values the selected profile and the environment give a command, " +
+			"such as \"backup\" or \"backups copy\", or the root command when none is given. " +
+			"Flags given on the command line win over the environment, " +
+			"which wins over the profile, which wins over the defaults.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigView(cmd, options.configPath, options.profile, args)
+		},
+	})
+
+	return cmd
+}
+
+func runConfigView(cmd *cobra.Command, configPath, profileName string, args []string) error {
+	target, rest, err := cmd.Root().Find(args)
+	if err != nil || len(rest) > 0 {
+		return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+	}
+
+	name, profile, err := loadProfile(configPath, profileName)
+	if err != nil {
+		return err
+	}
+
+	if configPath == "" {
+		if configPath, err = defaultConfigPath(); err != nil {
+			return err
+		}
+	}
+
+	// The flags of the command are not parsed, which leaves them all unchanged,
+	// and those it inherits are added the way parsing would add them.
+	target.Flags().AddFlagSet(target.PersistentFlags())
+	target.Flags().AddFlagSet(target.InheritedFlags())
+
+	resolved, err := resolveProfile(target, name, profile)
+	if err != nil {
+		return err
+	}
+
+	return writeConfigView(cmd.OutOrStdout(), configPath, name, resolved)
+}
+
+func writeConfigView(out io.Writer, configPath, profileName string, resolved []profileValue) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0) //nolint:mnd
+
+	fmt.Fprintf(writer, "Config file:\t%s\n", configPath)
+	fmt.Fprintf(writer, "Profile:\t%s\n\n", orDash(profileName))
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write the config: %w", err)
+	}
+
+	fmt.Fprintln(writer, "FLAG\tVALUE\tSOURCE")
+
+	for _, value := range resolved {
+		shown := strings.Join(value.values, ",")
+		if _, ok := credentialEnvVar(value.flag); ok {
+			shown = hiddenValue
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", value.flag, shown, value.source)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write the config: %w", err)
+	}
+
+	return nil
+}
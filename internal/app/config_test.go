@@ -0,0 +1,146 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+const testConfig = `defaultProfile: team
+profiles:
+  team:
+    non-root: true
+    helm-set: [rsync.image.tag=v2, sshd.image.tag=v2]
+    helm-timeout: 5m
+    bucket: team-backups
+    access-key: from-profile
+    rclone-config: /tmp/missing-rclone.conf
+    remote: manual:bucket/path
+  other:
+    bucket: other-backups
+`
+
+func TestProfileFillsTheFlagsNotGiven(t *testing.T) {
+	t.Parallel()
+
+	configPath := writeTestConfig(t, testConfig)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup",
+		"--source", "test-pvc",
+		"--source-kubeconfig", "/tmp/missing-kubeconfig",
+		"--helm-timeout", "2m",
+		"--config", configPath,
+	})
+
+	// The rclone config of the profile is what the backup fails on.
+	err = cmd.Execute()
+	require.ErrorContains(t, err, "failed to read rclone config file")
+
+	backup, _, err := cmd.Find([]string{"backup"})
+	require.NoError(t, err)
+
+	flags := backup.Flags()
+	assert.Equal(t, "2m0s", flags.Lookup(app.FlagHelmTimeout).Value.String(), "the command line wins")
+	assert.Equal(t, "true", flags.Lookup(app.FlagNonRoot).Value.String())
+	assert.Equal(t, "[rsync.image.tag=v2,sshd.image.tag=v2]", flags.Lookup(app.FlagHelmSet).Value.String())
+	assert.Equal(t, "team-backups", flags.Lookup(app.FlagBucket).Value.String())
+}
+
+func TestProfileErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config  string
+		args    []string
+		wantErr string
+	}{
+		"unknown key": {
+			config:  "profiles:\n  team:\n    bukket: x\n",
+			args:    []string{"--profile", "team"},
+			wantErr: `unknown key "bukket" in profile "team"`,
+		},
+		"missing profile": {
+			config:  testConfig,
+			args:    []string{"--profile", "nope"},
+			wantErr: `profile "nope" not found`,
+		},
+		"bad value": {
+			config:  "defaultProfile: team\nprofiles:\n  team:\n    follow: sometimes\n",
+			wantErr: `invalid value of "follow" in profile "team"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+			require.NoError(t, err)
+
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			cmd.SetArgs(append([]string{"status", "some-id", "--config", writeTestConfig(t, tc.config)}, tc.args...))
+
+			require.ErrorContains(t, cmd.Execute(), tc.wantErr)
+		})
+	}
+}
+
+//nolint:paralleltest // sets an environment variable
+func TestConfigView(t *testing.T) {
+	configPath := writeTestConfig(t, testConfig)
+
+	t.Setenv(app.EnvS3SecretKey, "from-env")
+	t.Setenv(app.EnvS3AccessKey, "from-env")
+
+	out := runConfigView(t, "config", "view", "backup", "--config", configPath)
+	assert.Contains(t, out, "Profile:       team\n")
+	assert.Regexp(t, `access-key\s+<hidden>\s+environment \(PV_MIGRATE_S3_ACCESS_KEY\)\n`, out)
+	assert.Regexp(t, `secret-key\s+<hidden>\s+environment \(PV_MIGRATE_S3_SECRET_KEY\)\n`, out)
+	assert.Regexp(t, `bucket\s+team-backups\s+profile\n`, out)
+	assert.Regexp(t, `helm-set\s+rsync.image.tag=v2,sshd.image.tag=v2\s+profile\n`, out)
+	assert.NotContains(t, out, "from-")
+
+	// The root command has no bucket flag, and the other profile only that.
+	out = runConfigView(t, "config", "view", "--config", configPath, "--profile", "other")
+	assert.Contains(t, out, "Profile:       other\n")
+	assert.NotContains(t, out, "bucket")
+}
+
+func runConfigView(t *testing.T, args ...string) string {
+	t.Helper()
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	require.NoError(t, cmd.Execute())
+
+	return out.String()
+}
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
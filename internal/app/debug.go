@@ -0,0 +1,720 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v4/pkg/release"
+	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+// debugBundleOptions are the flags of debug bundle.
+type debugBundleOptions struct {
+	kubeconfig string
+	contexts   []string
+	namespace  string
+	file       string
+}
+
+// debugBundle is the gzipped tarball debug bundle writes, every file under one
+// directory named after the operation. What cannot be collected is noted in a
+// file of its own instead of failing the bundle, since a misbehaving cluster is
+// when a bundle is needed most.
+type debugBundle struct {
+	gzip     *gzip.Writer
+	tar      *tar.Writer
+	root     string
+	modTime  time.Time
+	problems []string
+}
+
+// bundleRelease is a Helm release of the operation, as the bundle records it.
+type bundleRelease struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Revision  int       `json:"revision"`
+	Status    string    `json:"status"`
+	Deployed  time.Time `json:"deployed"`
+
+	manifest string
+	values   map[string]any
+}
+
+// bundleObjects are the objects of the operation found in one cluster.
+type bundleObjects struct {
+	pods []corev1.Pod
+	uids map[types.UID]bool
+	// instances are the releases the objects belong to, by namespace.
+	instances map[string][]string
+}
+
+func buildDebugCmd(logger **slog.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Collect what it takes to debug an operation",
+		Args:  cobra.NoArgs,
+	}
+
+	var options debugBundleOptions
+
+	bundleCmd := &cobra.Command{
+		Use:   "bundle <operation-id>",
+		Short: "Gather everything about an operation into a tarball to attach to a report",
+		Long: "Gather the rendered manifests and values of the Helm releases of an operation, " +
+			"its pods, jobs, deployments and services, their events and diagnostics, the full logs of " +
+			"their containers, the PVCs, PVs and StorageClasses they use, and the version of the CLI " +
+			"into a gzipped tarball. SSH keys, Secret data and the credentials in rclone configs are redacted.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if args[0] == "" {
+				return errors.New("operation ID must not be empty")
+			}
+
+			return runDebugBundle(cmd, *logger, &options, args[0])
+		},
+	}
+
+	flags := bundleCmd.Flags()
+	flags.StringVar(&options.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	addContextsFlag(flags, &options.contexts, "Kubernetes context to search, "+
+		"repeat it for the clusters of a migration between clusters")
+	flags.StringVarP(&options.namespace, "namespace", "n", "", "Namespace to search (default: all namespaces)")
+	flags.StringVarP(&options.file, "file", "f", "",
+		"Path of the tarball to write, or - for stdout (default: pv-migrate-debug-<operation-id>.tar.gz)")
+
+	cmd.AddCommand(bundleCmd)
+
+	return cmd
+}
+
+func runDebugBundle(cmd *cobra.Command, logger *slog.Logger, options *debugBundleOptions, operationID string) error {
+	ctx := cmd.Context()
+
+	clusters, err := connectClusters(options.kubeconfig, options.contexts, logger)
+	if err != nil {
+		return err
+	}
+
+	file := options.file
+	if file == "" {
+		file = appName + "-debug-" + operationID + ".tar.gz"
+	}
+
+	out := cmd.OutOrStdout()
+
+	if file != "-" {
+		created, createErr := os.Create(file)
+		if createErr != nil {
+			return fmt.Errorf("failed to create the bundle: %w", createErr)
+		}
+
+		defer created.Close()
+
+		out = created
+	}
+
+	bundle := newDebugBundle(out, appName+"-debug-"+operationID, time.Now())
+	releasePrefix := opid.ReleasePrefix + operationID + "-"
+	found := false
+
+	err = bundle.add("version.txt", []byte(cmd.Root().Version+"\n"))
+
+	for i := 0; err == nil && i < len(clusters); i++ {
+		dir := clusterDir(clusters[i].context)
+
+		releases, listErr := listReleases(clusters[i].client, options.namespace, releasePrefix)
+		if listErr != nil {
+			bundle.problem(dir, "failed to list the releases: %v", listErr)
+		}
+
+		var clusterFound bool
+
+		clusterFound, err = collectClusterBundle(ctx, bundle, dir, clusters[i].client.KubeClient,
+			bundleReleases(releases), options.namespace, releasePrefix, logger)
+		found = found || clusterFound
+	}
+
+	if err == nil {
+		err = bundle.close()
+	}
+
+	if err == nil && !found {
+		err = fmt.Errorf("no releases or resources found for operation %s", operationID)
+	}
+
+	if err != nil {
+		if file != "-" {
+			os.Remove(file) //nolint:errcheck,gosec
+		}
+
+		return err
+	}
+
+	if file != "-" {
+		logger.Info("📦 Wrote the debug bundle", "file", file, "problems", len(bundle.problems))
+	}
+
+	return nil
+}
+
+// clusterDir is the directory of the bundle holding what one cluster has.
+func clusterDir(kubeContext string) string {
+	if kubeContext == "" {
+		return "current-context"
+	}
+
+	return "context-" + kubeContext
+}
+
+func bundleReleases(releases []release.Releaser) []bundleRelease {
+	result := make([]bundleRelease, 0, len(releases))
+
+	for _, rel := range releases {
+		acc, err := release.NewAccessor(rel)
+		if err != nil {
+			continue
+		}
+
+		// Helm itself has the values only of the releases of this version.
+		var values map[string]any
+
+		switch typed := rel.(type) {
+		case *releasev1.Release:
+			values = typed.Config
+		case releasev1.Release:
+			values = typed.Config
+		}
+
+		result = append(result, bundleRelease{
+			Name:      acc.Name(),
+			Namespace: acc.Namespace(),
+			Revision:  acc.Version(),
+			Status:    acc.Status(),
+			Deployed:  acc.DeployedAt(),
+			manifest:  acc.Manifest(),
+			values:    values,
+		})
+	}
+
+	return result
+}
+
+// collectClusterBundle adds what one cluster has of the operation under dir,
+// and reports whether it has anything.
+func collectClusterBundle(
+	ctx context.Context, bundle *debugBundle, dir string, cli kubernetes.Interface, releases []bundleRelease,
+	namespace, releasePrefix string, logger *slog.Logger,
+) (bool, error) {
+	for _, rel := range releases {
+		releaseDir := path.Join(dir, "releases", rel.Name)
+
+		if err := bundle.addYAML(path.Join(releaseDir, "release.yaml"), rel); err != nil {
+			return false, err
+		}
+
+		if err := bundle.add(path.Join(releaseDir, "manifest.yaml"), []byte(redactManifest(rel.manifest))); err != nil {
+			return false, err
+		}
+
+		if err := bundle.addYAML(path.Join(releaseDir, "values.yaml"), redactValues(rel.values)); err != nil {
+			return false, err
+		}
+	}
+
+	objects, err := addBundleObjects(ctx, bundle, dir, cli, namespace, releasePrefix)
+	if err != nil {
+		return false, err
+	}
+
+	if err = addStorageObjects(ctx, bundle, dir, cli, objects); err != nil {
+		return false, err
+	}
+
+	if err = addEvents(ctx, bundle, dir, cli, objects, releasePrefix); err != nil {
+		return false, err
+	}
+
+	for _, ns := range slices.Sorted(maps.Keys(objects.instances)) {
+		for _, instance := range objects.instances[ns] {
+			var diagnostics bytes.Buffer
+
+			k8s.WriteWorkloadDiagnostics(ctx, cli, ns, k8s.InstanceLabelSelector(instance), console.Palette{},
+				&diagnostics, logger)
+
+			if err = bundle.add(path.Join(dir, "diagnostics", ns, instance+".txt"), diagnostics.Bytes()); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err = addContainerLogs(ctx, bundle, dir, cli, objects.pods); err != nil {
+		return false, err
+	}
+
+	return len(releases) > 0 || len(objects.uids) > 0, nil
+}
+
+// addBundleObjects adds the pods, jobs, deployments and services of the
+// operation, found by the instance label the chart sets to the release name.
+func addBundleObjects(
+	ctx context.Context, bundle *debugBundle, dir string, cli kubernetes.Interface, namespace, releasePrefix string,
+) (*bundleObjects, error) {
+	objects := &bundleObjects{uids: map[types.UID]bool{}, instances: map[string][]string{}}
+	options := metav1.ListOptions{LabelSelector: instanceLabel}
+
+	// keep reports whether an object is of the operation, and takes note of it.
+	keep := func(meta *metav1.ObjectMeta) bool {
+		instance := meta.Labels[instanceLabel]
+		if !strings.HasPrefix(instance, releasePrefix) {
+			return false
+		}
+
+		meta.ManagedFields = nil
+		objects.uids[meta.UID] = true
+
+		if !slices.Contains(objects.instances[meta.Namespace], instance) {
+			objects.instances[meta.Namespace] = append(objects.instances[meta.Namespace], instance)
+			slices.Sort(objects.instances[meta.Namespace])
+		}
+
+		return true
+	}
+
+	writeList := func(name string, items any) error {
+		return bundle.addYAML(path.Join(dir, "resources", name+".yaml"), objectList(items))
+	}
+
+	if pods, err := cli.CoreV1().Pods(namespace).List(ctx, options); err != nil {
+		bundle.problem(dir, "failed to list the pods: %v", err)
+	} else {
+		for i := range pods.Items {
+			if keep(&pods.Items[i].ObjectMeta) {
+				pods.Items[i].TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+				objects.pods = append(objects.pods, pods.Items[i])
+			}
+		}
+
+		if err = writeList("pods", objects.pods); err != nil {
+			return nil, err
+		}
+	}
+
+	if jobs, err := cli.BatchV1().Jobs(namespace).List(ctx, options); err != nil {
+		bundle.problem(dir, "failed to list the jobs: %v", err)
+	} else {
+		var kept []batchv1.Job
+
+		for i := range jobs.Items {
+			if keep(&jobs.Items[i].ObjectMeta) {
+				jobs.Items[i].TypeMeta = metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"}
+				kept = append(kept, jobs.Items[i])
+			}
+		}
+
+		if err = writeList("jobs", kept); err != nil {
+			return nil, err
+		}
+	}
+
+	if deployments, err := cli.AppsV1().Deployments(namespace).List(ctx, options); err != nil {
+		bundle.problem(dir, "failed to list the deployments: %v", err)
+	} else {
+		var kept []appsv1.Deployment
+
+		for i := range deployments.Items {
+			if keep(&deployments.Items[i].ObjectMeta) {
+				deployments.Items[i].TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+				kept = append(kept, deployments.Items[i])
+			}
+		}
+
+		if err = writeList("deployments", kept); err != nil {
+			return nil, err
+		}
+	}
+
+	if services, err := cli.CoreV1().Services(namespace).List(ctx, options); err != nil {
+		bundle.problem(dir, "failed to list the services: %v", err)
+	} else {
+		var kept []corev1.Service
+
+		for i := range services.Items {
+			if keep(&services.Items[i].ObjectMeta) {
+				services.Items[i].TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+				kept = append(kept, services.Items[i])
+			}
+		}
+
+		if err = writeList("services", kept); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// addStorageObjects adds the PVCs the pods of the operation mount, their PVs
+// and their StorageClasses.
+func addStorageObjects(
+	ctx context.Context, bundle *debugBundle, dir string, cli kubernetes.Interface, objects *bundleObjects,
+) error {
+	var (
+		pvcs           []corev1.PersistentVolumeClaim
+		pvs            []corev1.PersistentVolume
+		storageClasses []storagev1.StorageClass
+		seen           = map[string]bool{}
+	)
+
+	for i := range objects.pods {
+		pod := &objects.pods[i]
+
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil || seen[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] {
+				continue
+			}
+
+			seen[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = true
+
+			pvc, err := cli.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx,
+				volume.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+			if err != nil {
+				bundle.problem(dir, "failed to get PVC %s/%s: %v", pod.Namespace,
+					volume.PersistentVolumeClaim.ClaimName, err)
+
+				continue
+			}
+
+			pvc.ManagedFields = nil
+			pvc.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"}
+			pvcs = append(pvcs, *pvc)
+			objects.uids[pvc.UID] = true
+		}
+	}
+
+	for i := range pvcs {
+		if name := pvcs[i].Spec.VolumeName; name != "" && !seen[name] {
+			seen[name] = true
+
+			if pv, err := cli.CoreV1().PersistentVolumes().Get(ctx, name, metav1.GetOptions{}); err != nil {
+				bundle.problem(dir, "failed to get PV %s: %v", name, err)
+			} else {
+				pv.ManagedFields = nil
+				pv.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolume"}
+				pvs = append(pvs, *pv)
+			}
+		}
+
+		if name := pvcs[i].Spec.StorageClassName; name != nil && *name != "" && !seen["storageclass/"+*name] {
+			seen["storageclass/"+*name] = true
+
+			if class, err := cli.StorageV1().StorageClasses().Get(ctx, *name, metav1.GetOptions{}); err != nil {
+				bundle.problem(dir, "failed to get StorageClass %s: %v", *name, err)
+			} else {
+				class.ManagedFields = nil
+				class.TypeMeta = metav1.TypeMeta{APIVersion: "storage.k8s.io/v1", Kind: "StorageClass"}
+				storageClasses = append(storageClasses, *class)
+			}
+		}
+	}
+
+	if err := bundle.addYAML(path.Join(dir, "storage", "pvcs.yaml"), objectList(pvcs)); err != nil {
+		return err
+	}
+
+	if err := bundle.addYAML(path.Join(dir, "storage", "pvs.yaml"), objectList(pvs)); err != nil {
+		return err
+	}
+
+	return bundle.addYAML(path.Join(dir, "storage", "storageclasses.yaml"), objectList(storageClasses))
+}
+
+// addEvents adds the events of the namespaces of the operation that are about
+// its objects, including those already gone, which are named after a release.
+func addEvents(
+	ctx context.Context, bundle *debugBundle, dir string, cli kubernetes.Interface, objects *bundleObjects,
+	releasePrefix string,
+) error {
+	var events []corev1.Event
+
+	for _, ns := range slices.Sorted(maps.Keys(objects.instances)) {
+		list, err := cli.CoreV1().Events(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			bundle.problem(dir, "failed to list the events in namespace %s: %v", ns, err)
+
+			continue
+		}
+
+		for i := range list.Items {
+			involved := list.Items[i].InvolvedObject
+			if (involved.UID != "" && objects.uids[involved.UID]) || strings.HasPrefix(involved.Name, releasePrefix) {
+				list.Items[i].ManagedFields = nil
+				list.Items[i].TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Event"}
+				events = append(events, list.Items[i])
+			}
+		}
+	}
+
+	slices.SortStableFunc(events, func(a, b corev1.Event) int {
+		return eventTime(&a).Compare(eventTime(&b))
+	})
+
+	return bundle.addYAML(path.Join(dir, "events.yaml"), objectList(events))
+}
+
+func eventTime(event *corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+
+	return event.CreationTimestamp.Time
+}
+
+// addContainerLogs adds the whole log of every container of the pods, and the
+// log of the previous run of a container that restarted.
+func addContainerLogs(
+	ctx context.Context, bundle *debugBundle, dir string, cli kubernetes.Interface, pods []corev1.Pod,
+) error {
+	for i := range pods {
+		pod := &pods[i]
+
+		restarted := map[string]bool{}
+		for _, status := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+			restarted[status.Name] = status.RestartCount > 0
+		}
+
+		for _, container := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+			for _, previous := range []bool{false, true} {
+				if previous && !restarted[container.Name] {
+					continue
+				}
+
+				name := container.Name
+				if previous {
+					name += ".previous"
+				}
+
+				stream, err := cli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name,
+					&corev1.PodLogOptions{Container: container.Name, Previous: previous}).Stream(ctx)
+				if err != nil {
+					bundle.problem(dir, "failed to read the log of %s/%s: %v", pod.Namespace+"/"+pod.Name, name, err)
+
+					continue
+				}
+
+				data, err := io.ReadAll(stream)
+				stream.Close()
+
+				if err != nil {
+					bundle.problem(dir, "failed to read the log of %s/%s: %v", pod.Namespace+"/"+pod.Name, name, err)
+				}
+
+				if err = bundle.add(path.Join(dir, "logs", pod.Namespace, pod.Name, name+".log"), data); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// redactManifest replaces the data of the Secrets of a rendered manifest, which
+// hold the SSH keys and the rclone config. An rclone config is kept readable,
+// with only its credentials replaced, since it tells where the data went.
+func redactManifest(manifest string) string {
+	documents := strings.Split(manifest, "\n---")
+
+	for idx, document := range documents {
+		var object map[string]any
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil {
+			documents[idx] = "\n# left out: the document could not be parsed to redact it\n"
+
+			continue
+		}
+
+		if object["kind"] != "Secret" {
+			continue
+		}
+
+		var comments []string
+
+		for line := range strings.SplitSeq(document, "\n") {
+			if strings.HasPrefix(line, "#") {
+				comments = append(comments, line)
+			}
+		}
+
+		object["stringData"] = redactSecretData(object["data"], object["stringData"])
+		delete(object, "data")
+
+		redacted, err := yaml.Marshal(object)
+		if err != nil {
+			documents[idx] = "\n# left out: the document could not be redacted\n"
+
+			continue
+		}
+
+		lead := ""
+		if strings.HasPrefix(document, "---") {
+			lead = "---"
+		}
+
+		documents[idx] = lead + "\n" + strings.Join(append(comments, strings.TrimSuffix(string(redacted), "\n")), "\n")
+	}
+
+	return strings.Join(documents, "\n---")
+}
+
+func redactSecretData(data, stringData any) map[string]string {
+	redacted := map[string]string{}
+
+	for idx, values := range []any{data, stringData} {
+		entries, _ := values.(map[string]any)
+		encoded := idx == 0
+
+		for key, value := range entries {
+			redacted[key] = rclone.RedactedValue
+
+			if !strings.HasSuffix(key, ".conf") {
+				continue
+			}
+
+			text, _ := value.(string)
+			if decoded, err := base64.StdEncoding.DecodeString(text); encoded && err == nil {
+				text = string(decoded)
+			}
+
+			redacted[key] = rclone.RedactConfig(text)
+		}
+	}
+
+	return redacted
+}
+
+// redactValues returns the values of a release with the SSH keys, the rclone
+// config's credentials and the credentials of the rclone container replaced.
+func redactValues(values map[string]any) map[string]any {
+	var redacted map[string]any
+
+	// A copy through JSON, as the values came from it, leaves the release as is.
+	if data, err := yaml.Marshal(values); err != nil || yaml.Unmarshal(data, &redacted) != nil {
+		return map[string]any{"redacted": "the values could not be copied to redact them"}
+	}
+
+	for _, component := range []string{"sshd", "rsync"} {
+		if section, ok := redacted[component].(map[string]any); ok {
+			for _, key := range []string{"privateKey", "publicKey", "hostKey"} {
+				if value, _ := section[key].(string); value != "" {
+					section[key] = rclone.RedactedValue
+				}
+			}
+		}
+	}
+
+	if section, ok := redacted["rclone"].(map[string]any); ok {
+		if config, _ := section["config"].(string); config != "" {
+			section["config"] = rclone.RedactConfig(config)
+		}
+
+		if credentials, ok := section["credentials"].(map[string]any); ok {
+			for key := range credentials {
+				credentials[key] = rclone.RedactedValue
+			}
+		}
+	}
+
+	return redacted
+}
+
+// objectList wraps objects in a List, the way kubectl get prints several.
+func objectList(items any) map[string]any {
+	return map[string]any{"apiVersion": "v1", "kind": "List", "items": items}
+}
+
+func newDebugBundle(out io.Writer, root string, modTime time.Time) *debugBundle {
+	compressed := gzip.NewWriter(out)
+
+	return &debugBundle{gzip: compressed, tar: tar.NewWriter(compressed), root: root, modTime: modTime}
+}
+
+func (b *debugBundle) add(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    path.Join(b.root, name),
+		Mode:    0o644, //nolint:mnd
+		Size:    int64(len(data)),
+		ModTime: b.modTime,
+	}
+
+	if err := b.tar.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+
+	if _, err := b.tar.Write(data); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+
+	return nil
+}
+
+func (b *debugBundle) addYAML(name string, value any) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+
+	return b.add(name, data)
+}
+
+// problem notes what could not be collected, under the directory of a cluster.
+func (b *debugBundle) problem(dir, format string, args ...any) {
+	b.problems = append(b.problems, dir+": "+fmt.Sprintf(format, args...))
+}
+
+func (b *debugBundle) close() error {
+	if len(b.problems) > 0 {
+		if err := b.add("problems.txt", []byte(strings.Join(b.problems, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+
+	if err := b.tar.Close(); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+
+	if err := b.gzip.Close(); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+
+	return nil
+}
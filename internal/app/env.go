@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// secretFlags are the flags whose values are secrets, which are better not
+// given on the command line, where other users of the machine can read them.
+var secretFlags = []string{
+	FlagSecretKey, FlagStorageKey, FlagSFTPPassword, FlagWebDAVPassword, FlagWebDAVBearerToken, FlagSwiftKey,
+	FlagB2Key,
+}
+
+// flagEnvVar returns the environment variable a flag is bound to: the one a
+// credential has always been read from, or PV_MIGRATE_ and the flag name in
+// upper case otherwise. It reports whether the commands read the variable
+// themselves, rather than it being set on the flag.
+func flagEnvVar(flag string) (string, bool) {
+	if env, ok := credentialEnvVar(flag); ok {
+		return env, true
+	}
+
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_")), false
+}
+
+// boundFlag reports whether a flag can be set through the environment.
+func boundFlag(flag *pflag.Flag) bool {
+	return flag.Name != "help" && flag.Name != "version"
+}
+
+// applyEnv sets the flags of cmd that the command line does not give to the
+// values of their environment variables, each as if given once, or only the
+// named ones when there are names. The credentials are left to the commands,
+// which read them when no flag is given.
+func applyEnv(cmd *cobra.Command, names ...string) error {
+	var err error
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || !boundFlag(flag) || (len(names) > 0 && !slices.Contains(names, flag.Name)) {
+			return
+		}
+
+		env, readByCommand := flagEnvVar(flag.Name)
+		value, ok := os.LookupEnv(env)
+
+		if readByCommand || !ok {
+			return
+		}
+
+		if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value of %s for --%s: %w", env, flag.Name, setErr)
+		}
+	})
+
+	return err
+}
+
+// secretsOnCommandLine returns the secret flags given on the command line. It
+// has to be called before the environment and the profile set any flag.
+func secretsOnCommandLine(cmd *cobra.Command) []string {
+	var given []string
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		name := strings.TrimPrefix(flag.Name, copyDestFlagPrefix)
+		if flag.Changed && slices.Contains(secretFlags, name) {
+			given = append(given, flag.Name)
+		}
+	})
+
+	return given
+}
+
+func warnSecretsOnCommandLine(flags []string, logger *slog.Logger) {
+	for _, flag := range flags {
+		env, _ := flagEnvVar(flag)
+
+		logger.Warn("🔶 A secret is given on the command line, where other users of the machine can read it; "+
+			"set its environment variable instead", "flag", "--"+flag, "env", env)
+	}
+}
+
+// addEnvUsage names the environment variable of every flag in its usage, for
+// the help of each command, unless the usage names it already.
+func addEnvUsage(cmd *cobra.Command) {
+	for _, flags := range []*pflag.FlagSet{cmd.PersistentFlags(), cmd.LocalNonPersistentFlags()} {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if !boundFlag(flag) {
+				return
+			}
+
+			env, _ := flagEnvVar(flag.Name)
+			if !strings.Contains(flag.Usage, env) {
+				flag.Usage += " [$" + env + "]"
+			}
+		})
+	}
+
+	for _, child := range cmd.Commands() {
+		addEnvUsage(child)
+	}
+}
@@ -0,0 +1,108 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+//nolint:paralleltest // sets environment variables
+func TestEnvBinding(t *testing.T) {
+	configPath := writeTestConfig(t, testConfig)
+
+	t.Setenv("PV_MIGRATE_BUCKET", "env-bucket")
+	t.Setenv("PV_MIGRATE_HELM_SET", "a=1,b=2")
+	t.Setenv("PV_MIGRATE_HELM_TIMEOUT", "3m")
+	t.Setenv("PV_MIGRATE_CONFIG", configPath)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup",
+		"--source", "test-pvc",
+		"--source-kubeconfig", "/tmp/missing-kubeconfig",
+		"--helm-timeout", "2m",
+	})
+
+	// The config file comes from the environment, and its rclone config is what
+	// the backup fails on.
+	require.ErrorContains(t, cmd.Execute(), "failed to read rclone config file")
+
+	backup, _, err := cmd.Find([]string{"backup"})
+	require.NoError(t, err)
+
+	flags := backup.Flags()
+	assert.Equal(t, "2m0s", flags.Lookup(app.FlagHelmTimeout).Value.String(), "the command line wins")
+	assert.Equal(t, "env-bucket", flags.Lookup(app.FlagBucket).Value.String(), "the environment beats the profile")
+	assert.Equal(t, "[a=1,b=2]", flags.Lookup(app.FlagHelmSet).Value.String())
+	assert.Equal(t, "true", flags.Lookup(app.FlagNonRoot).Value.String(), "the profile fills the rest")
+
+	out := runConfigView(t, "config", "view", "backup")
+	assert.Regexp(t, `bucket\s+env-bucket\s+environment \(PV_MIGRATE_BUCKET\)\n`, out)
+	assert.Regexp(t, `non-root\s+true\s+profile\n`, out)
+}
+
+//nolint:paralleltest // sets environment variables
+func TestEnvBinding_InvalidValue(t *testing.T) {
+	t.Setenv("PV_MIGRATE_FOLLOW", "sometimes")
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{"status", "some-id", "--config", writeTestConfig(t, "profiles: {}\n")})
+
+	require.ErrorContains(t, cmd.Execute(), "invalid value of PV_MIGRATE_FOLLOW for --follow")
+}
+
+func TestEnvUsage(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	backup, _, err := cmd.Find([]string{"backup"})
+	require.NoError(t, err)
+
+	assert.Contains(t, backup.Flags().Lookup(app.FlagBucket).Usage, "[$PV_MIGRATE_BUCKET]")
+	assert.Contains(t, backup.Flags().Lookup(app.FlagAccessKey).Usage, "[$PV_MIGRATE_S3_ACCESS_KEY]")
+	assert.Equal(t, "S3 secret key (prefer env PV_MIGRATE_S3_SECRET_KEY)",
+		backup.Flags().Lookup(app.FlagSecretKey).Usage, "a usage naming its variable already is left alone")
+	assert.Contains(t, cmd.PersistentFlags().Lookup(app.FlagLogLevel).Usage, "[$PV_MIGRATE_LOG_LEVEL]")
+}
+
+func TestSecretOnCommandLineWarns(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date",
+		slog.New(slog.NewJSONHandler(&logs, nil)))
+	require.NoError(t, err)
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup",
+		"--source", "test-pvc",
+		"--source-kubeconfig", "/tmp/missing-kubeconfig",
+		"--rclone-config", "/tmp/missing-rclone.conf",
+		"--remote", "manual:bucket/path",
+		"--secret-key", "s3cr3t",
+		"--config", writeTestConfig(t, "profiles: {}\n"),
+	})
+
+	require.Error(t, cmd.Execute())
+	assert.Contains(t, logs.String(), `"flag":"--secret-key","env":"PV_MIGRATE_S3_SECRET_KEY"`)
+	assert.NotContains(t, logs.String(), "s3cr3t")
+}
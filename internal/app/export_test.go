@@ -1,16 +1,249 @@
 package app
 
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v4/pkg/release"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/pvmigrate"
+)
+
 const (
 	EnvS3AccessKey           = envS3AccessKey
 	EnvS3SecretKey           = envS3SecretKey
 	EnvAzureStorageAccount   = envAzureStorageAccount
 	EnvAzureStorageKey       = envAzureStorageKey
 	EnvGCSServiceAccountJSON = envGCSServiceAccountJSON
+	EnvSFTPPassword          = envSFTPPassword
+	EnvWebDAVPassword        = envWebDAVPassword
+	EnvWebDAVBearerToken     = envWebDAVBearerToken
+	EnvSwiftKey              = envSwiftKey
+	EnvB2Account             = envB2Account
+	EnvB2Key                 = envB2Key
 )
 
-var ApplyBucketStorageEnvDefaults = applyBucketStorageEnvDefaults
+var (
+	ApplyBucketStorageEnvDefaults = applyBucketStorageEnvDefaults
+	ApplyBackendSecretEnvDefaults = applyBackendSecretEnvDefaults
+)
 
 var (
 	ReleaseImageTag     = releaseImageTag
 	ReleaseChartVersion = releaseChartVersion
 )
+
+var (
+	SummarizeOperations = summarizeOperations
+	WriteOperations     = writeOperations
+	WriteStructured     = writeStructured
+)
+
+// StatusDocument describes an operation by its job and releases, as status
+// --output prints it.
+func StatusDocument(
+	ctx context.Context, cli kubernetes.Interface, kubeContext, operationID string, job *batchv1.Job,
+	releases []release.Releaser,
+) any {
+	document := newStatusDocument(ctx, cli, kubeContext, operationID, job)
+	document.Releases = append(document.Releases, statusReleases(kubeContext, releases)...)
+
+	return document
+}
+
+// WriteStatusDocuments prints documents made by StatusDocument as status prints
+// those of the operations it was asked for.
+func WriteStatusDocuments(out io.Writer, documents []any, output string, several bool) error {
+	statusDocuments := make([]statusDocument, 0, len(documents))
+	for _, document := range documents {
+		statusDocuments = append(statusDocuments, document.(statusDocument)) //nolint:forcetypeassert
+	}
+
+	return writeStatusDocuments(out, statusDocuments, output, several)
+}
+
+// FindOperationJob looks for the job of an operation in clusters, one per
+// context, and returns the context it was found in.
+func FindOperationJob(
+	ctx context.Context, clients map[string]kubernetes.Interface, contexts []string, namespace, releasePrefix string,
+) (*batchv1.Job, string, error) {
+	clusters := make([]cluster, 0, len(contexts))
+	for _, kubeContext := range contexts {
+		clusters = append(clusters, cluster{
+			context: kubeContext,
+			client:  &k8s.ClusterClient{KubeClient: clients[kubeContext]},
+		})
+	}
+
+	job, found, err := findOperationJob(ctx, clusters, namespace, releasePrefix, slog.New(slog.DiscardHandler))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return job, found.context, nil
+}
+
+// CleanupCandidate stands in for the releases of an operation found by cleanup.
+type CleanupCandidate struct {
+	ID           string
+	LastDeployed time.Time
+	Jobs         []batchv1.Job
+}
+
+// SelectForCleanup returns the IDs of the candidates a cleanup with the given
+// filters removes.
+func SelectForCleanup(
+	candidates []CleanupCandidate, olderThan time.Duration, succeededOnly, failedOnly, force bool, now time.Time,
+) []string {
+	operations := make([]cleanupOperation, 0, len(candidates))
+	for _, candidate := range candidates {
+		operations = append(operations, cleanupOperation{
+			id: candidate.ID, jobs: candidate.Jobs, lastDeployed: candidate.LastDeployed,
+		})
+	}
+
+	options := &cleanupOptions{olderThan: olderThan, succeededOnly: succeededOnly, failedOnly: failedOnly, force: force}
+
+	var ids []string
+	for _, operation := range selectForCleanup(operations, options, now, slog.New(slog.DiscardHandler)) {
+		ids = append(ids, operation.id)
+	}
+
+	return ids
+}
+
+// FollowOperations follows the operations with the given IDs, or every running
+// one when there are none, in one cluster.
+func FollowOperations(ctx context.Context, cli kubernetes.Interface, operationIDs []string, out io.Writer) error {
+	clusters := []cluster{{client: &k8s.ClusterClient{KubeClient: cli}}}
+	logger := slog.New(slog.DiscardHandler)
+
+	operations, err := findFollowedOperations(ctx, clusters, "", operationIDs, logger)
+	if err != nil {
+		return err
+	}
+
+	followOperations(ctx, operations, nil, false, logger)
+
+	return writeFollowSummary(out, operations)
+}
+
+var MigrationCommandLine = migrationCommandLine
+
+// ComposeMigration answers the questions of the interactive command with the
+// lines of input, offering the PVCs listed under "context/namespace" keys. It
+// returns the questions along with the migration.
+func ComposeMigration(
+	ctx context.Context, input, currentContext string, pvcs map[string][]string,
+) (pvmigrate.Migration, string, error) {
+	namespaces := map[string][]string{}
+
+	for key := range pvcs {
+		kubeContext, namespace, _ := strings.Cut(key, "/")
+		namespaces[kubeContext] = append(namespaces[kubeContext], namespace)
+	}
+
+	lister := &wizardLister{
+		contexts: func() ([]string, string, error) {
+			return slices.Collect(maps.Keys(namespaces)), currentContext, nil
+		},
+		namespaces: func(_ context.Context, kubeContext string) ([]string, string, error) {
+			return namespaces[kubeContext], "default", nil
+		},
+		pvcs: func(_ context.Context, kubeContext, namespace string) ([]string, error) {
+			return pvcs[kubeContext+"/"+namespace], nil
+		},
+	}
+
+	var out strings.Builder
+
+	migration, err := composeMigration(ctx, &wizard{in: bufio.NewReader(strings.NewReader(input)), out: &out}, lister)
+
+	return migration, out.String(), err
+}
+
+// SinceSeconds is the --since of logs as the API receives it.
+var SinceSeconds = sinceSeconds
+
+// OperationLogs writes the logs of the pods of an operation in one cluster, of
+// the given components, or of all when there are none.
+func OperationLogs(
+	ctx context.Context, cli kubernetes.Interface, operationID string, components []string, out io.Writer,
+) error {
+	clusters := []cluster{{client: &k8s.ClusterClient{KubeClient: cli}}}
+	options := &logsOptions{components: components}
+
+	sources, err := findLogSources(ctx, clusters, options, operationID, slog.New(slog.DiscardHandler))
+	if err != nil {
+		return err
+	}
+
+	return writeLogs(ctx, out, sources, options)
+}
+
+// DebugBundle collects the debug bundle of an operation in one cluster, whose
+// releases have the given manifests and values by name, and returns its files
+// by path.
+func DebugBundle(
+	ctx context.Context, cli kubernetes.Interface, operationID string, manifests map[string]string,
+	values map[string]map[string]any,
+) (map[string]string, error) {
+	releases := make([]bundleRelease, 0, len(manifests))
+	for _, name := range slices.Sorted(maps.Keys(manifests)) {
+		releases = append(releases, bundleRelease{
+			Name: name, Namespace: "apps", Status: "deployed", manifest: manifests[name], values: values[name],
+		})
+	}
+
+	var out bytes.Buffer
+
+	bundle := newDebugBundle(&out, "bundle", time.Time{})
+
+	if _, err := collectClusterBundle(ctx, bundle, "cluster", cli, releases, "", "pv-migrate-"+operationID+"-",
+		slog.New(slog.DiscardHandler)); err != nil {
+		return nil, err
+	}
+
+	if err := bundle.close(); err != nil {
+		return nil, err
+	}
+
+	compressed, err := gzip.NewReader(&out)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	reader := tar.NewReader(compressed)
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		files[header.Name] = string(data)
+	}
+}
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/jobprogress"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+	"github.com/utkuozdemir/pv-migrate/internal/progresslog"
+)
+
+// boardRefreshInterval is how often the progress of the operations followed at
+// once is read off their logs and drawn.
+const boardRefreshInterval = 2 * time.Second
+
+// followedOperation is one of the operations status follows at once.
+type followedOperation struct {
+	id      string
+	job     *batchv1.Job
+	cluster *cluster
+	row     *progresslog.BoardRow
+
+	// Set by the wait for the job, and read by the board as it is drawn.
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+// runStatusFollowMany follows several operations at once, or every running one
+// when no ID is given, with a progress bar each on a terminal. It ends with a
+// table of how each of them ended, and fails when any of them failed.
+func runStatusFollowMany(
+	ctx context.Context, logger *slog.Logger, options *statusOptions, operationIDs []string,
+) error {
+	clusters, err := connectClusters(options.kubeconfig, options.contexts, logger)
+	if err != nil {
+		return err
+	}
+
+	operations, err := findFollowedOperations(ctx, clusters, options.namespace, operationIDs, logger)
+	if err != nil {
+		return err
+	}
+
+	if len(operations) == 0 {
+		logger.Info("No running pv-migrate operations found")
+
+		return nil
+	}
+
+	logger.Info("Following operations", "count", len(operations))
+
+	var board *progresslog.Board
+
+	// The bars need a terminal to redraw, and a record written between two frames
+	// would be drawn over, so the waits log nothing while the board is up.
+	if isatty.IsTerminal(os.Stderr.Fd()) && !options.structuredLogs {
+		board = progresslog.NewBoard(os.Stderr)
+		logger = slog.New(slog.DiscardHandler)
+
+		for _, operation := range operations {
+			operation.row = board.AddRow(operation.id)
+			operation.row.Set(0, jobStatePending)
+		}
+	}
+
+	followOperations(ctx, operations, board, options.structuredLogs, logger)
+
+	if options.output == outputText {
+		return writeFollowSummary(options.out, operations)
+	}
+
+	failed := 0
+	documents := make([]statusDocument, 0, len(operations))
+
+	for _, operation := range operations {
+		documents = append(documents, buildStatusDocument(ctx, clusters, operation.cluster, options.namespace,
+			operation.id, operation.job, logger))
+
+		if operation.err != nil {
+			failed++
+		}
+	}
+
+	if err = writeStatusDocuments(options.out, documents, options.output, true); err != nil {
+		return err
+	}
+
+	return followFailure(failed, len(operations))
+}
+
+// findFollowedOperations finds the job of each of the operations, or of every
+// running operation when no ID is given.
+func findFollowedOperations(
+	ctx context.Context, clusters []cluster, namespace string, operationIDs []string, logger *slog.Logger,
+) ([]*followedOperation, error) {
+	if len(operationIDs) == 0 {
+		return findRunningOperations(ctx, clusters, namespace)
+	}
+
+	operations := make([]*followedOperation, 0, len(operationIDs))
+
+	for _, operationID := range operationIDs {
+		job, jobCluster, err := findOperationJob(ctx, clusters, namespace, opid.ReleasePrefix+operationID+"-", logger)
+		if err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, &followedOperation{id: operationID, job: job, cluster: jobCluster})
+	}
+
+	return operations, nil
+}
+
+// findRunningOperations finds the operations whose data-mover job has not
+// finished yet, in every cluster.
+func findRunningOperations(ctx context.Context, clusters []cluster, namespace string) ([]*followedOperation, error) {
+	var operations []*followedOperation
+
+	for i := range clusters {
+		jobs, err := clusters[i].client.KubeClient.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "app.kubernetes.io/managed-by=Helm",
+		})
+		if err != nil {
+			return nil, contextError(clusters[i].context, fmt.Errorf("failed to list jobs: %w", err))
+		}
+
+		for j := range jobs.Items {
+			job := &jobs.Items[j]
+
+			state := jobState(job)
+			if state == jobStateSucceeded || state == jobStateFailed || jobprogress.Description(job.Name) == "job" {
+				continue
+			}
+
+			parsed, ok := opid.ParseReleaseName(job.Labels[instanceLabel])
+			if !ok {
+				continue
+			}
+
+			operations = append(operations, &followedOperation{id: parsed.ID, job: job, cluster: &clusters[i]})
+		}
+	}
+
+	return operations, nil
+}
+
+// followOperations waits for every operation to finish, redrawing the board
+// until the last one has.
+func followOperations(
+	ctx context.Context, operations []*followedOperation, board *progresslog.Board, structuredLogs bool,
+	logger *slog.Logger,
+) {
+	var waiters sync.WaitGroup
+
+	for _, operation := range operations {
+		waiters.Go(func() {
+			waitForOperation(ctx, operation, structuredLogs, logger)
+		})
+	}
+
+	if board == nil {
+		waiters.Wait()
+
+		return
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		waiters.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(boardRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			for _, operation := range operations {
+				operation.refreshRow(ctx)
+			}
+
+			board.Draw()
+
+			return
+		default:
+		}
+
+		for _, operation := range operations {
+			operation.refreshRow(ctx)
+		}
+
+		board.Draw()
+
+		select {
+		case <-done:
+		case <-ticker.C:
+		}
+	}
+}
+
+func waitForOperation(ctx context.Context, operation *followedOperation, structuredLogs bool, logger *slog.Logger) {
+	cli := operation.cluster.client.KubeClient
+	logger = logger.With("operation", operation.id)
+
+	err := k8s.WaitForJobCompletion(ctx, cli, operation.job.Namespace, operation.job.Name, false, structuredLogs,
+		console.Palette{}, io.Discard, logger)
+
+	job := refreshJob(ctx, cli, operation.job, logger)
+
+	operation.mu.Lock()
+	defer operation.mu.Unlock()
+
+	operation.job, operation.err, operation.done = job, err, true
+
+	if err != nil {
+		logger.Error("❌ Operation failed", "error", err)
+
+		return
+	}
+
+	logger.Info("✅ Operation succeeded")
+}
+
+// refreshRow reads the progress of a running operation off its log, and shows
+// how a finished one ended.
+func (o *followedOperation) refreshRow(ctx context.Context) {
+	o.mu.Lock()
+	job, done, err := o.job, o.done, o.err
+	o.mu.Unlock()
+
+	switch {
+	case !done:
+		if latest, ok := findJobProgress(ctx, o.cluster.client.KubeClient, job); ok {
+			o.row.Set(latest.Percentage, jobStateRunning)
+		}
+	case err != nil:
+		o.row.SetStatus(jobStateFailed)
+	default:
+		o.row.Set(100, jobStateSucceeded) //nolint:mnd
+	}
+}
+
+// writeFollowSummary prints how each operation ended, and fails when any of
+// them failed.
+func writeFollowSummary(out io.Writer, operations []*followedOperation) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0) //nolint:mnd
+
+	fmt.Fprintln(writer, "ID\tSTATE\tELAPSED\tERROR")
+
+	failed := 0
+
+	for _, operation := range operations {
+		state, message := jobStateSucceeded, "-"
+		if operation.err != nil {
+			failed++
+
+			state, message = jobStateFailed, strings.SplitN(operation.err.Error(), "\n", 2)[0] //nolint:mnd
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", operation.id, state, orDash(jobElapsed(operation.job)), message)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write the summary: %w", err)
+	}
+
+	return followFailure(failed, len(operations))
+}
+
+func followFailure(failed, total int) error {
+	if failed > 0 {
+		return fmt.Errorf("%d of %d operations failed", failed, total)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
+	"github.com/utkuozdemir/pv-migrate/pvmigrate"
+)
+
+const (
+	FlagPreHook       = "pre-hook"
+	FlagPostHook      = "post-hook"
+	FlagHookSelector  = "hook-selector"
+	FlagHookContainer = "hook-container"
+	FlagHookOnError   = "hook-on-error"
+	FlagHookTimeout   = "hook-timeout"
+)
+
+// setHookFlags registers the flags of the hooks run in the application's pods,
+// which podsDescription says where to find.
+func setHookFlags(cmd *cobra.Command, hooks *pvmigrate.Hooks, podsDescription string) error {
+	flags := cmd.Flags()
+
+	flags.StringVar(&hooks.Pre, FlagPreHook, "",
+		"Command to run with sh -c in the hook pods before the transfer, e.g. to quiesce a database")
+	flags.StringVar(&hooks.Post, FlagPostHook, "",
+		"Command to run with sh -c in the hook pods once the transfer finishes, whatever its outcome")
+	flags.StringVar(&hooks.Selector, FlagHookSelector, "",
+		"Label selector of the hook pods, the running pods "+podsDescription+" to run the hooks in")
+	flags.StringVar(&hooks.Container, FlagHookContainer, "",
+		"Container of the hook pods to run the hooks in (default: the only container)")
+	flags.StringVar((*string)(&hooks.OnError), FlagHookOnError, hook.OnErrorFail,
+		"What to do when a hook fails: "+strings.Join(hook.OnErrorPolicies, " or "))
+	flags.DurationVar(&hooks.Timeout, FlagHookTimeout, hook.DefaultTimeout, "Timeout of each run of a hook")
+
+	for _, completion := range []struct {
+		flag string
+		fn   cobra.CompletionFunc
+	}{
+		{FlagPreHook, completionFuncNoFileComplete},
+		{FlagPostHook, completionFuncNoFileComplete},
+		{FlagHookSelector, completionFuncNoFileComplete},
+		{FlagHookContainer, completionFuncNoFileComplete},
+		{FlagHookOnError, buildStaticSliceCompletionFunc(hook.OnErrorPolicies)},
+	} {
+		if err := cmd.RegisterFlagCompletionFunc(completion.flag, completion.fn); err != nil {
+			return fmt.Errorf("failed to register completion for flag %q: %w", completion.flag, err)
+		}
+	}
+
+	return nil
+}
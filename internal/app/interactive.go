@@ -0,0 +1,363 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/shell"
+	"github.com/utkuozdemir/pv-migrate/pvmigrate"
+)
+
+// plainShellWord matches the arguments that need no quoting on a command line.
+var plainShellWord = regexp.MustCompile(`^[A-Za-z0-9._/:@=+,-]+$`)
+
+// wizard asks the questions of the interactive command on one stream and reads
+// the answers off another, a line each.
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// wizardLister looks up what the wizard offers to pick from. It is a set of
+// functions so that the questions can be asked without a cluster.
+type wizardLister struct {
+	contexts   func() (names []string, current string, err error)
+	namespaces func(ctx context.Context, kubeContext string) (names []string, current string, err error)
+	pvcs       func(ctx context.Context, kubeContext, namespace string) ([]string, error)
+}
+
+func buildInteractiveCmd(logger **slog.Logger, imageTag, chartVersion string) *cobra.Command {
+	var kubeconfig string
+
+	cmd := &cobra.Command{
+		Use:   "interactive",
+		Short: "Compose a migration by answering questions",
+		Long: "Walk through picking the source and destination context, namespace and PVC, " +
+			"show which strategies would be tried, and print the equivalent command line before running it.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runInteractive(cmd, kubeconfig, imageTag, chartVersion, *logger)
+		},
+	}
+
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+
+	return cmd
+}
+
+func runInteractive(cmd *cobra.Command, kubeconfig, imageTag, chartVersion string, logger *slog.Logger) error {
+	ctx := cmd.Context()
+	wiz := &wizard{in: bufio.NewReader(cmd.InOrStdin()), out: cmd.ErrOrStderr()}
+
+	migration, err := composeMigration(ctx, wiz, newWizardLister(kubeconfig, logger))
+	if err != nil {
+		return err
+	}
+
+	migration.Source.KubeconfigPath = kubeconfig
+	migration.Dest.KubeconfigPath = kubeconfig
+	migration.ImageTag = imageTag
+	migration.ChartVersion = chartVersion
+	migration.Logger = logger
+
+	plans, err := pvmigrate.Plan(ctx, migration)
+	if err != nil {
+		return err
+	}
+
+	if err = writeStrategyPlans(wiz.out, plans); err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(plans, func(plan pvmigrate.StrategyPlan) bool { return plan.DeclineReason == "" }) {
+		return errors.New("every strategy declines this migration")
+	}
+
+	fmt.Fprintln(wiz.out, "\nThe same migration without the questions:")
+	fmt.Fprintln(cmd.OutOrStdout(), migrationCommandLine(&migration))
+	fmt.Fprintln(wiz.out)
+
+	run, err := wiz.confirm("Run the migration now?", false)
+	if err != nil || !run {
+		return err
+	}
+
+	writer := os.Stderr
+	migration.Writer = writer
+	migration.ShowProgressBar = isatty.IsTerminal(writer.Fd())
+	migration.StructuredLogs = structuredLogsRequested(cmd)
+	migration.ColorOutput = colorOutputWanted(cmd, writer)
+
+	logger.Info("🚀 Starting migration")
+
+	return pvmigrate.Run(ctx, migration)
+}
+
+func newWizardLister(kubeconfig string, logger *slog.Logger) *wizardLister {
+	return &wizardLister{
+		contexts: func() ([]string, string, error) {
+			names, err := k8s.GetContexts(kubeconfig, logger)
+			if err != nil {
+				return nil, "", err //nolint:wrapcheck
+			}
+
+			current, err := k8s.GetCurrentContext(kubeconfig, logger)
+
+			return names, current, err //nolint:wrapcheck
+		},
+		namespaces: func(ctx context.Context, kubeContext string) ([]string, string, error) {
+			client, err := k8s.GetClusterClient(kubeconfig, kubeContext, logger)
+			if err != nil {
+				return nil, "", err //nolint:wrapcheck
+			}
+
+			names, err := k8s.GetNamespaces(ctx, kubeconfig, kubeContext, logger)
+
+			return names, client.NsInContext, err //nolint:wrapcheck
+		},
+		pvcs: func(ctx context.Context, kubeContext, namespace string) ([]string, error) {
+			return k8s.GetPVCs(ctx, kubeconfig, kubeContext, namespace, logger) //nolint:wrapcheck
+		},
+	}
+}
+
+// composeMigration asks for the source and the destination of a migration. The
+// destination defaults to the context and namespace of the source.
+func composeMigration(ctx context.Context, wiz *wizard, lister *wizardLister) (pvmigrate.Migration, error) {
+	var migration pvmigrate.Migration
+
+	contexts, currentContext, err := lister.contexts()
+	if err != nil {
+		return migration, err
+	}
+
+	slices.Sort(contexts)
+
+	if migration.Source, err = wiz.pickPVC(ctx, lister, "source", contexts, currentContext, nil); err != nil {
+		return migration, err
+	}
+
+	source := migration.Source
+
+	if migration.Dest, err = wiz.pickPVC(ctx, lister, "destination", contexts, source.Context, &source); err != nil {
+		return migration, err
+	}
+
+	migration.IgnoreMounted, err = wiz.confirm("Go ahead even if a pod is using one of the PVCs?", false)
+
+	return migration, err
+}
+
+// pickPVC asks for the context, namespace and name of one side of the
+// migration. Picking the destination, the namespace defaults to the one of the
+// source when the context is the same, and the source PVC is not offered.
+func (w *wizard) pickPVC(
+	ctx context.Context, lister *wizardLister, side string, contexts []string, defaultContext string,
+	source *pvmigrate.PVC,
+) (pvmigrate.PVC, error) {
+	var (
+		pvc pvmigrate.PVC
+		err error
+	)
+
+	if pvc.Context, err = w.choose("Context of the "+side+" PVC", contexts, defaultContext); err != nil {
+		return pvc, err
+	}
+
+	namespaces, currentNamespace, err := lister.namespaces(ctx, pvc.Context)
+	if err != nil {
+		return pvc, err
+	}
+
+	slices.Sort(namespaces)
+
+	defaultNamespace := currentNamespace
+	if source != nil && source.Context == pvc.Context {
+		defaultNamespace = source.Namespace
+	}
+
+	if pvc.Namespace, err = w.choose("Namespace of the "+side+" PVC", namespaces, defaultNamespace); err != nil {
+		return pvc, err
+	}
+
+	names, err := lister.pvcs(ctx, pvc.Context, pvc.Namespace)
+	if err != nil {
+		return pvc, err
+	}
+
+	if source != nil && source.Context == pvc.Context && source.Namespace == pvc.Namespace {
+		names = slices.DeleteFunc(names, func(name string) bool { return name == source.Name })
+	}
+
+	if len(names) == 0 {
+		return pvc, fmt.Errorf("no PVCs to pick from in namespace %s of context %s", pvc.Namespace, pvc.Context)
+	}
+
+	slices.Sort(names)
+
+	pvc.Name, err = w.choose("The "+side+" PVC", names, "")
+
+	return pvc, err
+}
+
+// choose lists the options, numbered, and returns the one the answer names by
+// number or by name. An empty answer picks defaultOption if it is one of the
+// options, or the only option there is.
+func (w *wizard) choose(question string, options []string, defaultOption string) (string, error) {
+	if len(options) == 0 {
+		return "", fmt.Errorf("nothing to choose from for %q", question)
+	}
+
+	if len(options) == 1 {
+		defaultOption = options[0]
+	}
+
+	if !slices.Contains(options, defaultOption) {
+		defaultOption = ""
+	}
+
+	fmt.Fprintf(w.out, "%s:\n", question)
+
+	for i, option := range options {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, option)
+	}
+
+	for {
+		prompt := "Pick one"
+		if defaultOption != "" {
+			prompt += " [" + defaultOption + "]"
+		}
+
+		answer, err := w.ask(prompt)
+		if err != nil {
+			return "", err
+		}
+
+		if answer == "" && defaultOption != "" {
+			return defaultOption, nil
+		}
+
+		if index, err := strconv.Atoi(answer); err == nil && index >= 1 && index <= len(options) {
+			return options[index-1], nil
+		}
+
+		if slices.Contains(options, answer) {
+			return answer, nil
+		}
+
+		fmt.Fprintf(w.out, "%q is not one of the options, give its number or its name\n", answer)
+	}
+}
+
+// confirm asks a yes or no question.
+func (w *wizard) confirm(question string, defaultYes bool) (bool, error) {
+	choices := "[y/N]"
+	if defaultYes {
+		choices = "[Y/n]"
+	}
+
+	for {
+		answer, err := w.ask(question + " " + choices)
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "":
+			return defaultYes, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+
+		fmt.Fprintln(w.out, "Answer y or n")
+	}
+}
+
+func (w *wizard) ask(prompt string) (string, error) {
+	fmt.Fprint(w.out, prompt+": ")
+
+	// A last answer without a newline still counts, the input ending before it
+	// does not.
+	line, err := w.in.ReadString('\n')
+	switch {
+	case errors.Is(err, io.EOF) && line == "":
+		return "", errors.New("input ended before every question was answered")
+	case err != nil && !errors.Is(err, io.EOF):
+		return "", fmt.Errorf("failed to read the answer: %w", err)
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// writeStrategyPlans shows which strategies the migration would try, in order.
+func writeStrategyPlans(out io.Writer, plans []pvmigrate.StrategyPlan) error {
+	fmt.Fprintln(out, "\nStrategies, in the order they would be tried:")
+
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0) //nolint:mnd
+
+	for _, plan := range plans {
+		verdict := "would be tried"
+		if plan.DeclineReason != "" {
+			verdict = "skipped: " + plan.DeclineReason
+		}
+
+		fmt.Fprintf(writer, "  %s\t%s\n", plan.Strategy, verdict)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write the strategies: %w", err)
+	}
+
+	return nil
+}
+
+// migrationCommandLine returns the command that runs the migration the wizard
+// composed, without asking.
+func migrationCommandLine(migration *pvmigrate.Migration) string {
+	args := []string{appName}
+
+	for _, flag := range []struct{ name, value string }{
+		{FlagSourceKubeconfig, migration.Source.KubeconfigPath},
+		{FlagSourceContext, migration.Source.Context},
+		{FlagSourceNamespace, migration.Source.Namespace},
+		{FlagSource, migration.Source.Name},
+		{FlagDestKubeconfig, migration.Dest.KubeconfigPath},
+		{FlagDestContext, migration.Dest.Context},
+		{FlagDestNamespace, migration.Dest.Namespace},
+		{FlagDest, migration.Dest.Name},
+	} {
+		if flag.value != "" {
+			args = append(args, "--"+flag.name, shellWord(flag.value))
+		}
+	}
+
+	if migration.IgnoreMounted {
+		args = append(args, "--"+FlagIgnoreMounted)
+	}
+
+	return strings.Join(args, " ")
+}
+
+func shellWord(value string) string {
+	if plainShellWord.MatchString(value) {
+		return value
+	}
+
+	return shell.Quote(value)
+}
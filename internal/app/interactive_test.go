@@ -0,0 +1,57 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+	"github.com/utkuozdemir/pv-migrate/pvmigrate"
+)
+
+func TestComposeMigration(t *testing.T) {
+	t.Parallel()
+
+	pvcs := map[string][]string{
+		"dev/default":  {"scratch"},
+		"prod/default": {"web"},
+		"prod/db":      {"data-new", "data-old"},
+	}
+
+	// Source: the current context by default, the db namespace by name, the
+	// second PVC by number. Destination: the context and namespace of the source
+	// by default, which leaves data-new as the only PVC.
+	migration, questions, err := app.ComposeMigration(t.Context(), "\ndb\n2\n\n\n\ny\n", "prod", pvcs)
+	require.NoError(t, err)
+	assert.Equal(t, pvmigrate.PVC{Context: "prod", Namespace: "db", Name: "data-old"}, migration.Source)
+	assert.Equal(t, pvmigrate.PVC{Context: "prod", Namespace: "db", Name: "data-new"}, migration.Dest)
+	assert.True(t, migration.IgnoreMounted)
+	assert.Contains(t, questions, "Context of the source PVC:\n  1) dev\n  2) prod\nPick one [prod]: ")
+	assert.Contains(t, questions, "The destination PVC:\n  1) data-new\nPick one [data-new]: ")
+
+	// A wrong answer is asked again, and the destination namespace defaults to
+	// the one of the context once the context differs from the source.
+	migration, questions, err = app.ComposeMigration(t.Context(), "prod\ndefault\n\n9\ndev\n\n\nn\n", "", pvcs)
+	require.NoError(t, err)
+	assert.Equal(t, pvmigrate.PVC{Context: "dev", Namespace: "default", Name: "scratch"}, migration.Dest)
+	assert.False(t, migration.IgnoreMounted)
+	assert.Contains(t, questions, `"9" is not one of the options`)
+
+	_, _, err = app.ComposeMigration(t.Context(), "\ndb\n", "prod", pvcs)
+	require.ErrorContains(t, err, "input ended before every question was answered")
+}
+
+func TestMigrationCommandLine(t *testing.T) {
+	t.Parallel()
+
+	migration := pvmigrate.Migration{
+		Source:        pvmigrate.PVC{Context: "prod", Namespace: "db", Name: "data-old"},
+		Dest:          pvmigrate.PVC{KubeconfigPath: "/home/me/my configs/kube", Context: "dev", Name: "data"},
+		IgnoreMounted: true,
+	}
+
+	assert.Equal(t, "pv-migrate --source-context prod --source-namespace db --source data-old "+
+		"--dest-kubeconfig '/home/me/my configs/kube' --dest-context dev --dest data --ignore-mounted",
+		app.MigrationCommandLine(&migration))
+}
@@ -0,0 +1,352 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v4/pkg/release"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/utkuozdemir/pv-migrate/internal/jobprogress"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+const (
+	FlagOutput = "output"
+
+	outputTable = "table"
+	outputText  = "text"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+
+	operationTypeMigrate = "migrate"
+
+	// instanceLabel is set by the chart on every resource to the release's name.
+	instanceLabel = "app.kubernetes.io/instance"
+)
+
+var (
+	listOutputFormats   = []string{outputTable, outputJSON, outputYAML}
+	statusOutputFormats = []string{outputText, outputJSON, outputYAML}
+)
+
+// operationSummary is one operation as list prints it.
+type operationSummary struct {
+	Context    string   `json:"context,omitempty"`
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	Namespaces []string `json:"namespaces"`
+	Strategy   string   `json:"strategy,omitempty"`
+	// State is the state of the data-mover job, or the status of the newest Helm
+	// release where the cluster holds no job, such as the sshd side of a migration
+	// across clusters.
+	State    string    `json:"state"`
+	Progress *int      `json:"progress,omitempty"`
+	Created  time.Time `json:"created"`
+	Releases []string  `json:"releases"`
+
+	// strategies are the ones a migration tried, of which the releases remain.
+	strategies []string
+	job        *batchv1.Job
+}
+
+func buildListCmd(logger **slog.Logger) (*cobra.Command, error) {
+	var (
+		kubeconfig string
+		contexts   []string
+		namespace  string
+		output     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the operations in the cluster",
+		Long: "List the migrations, backups and restores whose Helm releases are still in the cluster, " +
+			"such as detached ones and the ones left behind by --no-cleanup, with their state and progress.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains(listOutputFormats, output) {
+				return fmt.Errorf("invalid --%s: %q, must be one of %s",
+					FlagOutput, output, strings.Join(listOutputFormats, ", "))
+			}
+
+			return runList(cmd.Context(), *logger, kubeconfig, contexts, namespace, output, cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	addContextsFlag(flags, &contexts, "Kubernetes context to list the operations of")
+	flags.StringVarP(&namespace, "namespace", "n", "", "Namespace to search (default: all namespaces)")
+	flags.StringVarP(&output, FlagOutput, "o", outputTable, "Output format: "+strings.Join(listOutputFormats, ", "))
+
+	if err := cmd.RegisterFlagCompletionFunc(FlagOutput,
+		buildStaticSliceCompletionFunc(listOutputFormats)); err != nil {
+		return nil, fmt.Errorf("failed to register completion for flag %q: %w", FlagOutput, err)
+	}
+
+	return cmd, nil
+}
+
+func runList(
+	ctx context.Context, logger *slog.Logger, kubeconfig string, contexts []string, namespace, output string,
+	out io.Writer,
+) error {
+	clusters, err := connectClusters(kubeconfig, contexts, logger)
+	if err != nil {
+		return err
+	}
+
+	var operations []operationSummary
+
+	for i := range clusters {
+		found, err := listOperations(ctx, &clusters[i], namespace)
+		if err != nil {
+			return contextError(clusters[i].context, err)
+		}
+
+		operations = append(operations, found...)
+	}
+
+	if len(operations) == 0 && output == outputTable {
+		logger.Info("No pv-migrate operations found")
+
+		return nil
+	}
+
+	return writeOperations(out, operations, output, len(clusters) > 1, time.Now())
+}
+
+// listOperations finds the operations of one cluster by their releases, then
+// reads the state and progress of each off its data-mover job.
+func listOperations(ctx context.Context, cluster *cluster, namespace string) ([]operationSummary, error) {
+	client := cluster.client
+
+	releases, err := listReleases(client, namespace, opid.ReleasePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(releases) == 0 {
+		return nil, nil
+	}
+
+	jobs, err := client.KubeClient.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/managed-by=Helm",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	operations := summarizeOperations(cluster.context, releases, jobs.Items)
+	for i := range operations {
+		addJobProgress(ctx, client.KubeClient, &operations[i])
+	}
+
+	return operations, nil
+}
+
+// summarizeOperations groups the releases of one cluster by the operation they
+// belong to. Releases not named like an operation's are left out.
+func summarizeOperations(kubeContext string, releases []release.Releaser, jobs []batchv1.Job) []operationSummary {
+	accessors := make([]release.Accessor, 0, len(releases))
+
+	for _, rel := range releases {
+		if acc, err := release.NewAccessor(rel); err == nil {
+			accessors = append(accessors, acc)
+		}
+	}
+
+	// In the order they were installed, so that the strategies of a migration are
+	// in the order it tried them, and the newest release comes last.
+	slices.SortStableFunc(accessors, func(a, b release.Accessor) int {
+		return a.DeployedAt().Compare(b.DeployedAt())
+	})
+
+	var operations []operationSummary
+
+	index := make(map[string]int)
+
+	for _, acc := range accessors {
+		parsed, ok := opid.ParseReleaseName(acc.Name())
+		if !ok {
+			continue
+		}
+
+		i, seen := index[parsed.ID]
+		if !seen {
+			i = len(operations)
+			index[parsed.ID] = i
+
+			operations = append(operations, operationSummary{
+				Context: kubeContext,
+				ID:      parsed.ID,
+				Type:    operationType(parsed.Kind),
+				Created: acc.DeployedAt(),
+			})
+		}
+
+		operation := &operations[i]
+		operation.Releases = append(operation.Releases, acc.Namespace()+"/"+acc.Name())
+
+		// Without a job, the newest release is the best account of the state.
+		operation.State = acc.Status()
+
+		if !slices.Contains(operation.Namespaces, acc.Namespace()) {
+			operation.Namespaces = append(operation.Namespaces, acc.Namespace())
+		}
+
+		if operation.Type == operationTypeMigrate && !slices.Contains(operation.strategies, parsed.Kind) {
+			operation.strategies = append(operation.strategies, parsed.Kind)
+		}
+
+		if job := findReleaseJob(jobs, acc.Namespace(), acc.Name()); job != nil {
+			operation.job = job
+		}
+	}
+
+	for i := range operations {
+		operation := &operations[i]
+
+		slices.Sort(operation.Namespaces)
+		slices.Sort(operation.Releases)
+
+		operation.Strategy = strings.Join(operation.strategies, ",")
+
+		if operation.job != nil {
+			operation.State = jobState(operation.job)
+		}
+	}
+
+	return operations
+}
+
+// operationType names the kind of operation a release was created for: the
+// direction of a transfer to or from a bucket, with the lookup of the latest
+// backup counted as part of its restore, or else a migration by a strategy.
+func operationType(kind string) string {
+	switch kind {
+	case rclone.DirectionBackup, rclone.DirectionRestore, rclone.DirectionCopy, rclone.DirectionVerify:
+		return kind
+	case "resolve":
+		return rclone.DirectionRestore
+	default:
+		return operationTypeMigrate
+	}
+}
+
+// findReleaseJob finds the data-mover job of a release, the way FindDataMoverJob
+// does for an operation.
+func findReleaseJob(jobs []batchv1.Job, namespace, releaseName string) *batchv1.Job {
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Namespace == namespace && job.Labels[instanceLabel] == releaseName &&
+			jobprogress.Description(job.Name) != "job" {
+			return job
+		}
+	}
+
+	return nil
+}
+
+// addJobProgress fills in how far along the job of a running operation is.
+func addJobProgress(ctx context.Context, cli kubernetes.Interface, operation *operationSummary) {
+	switch {
+	case operation.job == nil:
+	case operation.job.Status.Succeeded > 0:
+		percentage := 100
+		operation.Progress = &percentage
+	case operation.job.Status.Active > 0:
+		if latest, ok := findJobProgress(ctx, cli, operation.job); ok {
+			percentage := int(latest.Percentage)
+			operation.Progress = &percentage
+		}
+	}
+}
+
+func writeOperations(out io.Writer, operations []operationSummary, output string, showContext bool,
+	now time.Time,
+) error {
+	if operations == nil {
+		operations = []operationSummary{}
+	}
+
+	if output != outputTable {
+		return writeStructured(out, operations, output)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0) //nolint:mnd
+
+	header := "ID\tTYPE\tNAMESPACES\tSTRATEGY\tSTATE\tPROGRESS\tAGE"
+	if showContext {
+		header = "CONTEXT\t" + header
+	}
+
+	fmt.Fprintln(writer, header)
+
+	for _, operation := range operations {
+		progress := "-"
+		if operation.Progress != nil {
+			progress = fmt.Sprintf("%d%%", *operation.Progress)
+		}
+
+		row := strings.Join([]string{
+			operation.ID, operation.Type, strings.Join(operation.Namespaces, ","), orDash(operation.Strategy),
+			operation.State, progress, duration.HumanDuration(now.Sub(operation.Created)),
+		}, "\t")
+
+		if showContext {
+			row = orDash(operation.Context) + "\t" + row
+		}
+
+		fmt.Fprintln(writer, row)
+	}
+
+	return writer.Flush() //nolint:wrapcheck
+}
+
+// writeStructured prints value as JSON or YAML, for scripts.
+func writeStructured(out io.Writer, value any, output string) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if output == outputJSON {
+		data, err = json.MarshalIndent(value, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal the output: %w", err)
+	}
+
+	if _, err = out.Write(data); err != nil {
+		return fmt.Errorf("failed to write the output: %w", err)
+	}
+
+	return nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
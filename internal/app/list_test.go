@@ -0,0 +1,113 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v4/pkg/release"
+	"helm.sh/helm/v4/pkg/release/common"
+	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+func TestSummarizeOperations(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 4, 11, 10, 0, 0, 0, time.UTC)
+
+	newRelease := func(namespace, name string, status common.Status, deployed time.Time) release.Releaser {
+		return &releasev1.Release{
+			Name:      name,
+			Namespace: namespace,
+			Info:      &releasev1.Info{Status: status, LastDeployed: deployed},
+		}
+	}
+
+	newJob := func(namespace, name, instance string, status batchv1.JobStatus) batchv1.Job {
+		return batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: namespace, Labels: map[string]string{"app.kubernetes.io/instance": instance},
+			},
+			Status: status,
+		}
+	}
+
+	releases := []release.Releaser{
+		newRelease("apps", "pv-migrate-brave-otter-clusterip", common.StatusDeployed, start.Add(time.Minute)),
+		newRelease("db", "pv-migrate-db1-backup", common.StatusDeployed, start.Add(time.Hour)),
+		newRelease("other", "pv-migrate-brave-otter-loadbalancer-dest", common.StatusDeployed, start.Add(2*time.Minute)),
+		newRelease("apps", "pv-migrate-brave-otter-mount", common.StatusFailed, start),
+		newRelease("db", "unrelated", common.StatusDeployed, start),
+	}
+
+	jobs := []batchv1.Job{
+		newJob("apps", "pv-migrate-brave-otter-clusterip-rsync", "pv-migrate-brave-otter-clusterip",
+			batchv1.JobStatus{Active: 1}),
+		newJob("db", "pv-migrate-db1-backup-rclone", "pv-migrate-db1-backup", batchv1.JobStatus{Failed: 1}),
+	}
+
+	operations := app.SummarizeOperations("prod", releases, jobs)
+	require.Len(t, operations, 2)
+
+	migration := operations[0]
+	assert.Equal(t, "prod", migration.Context)
+	assert.Equal(t, "brave-otter", migration.ID)
+	assert.Equal(t, "migrate", migration.Type)
+	assert.Equal(t, []string{"apps", "other"}, migration.Namespaces)
+	assert.Equal(t, "mount,clusterip,loadbalancer", migration.Strategy)
+	assert.Equal(t, "Running", migration.State)
+	assert.Equal(t, start, migration.Created)
+	assert.Len(t, migration.Releases, 3)
+
+	backup := operations[1]
+	assert.Equal(t, "db1", backup.ID)
+	assert.Equal(t, "backup", backup.Type)
+	assert.Empty(t, backup.Strategy)
+	assert.Equal(t, "Failed", backup.State)
+	assert.Equal(t, []string{"db/pv-migrate-db1-backup"}, backup.Releases)
+
+	// Without its job, the sshd side of a migration has the state of its release.
+	operations = app.SummarizeOperations("", releases[2:3], nil)
+	require.Len(t, operations, 1)
+	assert.Equal(t, "deployed", operations[0].State)
+}
+
+func TestWriteOperations(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 4, 11, 10, 0, 0, 0, time.UTC)
+	operations := app.SummarizeOperations("prod", []release.Releaser{&releasev1.Release{
+		Name:      "pv-migrate-db1-restore",
+		Namespace: "db",
+		Info:      &releasev1.Info{Status: common.StatusDeployed, LastDeployed: start},
+	}}, nil)
+
+	var table bytes.Buffer
+
+	require.NoError(t, app.WriteOperations(&table, operations, "table", true, start.Add(90*time.Minute)))
+	assert.Equal(t, "CONTEXT   ID    TYPE      NAMESPACES   STRATEGY   STATE      PROGRESS   AGE\n"+
+		"prod      db1   restore   db           -          deployed   -          90m\n", table.String())
+
+	var out bytes.Buffer
+
+	require.NoError(t, app.WriteOperations(&out, operations, "json", false, start))
+
+	var decoded []map[string]any
+
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Len(t, decoded, 1)
+	assert.Equal(t, "db1", decoded[0]["id"])
+	assert.Equal(t, "2026-04-11T10:00:00Z", decoded[0]["created"])
+	assert.NotContains(t, decoded[0], "progress")
+
+	out.Reset()
+	require.NoError(t, app.WriteOperations(&out, nil, "yaml", false, start))
+	assert.Equal(t, "[]\n", out.String())
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+)
+
+const (
+	FlagComponent = "component"
+
+	// The components of an operation that have logs, named after the component
+	// label the chart gives their pods.
+	componentRsync  = "rsync"
+	componentRclone = "rclone"
+	componentSshd   = "sshd"
+)
+
+var logComponents = []string{componentRsync, componentRclone, componentSshd}
+
+// logsOptions are the flags of logs.
+type logsOptions struct {
+	kubeconfig string
+	contexts   []string
+	namespace  string
+	follow     bool
+	since      time.Duration
+	components []string
+}
+
+// logSource is a pod of an operation whose log is shown.
+type logSource struct {
+	component string
+	cli       kubernetes.Interface
+	pod       *corev1.Pod
+	// job is the name of the data-mover job of the pod, which tells how its
+	// lines are rendered, or empty for an sshd pod.
+	job string
+}
+
+func buildLogsCmd(logger **slog.Logger) (*cobra.Command, error) {
+	var options logsOptions
+
+	cmd := &cobra.Command{
+		Use:   "logs <operation-id>",
+		Short: "Show the logs of an operation",
+		Long: "Show the full logs of the pods of an operation: the rsync or rclone job that moves the data, " +
+			"and the sshd pods it connects to. Each line is prefixed with its component and pod " +
+			"when there are several. rclone's JSON records are shown as \"level: message\" lines.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case args[0] == "":
+				return errors.New("operation ID must not be empty")
+			case options.since < 0:
+				return fmt.Errorf("invalid --since: %s, must not be negative", options.since)
+			}
+
+			for _, component := range options.components {
+				if !slices.Contains(logComponents, component) {
+					return fmt.Errorf("invalid --%s: %q, must be one of %s",
+						FlagComponent, component, strings.Join(logComponents, ", "))
+				}
+			}
+
+			return runLogs(cmd.Context(), cmd.OutOrStdout(), *logger, &options, args[0])
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	addContextsFlag(flags, &options.contexts, "Kubernetes context to search, "+
+		"repeat it for the clusters of a migration between clusters")
+	flags.StringVarP(&options.namespace, "namespace", "n", "", "Namespace to search (default: all namespaces)")
+	flags.BoolVarP(&options.follow, "follow", "f", false, "Keep streaming the logs until the pods stop")
+	flags.DurationVar(&options.since, "since", 0,
+		"Only show the lines newer than this, such as 10m, rounded up to whole seconds (default: the whole log)")
+	flags.StringSliceVar(&options.components, FlagComponent, nil,
+		"Components to show the logs of: "+strings.Join(logComponents, ", ")+" (default: all)")
+
+	if err := cmd.RegisterFlagCompletionFunc(FlagComponent,
+		buildStaticSliceCompletionFunc(logComponents)); err != nil {
+		return nil, fmt.Errorf("failed to register completion for flag %q: %w", FlagComponent, err)
+	}
+
+	return cmd, nil
+}
+
+func runLogs(ctx context.Context, out io.Writer, logger *slog.Logger, options *logsOptions, operationID string) error {
+	clusters, err := connectClusters(options.kubeconfig, options.contexts, logger)
+	if err != nil {
+		return err
+	}
+
+	sources, err := findLogSources(ctx, clusters, options, operationID, logger)
+	if err != nil {
+		return err
+	}
+
+	return writeLogs(ctx, out, sources, options)
+}
+
+// findLogSources finds the pods of the operation of the wanted components: the
+// pod of the data-mover job, in whichever cluster has it, and the sshd pods of
+// every cluster. An operation may have either alone, such as a backup without
+// sshd, or a migration whose job was already removed, so only finding neither
+// is an error.
+func findLogSources(
+	ctx context.Context, clusters []cluster, options *logsOptions, operationID string, logger *slog.Logger,
+) ([]logSource, error) {
+	wanted := func(component string) bool {
+		return len(options.components) == 0 || slices.Contains(options.components, component)
+	}
+
+	releasePrefix := opid.ReleasePrefix + operationID + "-"
+
+	var sources []logSource
+
+	if wanted(componentRsync) || wanted(componentRclone) {
+		job, jobCluster, err := findOperationJob(ctx, clusters, options.namespace, releasePrefix, logger)
+
+		switch {
+		case errors.Is(err, k8s.ErrJobNotFound):
+			logger.Debug("no data-mover job found for the operation", "id", operationID)
+		case err != nil:
+			return nil, err
+		default:
+			component := componentRsync
+			if strings.HasSuffix(job.Name, "-"+componentRclone) {
+				component = componentRclone
+			}
+
+			if wanted(component) {
+				pod, podErr := k8s.FindJobPod(ctx, jobCluster.client.KubeClient, job)
+				if podErr != nil {
+					return nil, contextError(jobCluster.context, podErr)
+				}
+
+				sources = append(sources, logSource{
+					component: component, cli: jobCluster.client.KubeClient, pod: pod, job: job.Name,
+				})
+			}
+		}
+	}
+
+	if wanted(componentSshd) {
+		for i := range clusters {
+			pods, err := k8s.FindSshdPods(ctx, clusters[i].client.KubeClient, options.namespace, releasePrefix)
+			if err != nil {
+				return nil, contextError(clusters[i].context, err)
+			}
+
+			for j := range pods {
+				sources = append(sources, logSource{
+					component: componentSshd, cli: clusters[i].client.KubeClient, pod: &pods[j],
+				})
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no pods found for operation %s", operationID)
+	}
+
+	return sources, nil
+}
+
+// sinceSeconds converts --since to the whole seconds the API takes, rounding up:
+// rounding down would turn a value under a second into 0, which the API rejects,
+// and would show lines older than asked for in general.
+func sinceSeconds(since time.Duration) *int64 {
+	if since <= 0 {
+		return nil
+	}
+
+	seconds := int64((since + time.Second - 1) / time.Second)
+
+	return &seconds
+}
+
+// writeLogs writes the logs of the sources to out. Without --follow they come
+// one after the other; with it they are streamed at once, a whole line at a
+// time, so the lines of different pods interleave but never mix.
+func writeLogs(ctx context.Context, out io.Writer, sources []logSource, options *logsOptions) error {
+	logOptions := &corev1.PodLogOptions{Follow: options.follow, SinceSeconds: sinceSeconds(options.since)}
+
+	var mu sync.Mutex
+
+	stream := func(ctx context.Context, source *logSource) error {
+		prefix := ""
+		if len(sources) > 1 {
+			prefix = "[" + source.component + " " + source.pod.Namespace + "/" + source.pod.Name + "] "
+		}
+
+		return k8s.StreamPodLogs(ctx, source.cli, source.pod, logOptions, func(line string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if source.job != "" {
+				line = k8s.RenderJobLogLine(source.job, line)
+			}
+
+			if _, err := io.WriteString(out, prefix+line+"\n"); err != nil {
+				return fmt.Errorf("failed to write the logs: %w", err)
+			}
+
+			return nil
+		})
+	}
+
+	if !options.follow {
+		for i := range sources {
+			if err := stream(ctx, &sources[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	for i := range sources {
+		group.Go(func() error { return stream(groupCtx, &sources[i]) })
+	}
+
+	return group.Wait() //nolint:wrapcheck
+}
@@ -0,0 +1,81 @@
+package app_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+func TestOperationLogs(t *testing.T) {
+	t.Parallel()
+
+	const jobName = "pv-migrate-brave-otter-clusterip-dest-rsync"
+
+	cli := fake.NewClientset(
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: "apps",
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "Helm"},
+		}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobName + "-abc",
+				Namespace: "apps",
+				Labels:    map[string]string{"job-name": jobName},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodFailed},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "pv-migrate-brave-otter-clusterip-src-sshd-xyz",
+			Namespace: "db",
+			Labels: map[string]string{
+				"app.kubernetes.io/component": "sshd",
+				"app.kubernetes.io/instance":  "pv-migrate-brave-otter-clusterip-src",
+			},
+		}},
+	)
+
+	var out strings.Builder
+
+	require.NoError(t, app.OperationLogs(t.Context(), cli, "brave-otter", nil, &out))
+	assert.Equal(t, "[rsync apps/"+jobName+"-abc] fake logs\n"+
+		"[sshd db/pv-migrate-brave-otter-clusterip-src-sshd-xyz] fake logs\n", out.String())
+
+	out.Reset()
+
+	require.NoError(t, app.OperationLogs(t.Context(), cli, "brave-otter", []string{"sshd"}, &out))
+	assert.Equal(t, "fake logs\n", out.String(), "a single pod needs no prefix")
+
+	err := app.OperationLogs(t.Context(), cli, "brave-otter", []string{"rclone"}, &out)
+	require.EqualError(t, err, "no pods found for operation brave-otter")
+
+	err = app.OperationLogs(t.Context(), cli, "other", nil, &out)
+	require.EqualError(t, err, "no pods found for operation other")
+}
+
+func TestSinceSeconds(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, app.SinceSeconds(0), "no --since shows the whole log")
+
+	for since, want := range map[time.Duration]int64{
+		500 * time.Millisecond:           1,
+		time.Second:                      1,
+		1500 * time.Millisecond:          2,
+		10 * time.Minute:                 600,
+		10*time.Minute + time.Nanosecond: 601,
+	} {
+		got := app.SinceSeconds(since)
+		require.NotNil(t, got, "--since %s", since)
+		assert.Equal(t, want, *got, "--since %s", since)
+	}
+}
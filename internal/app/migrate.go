@@ -85,6 +85,7 @@ const (
 	FlagNoCompress                = "no-compress"
 	FlagNonRoot                   = "non-root"
 	FlagRsyncExtraArgs            = "rsync-extra-args"
+	FlagBWLimit                   = "bwlimit"
 	FlagRsyncPush                 = "rsync-push"
 
 	FlagHelmTimeout   = "helm-timeout"
@@ -109,6 +110,9 @@ type Options struct {
 	// intermediate fields for cobra flag binding
 	strategies   []string
 	keyAlgorithm string
+
+	configPath string
+	profile    string
 }
 
 //nolint:funlen
@@ -146,16 +150,28 @@ func BuildMigrateCmd(ctx context.Context, version, commit, date string, logger *
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 			cmd.SilenceUsage = true // usage should only be printed when there is a usage error (e.g., invalid flags)
 
-			if logger != nil { // external logger provided (e.g. tests)
-				return nil
+			secrets := secretsOnCommandLine(cmd)
+
+			// Before the logger, so that they can set its level and format too. The
+			// environment goes first, the profile only sets what it leaves unset.
+			if err := applyEnv(cmd); err != nil {
+				return err
+			}
+
+			if err := applyProfile(cmd, options.configPath, options.profile); err != nil {
+				return err
 			}
 
-			var err error
+			if logger == nil { // no external logger provided (e.g. by tests)
+				var err error
 
-			if logger, err = buildLogger(options.LogLevel, options.LogFormat, writer, isATTY); err != nil {
-				return fmt.Errorf("failed to build logger: %w", err)
+				if logger, err = buildLogger(options.LogLevel, options.LogFormat, writer, isATTY); err != nil {
+					return fmt.Errorf("failed to build logger: %w", err)
+				}
 			}
 
+			warnSecretsOnCommandLine(secrets, logger)
+
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error { //nolint:contextcheck
@@ -183,8 +199,32 @@ func BuildMigrateCmd(ctx context.Context, version, commit, date string, logger *
 	}
 
 	cmd.AddCommand(buildCompletionCmd())
+	cmd.AddCommand(buildConfigCmd(&options))
 	cmd.AddCommand(buildCleanupCmd(&logger)) //nolint:contextcheck
-	cmd.AddCommand(buildStatusCmd(&logger))  //nolint:contextcheck
+
+	statusCmd, err := buildStatusCmd(&logger) //nolint:contextcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to build status command: %w", err)
+	}
+
+	cmd.AddCommand(statusCmd)
+
+	listCmd, err := buildListCmd(&logger) //nolint:contextcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to build list command: %w", err)
+	}
+
+	cmd.AddCommand(listCmd)
+
+	logsCmd, err := buildLogsCmd(&logger) //nolint:contextcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logs command: %w", err)
+	}
+
+	cmd.AddCommand(logsCmd)
+	cmd.AddCommand(buildDebugCmd(&logger)) //nolint:contextcheck
+
+	cmd.AddCommand(buildInteractiveCmd(&logger, migration.ImageTag, migration.ChartVersion)) //nolint:contextcheck
 
 	backupCmd, err := buildBackupCmd(&logger, migration.ImageTag, migration.ChartVersion) //nolint:contextcheck
 	if err != nil {
@@ -196,13 +236,21 @@ func BuildMigrateCmd(ctx context.Context, version, commit, date string, logger *
 		return nil, fmt.Errorf("failed to build restore command: %w", err)
 	}
 
+	backupsCmd, err := buildBackupsCmd(&logger, migration.ImageTag, migration.ChartVersion) //nolint:contextcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to build backups command: %w", err)
+	}
+
 	cmd.AddCommand(backupCmd)
 	cmd.AddCommand(restoreCmd)
+	cmd.AddCommand(backupsCmd)
 
 	cmd.InitDefaultVersionFlag()
 	versionFlag := cmd.Flags().Lookup("version")
 	versionFlag.Usage = strings.ToUpper(versionFlag.Usage[:1]) + versionFlag.Usage[1:]
 
+	addEnvUsage(&cmd)
+
 	return &cmd, nil
 }
 
@@ -253,6 +301,7 @@ func setMigrateCmdFlags(cmd *cobra.Command, options *Options, logLevels, logForm
 			" or an slog-parseable level: https://pkg.go.dev/log/slog#Level.UnmarshalText")
 	persistentFlags.StringVar(&options.LogFormat, FlagLogFormat, options.LogFormat,
 		"Log format, one of "+strings.Join(logFormats, ", "))
+	setConfigFlags(persistentFlags, &options.configPath, &options.profile)
 
 	flags.StringVarP(
 		&migration.Source.KubeconfigPath,
@@ -350,6 +399,8 @@ func setMigrateCmdFlags(cmd *cobra.Command, options *Options, logLevels, logForm
 
 	flags.StringVar(&migration.RsyncExtraArgs, FlagRsyncExtraArgs, migration.RsyncExtraArgs,
 		"Extra rsync flags appended to the rsync command (use at your own risk)")
+	flags.StringVar(&migration.BWLimit, FlagBWLimit, migration.BWLimit,
+		"Bandwidth limit for rsync in KiB/s, or with a suffix such as 10M (rsync --bwlimit)")
 	flags.BoolVar(&migration.Push, FlagRsyncPush, migration.Push,
 		"Push mode: run rsync on the source side and sshd on the destination side. "+
 			"Use when the source side cannot expose a service, e.g., behind a firewall or NAT. "+
@@ -366,7 +417,7 @@ func setMigrateCmdFlags(cmd *cobra.Command, options *Options, logLevels, logForm
 	flags.StringSliceVar(&migration.HelmFileValues, FlagHelmSetFile, migration.HelmFileValues,
 		"Additional Helm values from files (key1=path1,key2=path2)")
 
-	return nil
+	return setHookFlags(cmd, &migration.Hooks, "in the namespace of the source PVC")
 }
 
 // structuredLogsRequested reports whether this invocation logs machine-readable
@@ -0,0 +1,304 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/schedule"
+	"github.com/utkuozdemir/pv-migrate/pvmigrate"
+)
+
+const (
+	FlagSchedule         = "schedule"
+	FlagScheduleName     = "schedule-name"
+	FlagScheduleTimeZone = "time-zone"
+	FlagImage            = "image"
+	FlagApply            = "apply"
+
+	defaultImageRepository = "docker.io/utkuozdemir/pv-migrate"
+)
+
+// scheduleLocalFlags are the flags of backup schedule itself, which are not
+// passed on to the scheduled backup, along with the ones that only say how to
+// reach the cluster from here. The config file is not in the pod either; the
+// flags a profile sets are passed on instead.
+var scheduleLocalFlags = []string{
+	FlagSchedule, FlagScheduleName, FlagScheduleTimeZone, FlagImage, FlagApply,
+	FlagSourceKubeconfig, FlagSourceContext, FlagSourceNamespace, FlagName,
+	FlagConfig, FlagProfile,
+}
+
+// scheduleUnsupportedFlags cannot carry over to every run of a schedule.
+var scheduleUnsupportedFlags = map[string]string{
+	FlagID:          "an operation ID must be unique to a run",
+	FlagHelmSetFile: "use --helm-set or a values file instead",
+}
+
+// scheduleSecretEnv maps the credentials of a backup to the environment
+// variables the scheduled backup reads them from.
+func scheduleSecretEnv(backup *pvmigrate.Backup) map[string]string {
+	return map[string]string{
+		envS3AccessKey:           backup.AccessKey,
+		envS3SecretKey:           backup.SecretKey,
+		envAzureStorageAccount:   backup.StorageAccount,
+		envAzureStorageKey:       backup.StorageKey,
+		envGCSServiceAccountJSON: backup.GCSServiceAccountJSON,
+		envSFTPPassword:          backup.SFTPPassword,
+		envWebDAVPassword:        backup.WebDAVPassword,
+		envWebDAVBearerToken:     backup.WebDAVBearerToken,
+		envSwiftKey:              backup.SwiftKey,
+		envB2Account:             backup.B2Account,
+		envB2Key:                 backup.B2Key,
+	}
+}
+
+// scheduleCredentialFlags are the flags whose values move into the credentials
+// secret, either through scheduleSecretEnv or as a mounted file.
+var scheduleCredentialFlags = []string{
+	FlagAccessKey, FlagSecretKey, FlagStorageAccount, FlagStorageKey, FlagGCSServiceAccountFile,
+	FlagSFTPPassword, FlagSFTPKeyFile, FlagWebDAVPassword, FlagWebDAVBearerToken, FlagSwiftKey,
+	FlagB2Account, FlagB2Key, FlagRcloneConfig, FlagHelmValues,
+}
+
+func buildBackupScheduleCmd(logger **slog.Logger, imageTag string) (*cobra.Command, error) {
+	var (
+		backup              pvmigrate.Backup
+		gcsBucketPolicyOnly = true
+		opts                schedule.Options
+		apply               bool
+	)
+
+	imageDefault := defaultImageRepository + ":latest"
+	if imageTag != "" {
+		imageDefault = defaultImageRepository + ":" + imageTag
+	}
+
+	cmd := &cobra.Command{
+		Use:   "schedule --schedule <cron> --source <pvc-name> --backend <backend> --bucket <bucket> --name <name>",
+		Short: "Generate a CronJob that runs a backup on a schedule",
+		Long: "Generate the manifests of a CronJob that runs pv-migrate backup in the cluster with the given flags, " +
+			"along with its service account, role, role binding and a secret holding the credentials. " +
+			"The manifests are printed, or applied with --apply. Each run is named after its Job, " +
+			"which is the schedule name followed by the scheduled time.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			backup.GCSBucketPolicyOnly = &gcsBucketPolicyOnly
+
+			return runBackupSchedule(cmd, &backup, &opts, apply, *logger)
+		},
+	}
+
+	if err := setBackupFlags(cmd, &backup, &gcsBucketPolicyOnly); err != nil {
+		return nil, err
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Schedule, FlagSchedule, "", `Cron schedule of the backups, e.g. "0 2 * * *" or "@daily"`)
+	flags.StringVar(&opts.Name, FlagScheduleName, "",
+		"Name of the CronJob and its objects (default: the value of --name)")
+	flags.StringVar(&opts.TimeZone, FlagScheduleTimeZone, "",
+		"Time zone of the schedule, e.g. Europe/Berlin (default: the time zone of the cluster's controller)")
+	flags.StringVar(&opts.Image, FlagImage, imageDefault, "pv-migrate image the CronJob runs")
+	flags.BoolVar(&apply, FlagApply, false, "Apply the manifests to the cluster instead of printing them")
+
+	if err := cmd.MarkFlagRequired(FlagSchedule); err != nil {
+		return nil, fmt.Errorf("failed to mark flag %q as required: %w", FlagSchedule, err)
+	}
+
+	return cmd, nil
+}
+
+func runBackupSchedule(
+	cmd *cobra.Command,
+	backup *pvmigrate.Backup,
+	opts *schedule.Options,
+	apply bool,
+	logger *slog.Logger,
+) error {
+	if err := resolveBackupCredentials(cmd, backup); err != nil {
+		return err
+	}
+
+	if opts.Name == "" {
+		opts.Name = backup.Name
+	}
+
+	if opts.Name == "" {
+		return fmt.Errorf("--%s or --%s is required", FlagName, FlagScheduleName)
+	}
+
+	var (
+		cli *k8s.ClusterClient
+		err error
+	)
+
+	if apply || backup.PVC.Namespace == "" {
+		cli, err = k8s.GetClusterClient(backup.PVC.KubeconfigPath, backup.PVC.Context, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts.Namespace = backup.PVC.Namespace
+	if opts.Namespace == "" {
+		opts.Namespace = cli.NsInContext
+	}
+
+	if err = buildScheduledBackup(cmd.Flags(), backup, opts); err != nil {
+		return err
+	}
+
+	if err = opts.Validate(); err != nil {
+		return err
+	}
+
+	if !apply {
+		manifests, renderErr := opts.Render()
+		if renderErr != nil {
+			return renderErr
+		}
+
+		_, err = cmd.OutOrStdout().Write(manifests)
+
+		return err //nolint:wrapcheck
+	}
+
+	if err = opts.Apply(cmd.Context(), cli.KubeClient); err != nil {
+		return err
+	}
+
+	logger.Info("✅ Scheduled backup applied", "cronjob", opts.Name, "namespace", opts.Namespace,
+		"schedule", opts.Schedule)
+
+	return nil
+}
+
+// buildScheduledBackup turns the backup flags given to backup schedule into the
+// args of the scheduled backup, moving the credentials and local files into the
+// credentials secret.
+func buildScheduledBackup(flags *pflag.FlagSet, backup *pvmigrate.Backup, opts *schedule.Options) error {
+	opts.Args = []string{"backup", "--" + FlagSourceNamespace + "=" + opts.Namespace}
+	opts.Env = map[string]string{}
+	opts.Files = map[string]string{}
+	opts.SecretData = map[string]string{}
+	opts.Exec = backup.Hooks.Pre != "" || backup.Hooks.Post != ""
+
+	// A raw remote spec is a fixed location, every other mode writes each run
+	// under its own name.
+	if backup.Remote == "" {
+		opts.Args = append(opts.Args, "--"+FlagName+"=$("+schedule.BackupNameEnv+")")
+	}
+
+	var err error
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || !flag.Changed || slices.Contains(scheduleLocalFlags, flag.Name) ||
+			slices.Contains(scheduleCredentialFlags, flag.Name) {
+			return
+		}
+
+		if reason, ok := scheduleUnsupportedFlags[flag.Name]; ok {
+			err = fmt.Errorf("--%s is not supported for a scheduled backup: %s", flag.Name, reason)
+
+			return
+		}
+
+		var args []string
+
+		args, err = flagArgs(flag)
+		opts.Args = append(opts.Args, args...)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for env, value := range scheduleSecretEnv(backup) {
+		if value != "" {
+			opts.Env[env] = env
+			opts.SecretData[env] = value
+		}
+	}
+
+	if backup.SFTPKeyPEM != "" {
+		opts.Args = append(opts.Args, "--"+FlagSFTPKeyFile+"="+addScheduleFile(opts, "sftp-key", backup.SFTPKeyPEM))
+	}
+
+	if backup.RcloneConfigFile != "" {
+		data, readErr := os.ReadFile(backup.RcloneConfigFile)
+		if readErr != nil {
+			return fmt.Errorf("failed to read rclone config %s: %w", backup.RcloneConfigFile, readErr)
+		}
+
+		opts.Args = append(opts.Args, "--"+FlagRcloneConfig+"="+addScheduleFile(opts, "rclone.conf", string(data)))
+	}
+
+	return addScheduleValuesFiles(backup.HelmValuesFiles, opts)
+}
+
+// addScheduleValuesFiles passes URLs on as they are and copies local values files
+// into the credentials secret.
+func addScheduleValuesFiles(valuesFiles []string, opts *schedule.Options) error {
+	for i, valuesFile := range valuesFiles {
+		if strings.Contains(valuesFile, "://") {
+			opts.Args = append(opts.Args, "--"+FlagHelmValues+"="+valuesFile)
+
+			continue
+		}
+
+		data, err := os.ReadFile(valuesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read values file %s: %w", valuesFile, err)
+		}
+
+		mountPath := addScheduleFile(opts, fmt.Sprintf("values-%d.yaml", i), string(data))
+		opts.Args = append(opts.Args, "--"+FlagHelmValues+"="+mountPath)
+	}
+
+	return nil
+}
+
+func addScheduleFile(opts *schedule.Options, key, content string) string {
+	opts.SecretData[key] = content
+	opts.Files[key] = key
+
+	return path.Join(schedule.FilesMountPath, key)
+}
+
+// flagArgs renders a flag back into args that set it to its current value.
+func flagArgs(flag *pflag.Flag) ([]string, error) {
+	if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+		var args []string
+		for _, value := range sliceValue.GetSlice() {
+			args = append(args, "--"+flag.Name+"="+value)
+		}
+
+		return args, nil
+	}
+
+	// A map flag prints as a bracketed CSV record of key=value pairs.
+	if flag.Value.Type() == "stringToString" {
+		values, err := csv.NewReader(strings.NewReader(strings.Trim(flag.Value.String(), "[]"))).Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the value of --%s: %w", flag.Name, err)
+		}
+
+		args := make([]string, 0, len(values))
+		for _, value := range values {
+			args = append(args, "--"+flag.Name+"="+value)
+		}
+
+		return args, nil
+	}
+
+	return []string{"--" + flag.Name + "=" + flag.Value.String()}, nil
+}
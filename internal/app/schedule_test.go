@@ -0,0 +1,145 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+func TestBackupScheduleCmd_PrintsManifests(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	cmd.SetOut(&out)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup", "schedule",
+		"--schedule", "0 2 * * *",
+		"--source", "app-data",
+		"--source-namespace", "app",
+		"--source-kubeconfig", "/tmp/missing-kubeconfig",
+		"--backend", "s3",
+		"--bucket", "pv-backups",
+		"--access-key", "access",
+		"--secret-key", "secret",
+		"--helm-set", "rclone.podLabels.team=data",
+		"--name", "app-data",
+	})
+
+	require.NoError(t, cmd.Execute())
+
+	manifests := out.String()
+	assert.Contains(t, manifests, "name: app-data-credentials\n")
+	assert.Contains(t, manifests, "PV_MIGRATE_S3_SECRET_KEY: secret\n")
+	assert.Contains(t, manifests, "- --name=$(BACKUP_NAME)\n")
+	assert.Contains(t, manifests, "- --source=app-data\n")
+	assert.Contains(t, manifests, "- --helm-set=rclone.podLabels.team=data\n")
+	assert.NotContains(t, manifests, "--secret-key")
+	assert.NotContains(t, manifests, "--source-kubeconfig")
+}
+
+func TestBackupScheduleCmd_PassesOnProfileFlags(t *testing.T) {
+	t.Parallel()
+
+	configPath := writeTestConfig(t, "profiles:\n  nightly:\n    bucket: team-backups\n    non-root: true\n")
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	cmd.SetOut(&out)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup", "schedule",
+		"--config", configPath,
+		"--profile", "nightly",
+		"--schedule", "0 2 * * *",
+		"--source", "app-data",
+		"--source-namespace", "app",
+		"--backend", "s3",
+		"--name", "app-data",
+	})
+
+	require.NoError(t, cmd.Execute())
+
+	manifests := out.String()
+	assert.Contains(t, manifests, "- --bucket=team-backups\n")
+	assert.Contains(t, manifests, "- --non-root=true\n")
+	assert.NotContains(t, manifests, "--config", "the config file is not in the pod")
+	assert.NotContains(t, manifests, "--profile")
+}
+
+func TestBackupScheduleCmd_RejectsFixedID(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	cmd.SetOut(io.Discard)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup", "schedule",
+		"--schedule", "0 2 * * *",
+		"--source", "app-data",
+		"--source-namespace", "app",
+		"--backend", "s3",
+		"--bucket", "pv-backups",
+		"--name", "app-data",
+		"--id", "nightly",
+	})
+
+	require.ErrorContains(t, cmd.Execute(), "--id is not supported for a scheduled backup")
+}
+
+func TestBackupScheduleCmd_GrantsExecForHooks(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	cmd, err := app.BuildMigrateCmd(context.Background(), "dev", "commit", "date", logger)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	cmd.SetOut(&out)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{
+		"backup", "schedule",
+		"--schedule", "0 2 * * *",
+		"--source", "app-data",
+		"--source-namespace", "app",
+		"--backend", "s3",
+		"--bucket", "pv-backups",
+		"--name", "app-data",
+		"--pre-hook", "fsfreeze -f /data",
+		"--post-hook", "fsfreeze -u /data",
+		"--hook-selector", "app=db",
+	})
+
+	require.NoError(t, cmd.Execute())
+
+	manifests := out.String()
+	assert.Contains(t, manifests, "- --pre-hook=fsfreeze -f /data\n")
+	assert.Contains(t, manifests, "- --hook-selector=app=db\n")
+	assert.Contains(t, manifests, "- pods/exec\n")
+}
@@ -7,11 +7,13 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
+	"helm.sh/helm/v4/pkg/release"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,61 +23,166 @@ import (
 	"github.com/utkuozdemir/pv-migrate/internal/jobprogress"
 	"github.com/utkuozdemir/pv-migrate/internal/k8s"
 	"github.com/utkuozdemir/pv-migrate/internal/opid"
+	"github.com/utkuozdemir/pv-migrate/internal/progresslog"
 )
 
-func buildStatusCmd(logger **slog.Logger) *cobra.Command {
-	var (
-		kubeconfig  string
-		kubeContext string
-		namespace   string
-		follow      bool
-	)
+// statusOptions are the flags of status, and where its documents go.
+type statusOptions struct {
+	kubeconfig     string
+	contexts       []string
+	namespace      string
+	follow         bool
+	all            bool
+	output         string
+	structuredLogs bool
+	out            io.Writer
+}
+
+func buildStatusCmd(logger **slog.Logger) (*cobra.Command, error) {
+	var options statusOptions
 
 	cmd := &cobra.Command{
-		Use:   "status <operation-id>",
+		Use:   "status [operation-id...]",
 		Short: "Show the status of a detached operation",
-		Args:  cobra.ExactArgs(1),
+		Long: "Show the status of one or more detached operations. " +
+			"With --follow and several IDs, or --all, they are followed at once, with a progress bar each. " +
+			"--output json or yaml prints a document per operation for scripts instead of log records, " +
+			"as a JSON array or a YAML stream for several.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if args[0] == "" {
+			switch {
+			case !options.all && len(args) == 0:
+				return errors.New("provide an operation ID or use --all")
+			case options.all && len(args) > 0:
+				return errors.New("--all cannot be combined with operation IDs")
+			case options.all && !options.follow:
+				return errors.New("--all needs --follow, use list to see every operation")
+			case slices.Contains(args, ""):
 				return errors.New("operation ID must not be empty")
+			case !slices.Contains(statusOutputFormats, options.output):
+				return fmt.Errorf("invalid --%s: %q, must be one of %s",
+					FlagOutput, options.output, strings.Join(statusOutputFormats, ", "))
+			}
+
+			options.structuredLogs = structuredLogsRequested(cmd)
+			options.out = cmd.OutOrStdout()
+
+			if options.follow && len(args) != 1 {
+				return runStatusFollowMany(cmd.Context(), *logger, &options, args)
 			}
 
-			return runStatus(cmd.Context(), *logger, kubeconfig, kubeContext, namespace, args[0], follow,
-				structuredLogsRequested(cmd))
+			return runStatuses(cmd.Context(), *logger, &options, args)
 		},
 	}
 
 	flags := cmd.Flags()
-	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
-	flags.StringVar(&kubeContext, "context", "", "Kubernetes context to use")
-	flags.StringVarP(&namespace, "namespace", "n", "", "Namespace to search (default: all namespaces)")
-	flags.BoolVarP(&follow, "follow", "f", false, "Follow operation progress")
+	flags.StringVar(&options.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	addContextsFlag(flags, &options.contexts, "Kubernetes context to search, "+
+		"repeat it for the clusters of a migration between clusters")
+	flags.StringVarP(&options.namespace, "namespace", "n", "", "Namespace to search (default: all namespaces)")
+	flags.BoolVarP(&options.follow, "follow", "f", false, "Follow operation progress")
+	flags.BoolVar(&options.all, "all", false, "Follow every running operation, with --follow")
+	flags.StringVarP(&options.output, FlagOutput, "o", outputText,
+		"Output format: "+strings.Join(statusOutputFormats, ", "))
+
+	if err := cmd.RegisterFlagCompletionFunc(FlagOutput,
+		buildStaticSliceCompletionFunc(statusOutputFormats)); err != nil {
+		return nil, fmt.Errorf("failed to register completion for flag %q: %w", FlagOutput, err)
+	}
 
-	return cmd
+	return cmd, nil
 }
 
-func runStatus(
-	ctx context.Context, logger *slog.Logger, kubeconfig, kubeContext, namespace, operationID string,
-	follow, structuredLogs bool,
-) error {
-	client, err := k8s.GetClusterClient(kubeconfig, kubeContext, logger)
+// runStatuses reports on each of the operations in turn. Their documents are
+// printed together once all are found, so that the output is whole whichever of
+// them could not be.
+func runStatuses(ctx context.Context, logger *slog.Logger, options *statusOptions, operationIDs []string) error {
+	var errs []error
+
+	if options.output == outputText {
+		for _, operationID := range operationIDs {
+			errs = append(errs, runStatus(ctx, logger, options, operationID))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	documents := make([]statusDocument, 0, len(operationIDs))
+
+	for _, operationID := range operationIDs {
+		document, err := collectStatus(ctx, logger, options, operationID)
+		if document != nil {
+			documents = append(documents, *document)
+		}
+
+		errs = append(errs, err)
+	}
+
+	errs = append(errs, writeStatusDocuments(options.out, documents, options.output, len(operationIDs) > 1))
+
+	return errors.Join(errs...)
+}
+
+// collectStatus finds one operation for --output json or yaml, following it
+// first with --follow, and returns its document for the caller to print along
+// with those of the other operations. The document comes with an error when the
+// operation failed, and is nil when it could not be found.
+func collectStatus(
+	ctx context.Context, logger *slog.Logger, options *statusOptions, operationID string,
+) (*statusDocument, error) {
+	clusters, err := connectClusters(options.kubeconfig, options.contexts, logger)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ns := namespace
-	if ns == "" {
-		ns = client.NsInContext
+	job, jobCluster, err := findOperationJob(ctx, clusters, options.namespace,
+		opid.ReleasePrefix+operationID+"-", logger)
+	if err != nil {
+		return nil, err
+	}
+
+	client := jobCluster.client
+
+	var followErr error
+
+	if options.follow {
+		followErr = followJobProgress(ctx, client.KubeClient, job, options.structuredLogs, logger)
+		job = refreshJob(ctx, client.KubeClient, job, logger)
+	}
+
+	document := buildStatusDocument(ctx, clusters, jobCluster, options.namespace, operationID, job, logger)
+
+	if jobState(job) == jobStateFailed {
+		return &document, fmt.Errorf("operation %s failed", operationID)
+	}
+
+	return &document, followErr
+}
+
+func runStatus(ctx context.Context, logger *slog.Logger, options *statusOptions, operationID string) error {
+	clusters, err := connectClusters(options.kubeconfig, options.contexts, logger)
+	if err != nil {
+		return err
 	}
 
 	releasePrefix := opid.ReleasePrefix + operationID + "-"
 
-	job, err := k8s.FindDataMoverJob(ctx, client.KubeClient, ns, releasePrefix, logger)
+	job, jobCluster, err := findOperationJob(ctx, clusters, options.namespace, releasePrefix, logger)
 	if err != nil {
 		return err
 	}
 
-	if follow {
+	client := jobCluster.client
+	structuredLogs := options.structuredLogs
+
+	for i := range clusters {
+		if &clusters[i] != jobCluster {
+			printPeerReleases(&clusters[i], options.namespace, releasePrefix, logger)
+		}
+	}
+
+	logger = jobCluster.logger(logger, len(clusters) > 1)
+
+	if options.follow {
 		// No special case for an already-finished job: the waiter handles a
 		// terminal pod, and it is the only path that explains the exit code.
 		err = followJobProgress(ctx, client.KubeClient, job, structuredLogs, logger)
@@ -93,7 +200,7 @@ func runStatus(
 	// alone would bury the answer the pod still holds. The status line leads, so
 	// the evidence below it has context, then the explanation closes, and the
 	// exit code is non-zero so automation sees the failure too.
-	if job.Status.Failed > 0 {
+	if jobState(job) == jobStateFailed {
 		printJobStatus(job, logger)
 
 		palette := console.Palette{Enabled: console.ForTerminal(isatty.IsTerminal(os.Stderr.Fd()), structuredLogs)}
@@ -124,6 +231,63 @@ func runStatus(
 	return nil
 }
 
+// findOperationJob looks for the data-mover job of an operation in each cluster
+// in turn. Only one of the clusters of a migration between clusters has it.
+func findOperationJob(
+	ctx context.Context, clusters []cluster, namespace, releasePrefix string, logger *slog.Logger,
+) (*batchv1.Job, *cluster, error) {
+	for i := range clusters {
+		ns := namespace
+		if ns == "" {
+			ns = clusters[i].client.NsInContext
+		}
+
+		job, err := k8s.FindDataMoverJob(ctx, clusters[i].client.KubeClient, ns, releasePrefix, logger)
+
+		switch {
+		case err == nil:
+			return job, &clusters[i], nil
+		case errors.Is(err, k8s.ErrJobNotFound) && len(clusters) > 1:
+			continue
+		default:
+			return nil, nil, contextError(clusters[i].context, err)
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w for migration %s in any of the contexts", k8s.ErrJobNotFound, releasePrefix)
+}
+
+// printPeerReleases reports the releases of the operation in a cluster without
+// its job, such as the sshd side of a migration between clusters.
+func printPeerReleases(peer *cluster, namespace, releasePrefix string, logger *slog.Logger) {
+	releases, err := listReleases(peer.client, namespace, releasePrefix)
+	if err != nil {
+		logger.Warn("🔶 Failed to list the releases of the operation", "context", peer.context, "error", err)
+
+		return
+	}
+
+	if len(releases) == 0 {
+		logger.Warn("🔶 No release of the operation found", "context", peer.context)
+
+		return
+	}
+
+	for _, rel := range releases {
+		acc, err := release.NewAccessor(rel)
+		if err != nil {
+			continue
+		}
+
+		logger.Info("Peer release",
+			"context", peer.context,
+			"release", acc.Name(),
+			"namespace", acc.Namespace(),
+			"status", acc.Status(),
+		)
+	}
+}
+
 func followJobProgress(
 	ctx context.Context, cli kubernetes.Interface, job *batchv1.Job, structuredLogs bool, logger *slog.Logger,
 ) error {
@@ -152,9 +316,24 @@ func refreshJob(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job,
 }
 
 func printJobProgress(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job, logger *slog.Logger) {
+	latest, ok := findJobProgress(ctx, cli, job)
+	if !ok {
+		return
+	}
+
+	logger.Info("Operation progress",
+		"percentage", fmt.Sprintf("%d%%", latest.Percentage),
+		"transferred", formatBytes(latest.Transferred),
+		"total", formatBytes(latest.Total),
+	)
+}
+
+// findJobProgress reads the latest progress off the end of the log of the job's
+// pod, if it has printed any.
+func findJobProgress(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job) (progresslog.Update, bool) {
 	pod, err := k8s.FindJobPod(ctx, cli, job)
 	if err != nil {
-		return
+		return progresslog.Update{}, false
 	}
 
 	tailLines := int64(5) //nolint:mnd
@@ -162,52 +341,31 @@ func printJobProgress(ctx context.Context, cli kubernetes.Interface, job *batchv
 	stream, err := cli.CoreV1().Pods(job.Namespace).GetLogs(pod.Name,
 		&corev1.PodLogOptions{TailLines: &tailLines}).Stream(ctx)
 	if err != nil {
-		return
+		return progresslog.Update{}, false
 	}
 
 	defer stream.Close()
 
 	data, err := io.ReadAll(stream)
 	if err != nil {
-		return
-	}
-
-	latest, ok := jobprogress.FindLast(job.Name, string(data))
-	if !ok {
-		return
+		return progresslog.Update{}, false
 	}
 
-	logger.Info("Operation progress",
-		"percentage", fmt.Sprintf("%d%%", latest.Percentage),
-		"transferred", formatBytes(latest.Transferred),
-		"total", formatBytes(latest.Total),
-	)
+	return jobprogress.FindLast(job.Name, string(data))
 }
 
 func printJobStatus(job *batchv1.Job, logger *slog.Logger) {
-	var status string
-
-	switch {
-	case job.Status.Succeeded > 0:
-		status = "Succeeded"
-	case job.Status.Failed > 0:
-		status = "Failed"
-	case job.Status.Active > 0:
-		status = "Running"
-	default:
-		status = "Pending"
-	}
-
+	status := jobState(job)
 	elapsed := jobElapsed(job)
 
 	// The level carries the state: a failed operation announced at a green INFO
 	// reads as fine on a skim.
 	logFn := logger.Info
 
-	switch {
-	case job.Status.Failed > 0:
+	switch status {
+	case jobStateFailed:
 		logFn = logger.Error
-	case job.Status.Succeeded == 0 && job.Status.Active == 0:
+	case jobStatePending:
 		logFn = logger.Warn
 	}
 
@@ -219,6 +377,29 @@ func printJobStatus(job *batchv1.Job, logger *slog.Logger) {
 	)
 }
 
+// The states of an operation, as its data-mover job has them.
+const (
+	jobStateSucceeded = "Succeeded"
+	jobStateFailed    = "Failed"
+	jobStateRunning   = "Running"
+	jobStatePending   = "Pending"
+)
+
+func jobState(job *batchv1.Job) string {
+	switch {
+	case job.Status.Succeeded > 0:
+		return jobStateSucceeded
+	// A pod that failed is retried while the backoff limit allows, so a job can
+	// count failures and still be running.
+	case job.Status.Active > 0:
+		return jobStateRunning
+	case job.Status.Failed > 0:
+		return jobStateFailed
+	default:
+		return jobStatePending
+	}
+}
+
 // jobElapsed reports how long the job ran. A failed job has no completion
 // time, and "now minus start" on one that failed days ago reads as a days-long
 // run, so the failure condition's transition time is the end when it is there.
@@ -227,24 +408,30 @@ func jobElapsed(job *batchv1.Job) string {
 		return ""
 	}
 
-	end := time.Now()
+	end, ok := jobEnd(job)
+	if !ok {
+		end = time.Now()
+	}
 
+	return end.Sub(job.Status.StartTime.Time).Truncate(time.Second).String()
+}
+
+// jobEnd reports when the job finished, if it has.
+func jobEnd(job *batchv1.Job) (time.Time, bool) {
 	switch {
 	case job.Status.CompletionTime != nil:
-		end = job.Status.CompletionTime.Time
+		return job.Status.CompletionTime.Time, true
 	case job.Status.Failed > 0:
 		for i := range job.Status.Conditions {
 			cond := &job.Status.Conditions[i]
 			if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue &&
 				!cond.LastTransitionTime.IsZero() {
-				end = cond.LastTransitionTime.Time
-
-				break
+				return cond.LastTransitionTime.Time, true
 			}
 		}
 	}
 
-	return end.Sub(job.Status.StartTime.Time).Truncate(time.Second).String()
+	return time.Time{}, false
 }
 
 func formatBytes(bytes int64) string {
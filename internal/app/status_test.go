@@ -0,0 +1,89 @@
+package app_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+)
+
+func TestFindOperationJob_AcrossClusters(t *testing.T) {
+	t.Parallel()
+
+	rsyncJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Name:      "pv-migrate-brave-otter-loadbalancer-dest-rsync",
+		Namespace: "apps",
+		Labels:    map[string]string{"app.kubernetes.io/managed-by": "Helm"},
+	}}
+
+	clients := map[string]kubernetes.Interface{
+		"source": fake.NewClientset(),
+		"dest":   fake.NewClientset(rsyncJob),
+	}
+
+	job, found, err := app.FindOperationJob(t.Context(), clients, []string{"source", "dest"}, "apps",
+		"pv-migrate-brave-otter-")
+	require.NoError(t, err)
+	assert.Equal(t, "dest", found)
+	assert.Equal(t, rsyncJob.Name, job.Name)
+
+	_, _, err = app.FindOperationJob(t.Context(), clients, []string{"source", "dest"}, "apps",
+		"pv-migrate-other-")
+	require.ErrorIs(t, err, k8s.ErrJobNotFound)
+	assert.ErrorContains(t, err, "in any of the contexts")
+
+	_, _, err = app.FindOperationJob(t.Context(), clients, []string{"source"}, "apps", "pv-migrate-brave-otter-")
+	require.ErrorIs(t, err, k8s.ErrJobNotFound)
+	assert.ErrorContains(t, err, "context source: no job found for migration pv-migrate-brave-otter-")
+}
+
+func TestFollowOperations_Summary(t *testing.T) {
+	t.Parallel()
+
+	helmLabels := func(release string) map[string]string {
+		return map[string]string{"app.kubernetes.io/managed-by": "Helm", "app.kubernetes.io/instance": release}
+	}
+
+	jobAndPod := func(release, mover string, active int32, phase corev1.PodPhase) (*batchv1.Job, *corev1.Pod) {
+		name := release + "-" + mover
+
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps", Labels: helmLabels(release)},
+			Status:     batchv1.JobStatus{Active: active},
+		}, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name + "-x1", Namespace: "apps", Labels: map[string]string{"job-name": name},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	okJob, okPod := jobAndPod("pv-migrate-brave-otter-mount", "rsync", 1, corev1.PodSucceeded)
+	badJob, badPod := jobAndPod("pv-migrate-db1-backup", "rclone", 1, corev1.PodFailed)
+	cli := fake.NewClientset(okJob, okPod, badJob, badPod)
+
+	var out bytes.Buffer
+
+	err := app.FollowOperations(t.Context(), cli, []string{"brave-otter", "db1"}, &out)
+	require.ErrorContains(t, err, "1 of 2 operations failed")
+	assert.Regexp(t, `^ID\s+STATE\s+ELAPSED\s+ERROR\n`+
+		`brave-otter\s+Succeeded\s+-\s+-\n`+
+		`db1\s+Failed\s+-\s+pod apps/pv-migrate-db1-backup-rclone-x1 failed`, out.String())
+
+	// --all finds the jobs that have not finished, which both still are here.
+	out.Reset()
+
+	err = app.FollowOperations(t.Context(), cli, nil, &out)
+	require.Error(t, err)
+	assert.Contains(t, out.String(), "brave-otter")
+	assert.Contains(t, out.String(), "db1")
+}
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"helm.sh/helm/v4/pkg/release"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+)
+
+// The version and kind every status document states. The version changes only
+// when a field is removed or changes meaning; fields may be added within one.
+const (
+	statusDocumentAPIVersion = "pv-migrate/v1"
+	statusDocumentKind       = "OperationStatus"
+)
+
+// statusDocument is what status --output prints for an operation, for scripts
+// to read instead of log records.
+type statusDocument struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	ID         string `json:"id"`
+	Context    string `json:"context,omitempty"`
+	Namespace  string `json:"namespace"`
+	Job        string `json:"job"`
+	State      string `json:"state"`
+
+	StartTime *time.Time `json:"startTime,omitempty"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	Elapsed   string     `json:"elapsed,omitempty"`
+
+	Progress *statusProgress `json:"progress,omitempty"`
+
+	// Failure explains why the job failed, as status prints it in text.
+	Failure string `json:"failure,omitempty"`
+
+	Releases []statusRelease `json:"releases"`
+}
+
+// statusProgress is the progress the data mover last printed.
+type statusProgress struct {
+	Percentage       int   `json:"percentage"`
+	TransferredBytes int64 `json:"transferredBytes"`
+	TotalBytes       int64 `json:"totalBytes"`
+}
+
+type statusRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Context   string `json:"context,omitempty"`
+	Status    string `json:"status"`
+}
+
+// writeStatusDocuments prints the documents status gathered. Asked for several
+// operations, JSON gets an array of them and YAML a stream of documents, so each
+// parses as a whole however many were found; a single operation gets its
+// document alone. Operations that could not be found leave no document, and no
+// separator either.
+func writeStatusDocuments(out io.Writer, documents []statusDocument, output string, several bool) error {
+	if output == outputJSON {
+		if several {
+			return writeStructured(out, documents, output)
+		}
+
+		if len(documents) == 0 {
+			return nil
+		}
+
+		return writeStructured(out, documents[0], output)
+	}
+
+	for i, document := range documents {
+		if i > 0 {
+			if _, err := fmt.Fprintln(out, "---"); err != nil {
+				return fmt.Errorf("failed to write the output: %w", err)
+			}
+		}
+
+		if err := writeStructured(out, document, output); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildStatusDocument describes an operation by its data-mover job, along with
+// the releases of the operation in every cluster.
+func buildStatusDocument(
+	ctx context.Context, clusters []cluster, jobCluster *cluster, namespace, operationID string, job *batchv1.Job,
+	logger *slog.Logger,
+) statusDocument {
+	document := newStatusDocument(ctx, jobCluster.client.KubeClient, jobCluster.context, operationID, job)
+
+	for i := range clusters {
+		releases, err := listReleases(clusters[i].client, namespace, opid.ReleasePrefix+operationID+"-")
+		if err != nil {
+			logger.Warn("🔶 Failed to list the releases of the operation", "context", clusters[i].context,
+				"error", err)
+
+			continue
+		}
+
+		document.Releases = append(document.Releases, statusReleases(clusters[i].context, releases)...)
+	}
+
+	return document
+}
+
+// newStatusDocument describes an operation by its data-mover job alone.
+func newStatusDocument(
+	ctx context.Context, cli kubernetes.Interface, kubeContext, operationID string, job *batchv1.Job,
+) statusDocument {
+	document := statusDocument{
+		APIVersion: statusDocumentAPIVersion,
+		Kind:       statusDocumentKind,
+		ID:         operationID,
+		Context:    kubeContext,
+		Namespace:  job.Namespace,
+		Job:        job.Name,
+		State:      jobState(job),
+		Elapsed:    jobElapsed(job),
+		Releases:   []statusRelease{},
+	}
+
+	if job.Status.StartTime != nil {
+		start := job.Status.StartTime.UTC()
+		document.StartTime = &start
+	}
+
+	if end, ok := jobEnd(job); ok {
+		end = end.UTC()
+		document.EndTime = &end
+	}
+
+	if latest, ok := findJobProgress(ctx, cli, job); ok && (latest.Percentage > 0 || latest.Transferred > 0) {
+		document.Progress = &statusProgress{
+			Percentage:       latest.Percentage,
+			TransferredBytes: latest.Transferred,
+			TotalBytes:       latest.Total,
+		}
+	}
+
+	if jobState(job) == jobStateFailed {
+		document.Failure = k8s.DescribeJobFailure(ctx, cli, job)
+	}
+
+	return document
+}
+
+func statusReleases(kubeContext string, releases []release.Releaser) []statusRelease {
+	result := make([]statusRelease, 0, len(releases))
+
+	for _, rel := range releases {
+		acc, err := release.NewAccessor(rel)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, statusRelease{
+			Name:      acc.Name(),
+			Namespace: acc.Namespace(),
+			Context:   kubeContext,
+			Status:    acc.Status(),
+		})
+	}
+
+	return result
+}
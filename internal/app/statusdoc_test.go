@@ -0,0 +1,148 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v4/pkg/release"
+	"helm.sh/helm/v4/pkg/release/common"
+	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/utkuozdemir/pv-migrate/internal/app"
+)
+
+func TestStatusDocument(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 4, 11, 10, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-migrate-db1-backup-rclone", Namespace: "db"},
+		Status: batchv1.JobStatus{
+			Failed:    1,
+			StartTime: &metav1.Time{Time: start},
+			Conditions: []batchv1.JobCondition{{
+				Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Time{Time: end},
+			}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: job.Name + "-x1", Namespace: "db", Labels: map[string]string{"job-name": job.Name},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+	releases := []release.Releaser{&releasev1.Release{
+		Name:      "pv-migrate-db1-backup",
+		Namespace: "db",
+		Info:      &releasev1.Info{Status: common.StatusDeployed, LastDeployed: start},
+	}}
+
+	document := app.StatusDocument(t.Context(), fake.NewClientset(job, pod), "prod", "db1", job, releases)
+
+	var out bytes.Buffer
+
+	require.NoError(t, app.WriteStructured(&out, document, "json"))
+
+	var decoded map[string]any
+
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "pv-migrate/v1", decoded["apiVersion"])
+	assert.Equal(t, "OperationStatus", decoded["kind"])
+	assert.Equal(t, "db1", decoded["id"])
+	assert.Equal(t, "prod", decoded["context"])
+	assert.Equal(t, "pv-migrate-db1-backup-rclone", decoded["job"])
+	assert.Equal(t, "Failed", decoded["state"])
+	assert.Equal(t, "2026-04-11T10:00:00Z", decoded["startTime"])
+	assert.Equal(t, "2026-04-11T10:01:30Z", decoded["endTime"])
+	assert.Equal(t, "1m30s", decoded["elapsed"])
+	assert.Contains(t, decoded["failure"], "pod db/pv-migrate-db1-backup-rclone-x1 failed")
+	assert.NotContains(t, decoded, "progress", "the fake log has no progress line")
+	assert.Equal(t, []any{map[string]any{
+		"name": "pv-migrate-db1-backup", "namespace": "db", "context": "prod", "status": "deployed",
+	}}, decoded["releases"])
+
+	out.Reset()
+	require.NoError(t, app.WriteStructured(&out, document, "yaml"))
+	assert.Contains(t, out.String(), "apiVersion: pv-migrate/v1\n")
+	assert.Contains(t, out.String(), "state: Failed\n")
+}
+
+func TestStatusDocumentOfRetryingJob(t *testing.T) {
+	t.Parallel()
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-migrate-db1-backup-rclone", Namespace: "db"},
+		Status:     batchv1.JobStatus{Failed: 1, Active: 1},
+	}
+	failedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: job.Name + "-x1", Namespace: "db", Labels: map[string]string{"job-name": job.Name},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+
+	document := app.StatusDocument(t.Context(), fake.NewClientset(job, failedPod), "", "db1", job, nil)
+
+	var out bytes.Buffer
+
+	require.NoError(t, app.WriteStructured(&out, document, "json"))
+
+	var decoded map[string]any
+
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "Running", decoded["state"])
+	assert.NotContains(t, decoded, "failure", "a job still retrying has not failed")
+}
+
+// TestWriteStatusDocuments checks that several operations come out as a whole a
+// parser reads in one go, and that only the documents written are separated.
+func TestWriteStatusDocuments(t *testing.T) {
+	t.Parallel()
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "pv-migrate-a-backup-rclone", Namespace: "db"}}
+	first := app.StatusDocument(t.Context(), fake.NewClientset(job), "", "a", job, nil)
+	second := app.StatusDocument(t.Context(), fake.NewClientset(job), "", "b", job, nil)
+
+	var out bytes.Buffer
+
+	require.NoError(t, app.WriteStatusDocuments(&out, []any{first, second}, "json", true))
+
+	var decoded []map[string]any
+
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded), "several operations must be one JSON value")
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "b", decoded[1]["id"])
+
+	out.Reset()
+	require.NoError(t, app.WriteStatusDocuments(&out, []any{first}, "json", true))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded), "several IDs get an array even if one was found")
+	require.Len(t, decoded, 1)
+
+	out.Reset()
+	require.NoError(t, app.WriteStatusDocuments(&out, []any{first}, "json", false))
+
+	var single map[string]any
+
+	require.NoError(t, json.Unmarshal(out.Bytes(), &single), "a single operation gets its document alone")
+	assert.Equal(t, "a", single["id"])
+
+	out.Reset()
+	require.NoError(t, app.WriteStatusDocuments(&out, []any{first, second}, "yaml", true))
+	assert.Equal(t, 1, strings.Count(out.String(), "---\n"))
+	assert.False(t, strings.HasPrefix(out.String(), "---"))
+
+	out.Reset()
+	require.NoError(t, app.WriteStatusDocuments(&out, []any{second}, "yaml", true))
+	assert.NotContains(t, out.String(), "---", "an operation that was not found leaves no separator")
+}
@@ -0,0 +1,86 @@
+package bucketstorage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+	"github.com/utkuozdemir/pv-migrate/internal/shell"
+)
+
+// validateArchive checks that an archive is written where a restore can tell it
+// is one: the metadata records the format, and a raw remote has no metadata.
+func validateArchive(req *Request) error {
+	if !req.Archive {
+		return nil
+	}
+
+	if req.Direction != rclone.DirectionBackup {
+		return errors.New("--archive only applies to backups, a restore reads the format off the metadata")
+	}
+
+	if isRawRemote(req) {
+		return errors.New("--archive needs the managed layout, whose metadata records the format, " +
+			"so it cannot be used with --remote")
+	}
+
+	return nil
+}
+
+// detectsArchive reports whether a restore extracts the archive of a backup that
+// turns out to be one. A partial restore cannot, and is stopped before it starts
+// by the check of buildFormatCheck instead.
+func detectsArchive(req *Request) bool {
+	return req.Direction == rclone.DirectionRestore && !isRawRemote(req) &&
+		req.SourcePath == "" && req.Filter.IsEmpty()
+}
+
+// buildFormatCheck reads the format of the backup off its metadata, for the
+// restore to pick its pipeline by, and stops the job before it changes anything
+// when the backup is an archive that the options of req cannot be applied to.
+// It is empty for a backup, and for a raw remote, which has no metadata. For a
+// set, it also stops the job when one of pvcNames is not among the PVCs the
+// backup holds, before anything is restored to any of them.
+func buildFormatCheck(req *Request, pvcNames []string) (string, error) {
+	if req.Direction == rclone.DirectionBackup || isRawRemote(req) {
+		return "", nil
+	}
+
+	detect := rclone.DetectFormatCmd{
+		MetadataPath: rclone.BuildMetadataRemotePath(managedRemoteName(req), req.Bucket, req.Prefix, req.Name),
+		ConfigPath:   "/etc/rclone/rclone.conf",
+		ExtraArgs:    req.RcloneExtraArgs,
+	}
+
+	if isPVCSet(req) {
+		detect.PVCs = pvcNames
+	}
+
+	cmdStr, err := detect.Build()
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	var unsupported string
+
+	switch {
+	case verifies(req):
+		unsupported = "cannot be verified with rclone check, check the volume against its manifest instead"
+	case req.SourcePath != "" || !req.Filter.IsEmpty():
+		unsupported = "can only be restored as a whole, without --source-path or filters"
+	default:
+		return cmdStr, nil
+	}
+
+	return fmt.Sprintf("%s && if %s; then echo %s >&2; exit 2; fi",
+		cmdStr, rclone.IsArchiveCondition(), shell.Quote("the backup is an archive, which "+unsupported)), nil
+}
+
+// prependCommand runs first before then, unless either is empty.
+func prependCommand(first, then string) string {
+	if first == "" || then == "" {
+		return then
+	}
+
+	return first + " && " + then
+}
@@ -0,0 +1,103 @@
+package bucketstorage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestValidateArchive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     bucketstorage.Request
+		wantErr string
+	}{
+		{name: "tree", req: bucketstorage.Request{Direction: rclone.DirectionRestore}},
+		{name: "backup", req: bucketstorage.Request{Direction: rclone.DirectionBackup, Archive: true}},
+		{
+			name:    "restore",
+			req:     bucketstorage.Request{Direction: rclone.DirectionRestore, Archive: true},
+			wantErr: "only applies to backups",
+		},
+		{
+			name: "raw remote",
+			req: bucketstorage.Request{
+				Direction: rclone.DirectionBackup, Archive: true, RcloneConfigFile: "rclone.conf",
+			},
+			wantErr: "cannot be used with --remote",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := bucketstorage.ValidateArchive(&tt.req)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestBuildFormatCheck(t *testing.T) {
+	t.Parallel()
+
+	restore := func(modify func(req *bucketstorage.Request)) *bucketstorage.Request {
+		req := &bucketstorage.Request{Direction: rclone.DirectionRestore, Bucket: "bucket", Prefix: "pv-migrate", Name: "pg"}
+		modify(req)
+
+		return req
+	}
+
+	check, err := bucketstorage.BuildFormatCheck(restore(func(*bucketstorage.Request) {}), []string{"data"})
+	require.NoError(t, err)
+	assert.Contains(t, check, "rclone copyto --config '/etc/rclone/rclone.conf' "+
+		"'remote:bucket/pv-migrate/pg.meta.yaml' /tmp/pv-migrate-format.yaml")
+	assert.NotContains(t, check, "exit 2")
+
+	check, err = bucketstorage.BuildFormatCheck(restore(func(req *bucketstorage.Request) { req.Verify = true }), nil)
+	require.NoError(t, err)
+	assert.Contains(t, check, `&& if [ "$format" = 'tar.zst' ]; then echo `)
+	assert.Contains(t, check, "cannot be verified with rclone check")
+
+	check, err = bucketstorage.BuildFormatCheck(restore(func(req *bucketstorage.Request) { req.SourcePath = "uploads" }),
+		nil)
+	require.NoError(t, err)
+	assert.Contains(t, check, "can only be restored as a whole")
+
+	check, err = bucketstorage.BuildFormatCheck(restore(func(req *bucketstorage.Request) {
+		req.Filter = rclone.Filter{Include: []string{"*.yaml"}}
+	}), nil)
+	require.NoError(t, err)
+	assert.Contains(t, check, "can only be restored as a whole")
+
+	for _, req := range []*bucketstorage.Request{
+		{Direction: rclone.DirectionBackup, Bucket: "bucket", Name: "pg"},
+		restore(func(req *bucketstorage.Request) { req.RcloneConfigFile = "rclone.conf" }),
+	} {
+		check, err = bucketstorage.BuildFormatCheck(req, nil)
+		require.NoError(t, err)
+		assert.Empty(t, check)
+	}
+
+	check, err = bucketstorage.BuildFormatCheck(restore(func(*bucketstorage.Request) {}), []string{"data"})
+	require.NoError(t, err)
+	assert.NotContains(t, check, "sourcePvcs", "a single PVC is not checked against a set")
+
+	check, err = bucketstorage.BuildFormatCheck(restore(func(req *bucketstorage.Request) {
+		req.PVCNames = []string{"pg-data", "pg-wal"}
+	}), []string{"pg-data", "pg-wal"})
+	require.NoError(t, err)
+	assert.Contains(t, check, "for pvc in 'pg-data' 'pg-wal'; do ")
+}
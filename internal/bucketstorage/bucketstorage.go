@@ -22,10 +22,13 @@ import (
 	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/getter"
 	"helm.sh/helm/v4/pkg/kube"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 
 	"github.com/utkuozdemir/pv-migrate/internal/console"
 	"github.com/utkuozdemir/pv-migrate/internal/helm"
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
 	"github.com/utkuozdemir/pv-migrate/internal/k8s"
 	"github.com/utkuozdemir/pv-migrate/internal/opid"
 	"github.com/utkuozdemir/pv-migrate/internal/pvc"
@@ -46,14 +49,17 @@ var helmProviders = getter.All(cli.New())
 
 // Request holds all parameters for a backup or restore operation.
 type Request struct {
-	ID                    string
-	ImageTag              string
-	ChartVersion          string
-	Direction             string // rclone.DirectionBackup or rclone.DirectionRestore
-	KubeconfigPath        string
-	Context               string
-	Namespace             string
-	PVCName               string
+	ID             string
+	ImageTag       string
+	ChartVersion   string
+	Direction      string // rclone.DirectionBackup, rclone.DirectionRestore or rclone.DirectionVerify
+	KubeconfigPath string
+	Context        string
+	Namespace      string
+	PVCName        string
+	// PVCNames and PVCSelector name a set of PVCs in Namespace instead of PVCName.
+	PVCNames              []string
+	PVCSelector           string
 	IgnoreMounted         bool
 	NonRoot               bool
 	Detach                bool
@@ -61,6 +67,20 @@ type Request struct {
 	NoCleanupOnFailure    bool
 	DeleteExtraneousFiles bool
 
+	// Archive stores a backup as one tarball per PVC, compressed with zstd, and
+	// records the format in its metadata, where a restore finds it.
+	Archive bool
+
+	// NoManifest leaves out the integrity manifest of a backup, a list of the
+	// SHA-256 of every file uploaded next to its metadata, which takes reading
+	// all of the data a second time.
+	NoManifest bool
+
+	// Verify checks the volume against the backup after a restore, by size or,
+	// with VerifyMode set to rclone.CheckModeChecksum, by size and hash.
+	Verify     bool
+	VerifyMode string
+
 	// Bucket storage config
 	Backend               string
 	Bucket                string
@@ -73,12 +93,67 @@ type Request struct {
 	StorageKey            string
 	GCSServiceAccountJSON string
 	GCSBucketPolicyOnly   *bool
+	SFTPHost              string
+	SFTPPort              int
+	SFTPUser              string
+	SFTPPassword          string
+	SFTPKeyPEM            string
+	WebDAVURL             string
+	WebDAVVendor          string
+	WebDAVUser            string
+	WebDAVPassword        string
+	WebDAVBearerToken     string
+	SwiftAuthURL          string
+	SwiftUser             string
+	SwiftKey              string
+	SwiftTenant           string
+	SwiftDomain           string
+	B2Account             string
+	B2Key                 string
 	Name                  string
 	Prefix                string
 	Path                  string
 	RcloneConfigFile      string
+	RcloneConfigRemote    string
 	Remote                string
 	RcloneExtraArgs       string
+	BWLimit               string
+
+	// SourcePath is a directory inside the backup to restore from instead of all of
+	// it, and Filter limits a restore or a verification to some of its files.
+	SourcePath string
+	Filter     rclone.Filter
+
+	// CredentialsSecret names a secret in the PVC's namespace whose keys are
+	// passed to rclone as RCLONE_CONFIG_<REMOTE>_* environment variables.
+	CredentialsSecret string
+
+	// CredentialsKubeconfigPath, CredentialsContext and CredentialsNamespace read
+	// CredentialsSecret from another cluster than the PVC's. Its contents are then
+	// copied into a secret of the release, since a pod cannot reference it there.
+	CredentialsKubeconfigPath string
+	CredentialsContext        string
+	CredentialsNamespace      string
+
+	// FromLatest restores the newest of the backups named Name or Name-<version>,
+	// by the backup time their metadata records, instead of Name itself.
+	FromLatest bool
+
+	// Workload identity for the rclone pod
+	ServiceAccountName        string
+	ServiceAccountAnnotations map[string]string
+	AWSRoleARN                string
+	AzureClientID             string
+	AzureTenantID             string
+	GCPServiceAccount         string
+
+	// EnvAuth allows a generated config without credentials or a workload identity,
+	// leaving rclone to find credentials in the pod's environment.
+	EnvAuth bool
+
+	// Hooks run in the pods of the application in Namespace, before the rclone
+	// job is installed and after it finishes.
+	Hooks hook.Config
 
 	HelmTimeout      time.Duration
 	HelmValuesFiles  []string
@@ -97,9 +172,21 @@ type Request struct {
 	// ColorOutput colors the plain-text report blocks semantically. Set only
 	// when the writer is a terminal and the logs are not machine-readable.
 	ColorOutput bool
+
+	// remoteName names the generated remote when a config holds more than one.
+	remoteName string
+
+	// credentials holds the contents of CredentialsSecret once it is read from
+	// another cluster.
+	credentials map[string]string
+
+	// hooks runs Hooks once the cluster client is known, and keeps their output.
+	hooks *hook.Runner
+	// hookExec runs the hooks in the pods, k8s.Exec when nil.
+	hookExec hook.ExecFunc
 }
 
-// Run executes a backup or restore operation.
+// Run executes a backup, restore or verify operation.
 //
 //nolint:cyclop,funlen
 func Run(ctx context.Context, req *Request) error {
@@ -128,14 +215,48 @@ func Run(ctx context.Context, req *Request) error {
 		return err
 	}
 
-	localPath := dataMountPath
+	if err = validateCredentialsSecret(req); err != nil {
+		return err
+	}
+
+	if err = validateWorkloadIdentity(req); err != nil {
+		return err
+	}
+
+	warnAmbientAuth(req, logger)
+
+	if err = validatePVCSet(req); err != nil {
+		return err
+	}
 
 	if req.Path != "" {
 		if err = validateSubpath(req.Path); err != nil {
 			return fmt.Errorf("invalid --path: %w", err)
 		}
+	}
+
+	if err = rclone.ValidateBWLimit(req.BWLimit); err != nil {
+		return err
+	}
+
+	if err = validateRestoreSelection(req); err != nil {
+		return err
+	}
 
-		localPath = path.Join(dataMountPath, req.Path)
+	if err = validateVerify(req); err != nil {
+		return err
+	}
+
+	if err = validateHooks(req); err != nil {
+		return err
+	}
+
+	if err = validateFromLatest(req); err != nil {
+		return err
+	}
+
+	if err = validateArchive(req); err != nil {
+		return err
 	}
 
 	client, err := k8s.GetClusterClient(req.KubeconfigPath, req.Context, logger)
@@ -148,27 +269,76 @@ func Run(ctx context.Context, req *Request) error {
 		ns = client.NsInContext
 	}
 
-	pvcInfo, err := pvc.New(ctx, client, ns, req.PVCName)
+	pvcNames, err := resolvePVCNames(ctx, client.KubeClient, ns, req)
 	if err != nil {
-		return fmt.Errorf("failed to get PVC info: %w", err)
+		return err
+	}
+
+	pvcInfos := make([]*pvc.Info, 0, len(pvcNames))
+
+	for _, pvcName := range pvcNames {
+		pvcInfo, infoErr := pvc.New(ctx, client, ns, pvcName)
+		if infoErr != nil {
+			return fmt.Errorf("failed to get PVC info: %w", infoErr)
+		}
+
+		if err = handleMounted(pvcInfo, req.IgnoreMounted, logger); err != nil {
+			return err
+		}
+
+		pvcInfos = append(pvcInfos, pvcInfo)
 	}
 
-	if err = handleMounted(pvcInfo, req.IgnoreMounted, logger); err != nil {
+	if err = checkMountedNodes(pvcInfos); err != nil {
 		return err
 	}
 
-	rcloneCmd := rclone.Cmd{
-		Direction:  req.Direction,
-		RemotePath: remotePath,
-		LocalPath:  localPath,
-		ConfigPath: "/etc/rclone/rclone.conf",
-		ExtraArgs:  req.RcloneExtraArgs,
-		Delete:     req.DeleteExtraneousFiles,
+	switch {
+	case credentialsFromOtherCluster(req):
+		if err = readCredentials(ctx, req, logger); err != nil {
+			return err
+		}
+	case req.CredentialsSecret != "":
+		if err = checkCredentialsSecret(ctx, client, ns, req.CredentialsSecret); err != nil {
+			return err
+		}
+	}
+
+	if req.FromLatest {
+		if err = resolveLatest(ctx, req, client, ns, operationID, rcloneConf, logger); err != nil {
+			return err
+		}
+
+		if remotePath, err = buildRemotePath(req); err != nil {
+			return err
+		}
+	}
+
+	// A verify job only checks, so the transfer is left out.
+	var cmdStr string
+
+	if req.Direction != rclone.DirectionVerify {
+		if cmdStr, err = buildRcloneCommand(req, remotePath, pvcNames); err != nil {
+			return fmt.Errorf("failed to build rclone command: %w", err)
+		}
+	}
+
+	verifyCmd, err := buildVerifyCommand(req, remotePath, pvcNames)
+	if err != nil {
+		return fmt.Errorf("failed to build rclone check command: %w", err)
 	}
 
-	cmdStr, err := rcloneCmd.Build()
+	// The check goes first in the job: before the restore, or before the
+	// verification when that is the whole job.
+	formatCheck, err := buildFormatCheck(req, pvcNames)
 	if err != nil {
-		return fmt.Errorf("failed to build rclone command: %w", err)
+		return fmt.Errorf("failed to build the format check: %w", err)
+	}
+
+	if cmdStr != "" {
+		cmdStr = prependCommand(formatCheck, cmdStr)
+	} else {
+		verifyCmd = prependCommand(formatCheck, verifyCmd)
 	}
 
 	helmChart, err := helm.LoadChart(req.ChartVersion)
@@ -176,27 +346,42 @@ func Run(ctx context.Context, req *Request) error {
 		return fmt.Errorf("failed to load helm chart: %w", err)
 	}
 
-	readOnly := req.Direction == rclone.DirectionBackup
+	readOnly := req.Direction == rclone.DirectionBackup || req.Direction == rclone.DirectionVerify
 
-	var metadataBase64, metadataRemotePath string
+	var metadataBase64, metadataRemotePath, manifestCmd string
 
 	if shouldUploadMetadata(req) {
-		metadataBase64, err = generateMetadataBase64(ns, req.PVCName)
+		metadataBase64, err = generateMetadataBase64(ns, req, pvcNames)
 		if err != nil {
 			return fmt.Errorf("failed to generate backup metadata: %w", err)
 		}
 
-		metadataRemotePath = rclone.BuildMetadataRemotePath(req.Bucket, req.Prefix, req.Name)
+		metadataRemotePath = rclone.BuildMetadataRemotePath(managedRemoteName(req), req.Bucket, req.Prefix, req.Name)
+
+		if !req.NoManifest {
+			if manifestCmd, err = buildManifestCommand(req, pvcNames); err != nil {
+				return fmt.Errorf("failed to build the integrity manifest command: %w", err)
+			}
+		}
 	}
 
-	helmVals := buildHelmValues(ns, req, pvcInfo, rcloneConf, cmdStr, readOnly, metadataBase64, metadataRemotePath)
+	helmVals := buildHelmValues(ns, req, pvcInfos, rcloneConf, cmdStr, readOnly, metadataBase64, metadataRemotePath)
+	applyVerifyValues(helmVals, verifyCmd)
+	applyManifestValues(helmVals, manifestCmd)
 
 	releaseName := opid.ReleasePrefix + operationID + "-" + req.Direction
 
 	logger = logger.With("release", releaseName)
+
+	if err = runPreHook(ctx, req, client, ns, logger); err != nil {
+		return err
+	}
+
 	logger.Info("📦 Installing Helm chart")
 
-	if err = installHelmChart(helmChart, pvcInfo, releaseName, helmVals, req, logger); err != nil {
+	if err = installHelmChart(helmChart, client, ns, releaseName, helmVals, req, logger); err != nil {
+		err = runPostHook(ctx, req, err)
+
 		// A timed-out install means resources that are stuck rather than absent,
 		// and this path runs no cleanup, so they are still there to be read.
 		writeFailure(ctx, req, client.KubeClient, ns, releaseName, err, logger)
@@ -206,7 +391,7 @@ func Run(ctx context.Context, req *Request) error {
 
 	jobName := releaseName + "-rclone"
 
-	return handleJobCompletion(ctx, req, pvcInfo, releaseName, jobName, operationID, logger)
+	return handleJobCompletion(ctx, req, client, ns, releaseName, jobName, operationID, logger)
 }
 
 func buildRcloneConfig(req *Request) (string, error) {
@@ -216,10 +401,26 @@ func buildRcloneConfig(req *Request) (string, error) {
 			return "", fmt.Errorf("failed to read rclone config file: %w", err)
 		}
 
+		// Checked here rather than left to rclone, which would only fail once the
+		// job is running, and would then name a remote the user never mentioned.
+		if req.RcloneConfigRemote != "" && !rclone.ConfigHasRemote(conf, req.RcloneConfigRemote) {
+			return "", fmt.Errorf("--rclone-config-remote %q is not defined in %s",
+				req.RcloneConfigRemote, req.RcloneConfigFile)
+		}
+
 		return conf, nil
 	}
 
-	opts := rclone.ConfigOptions{
+	conf, err := rclone.GenerateConfig(configOptions(req))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate rclone config: %w", err)
+	}
+
+	return conf, nil
+}
+
+func configOptions(req *Request) rclone.ConfigOptions {
+	return rclone.ConfigOptions{
 		Backend:               req.Backend,
 		Provider:              req.S3Provider,
 		Endpoint:              req.Endpoint,
@@ -230,20 +431,46 @@ func buildRcloneConfig(req *Request) (string, error) {
 		StorageKey:            req.StorageKey,
 		GCSServiceAccountJSON: req.GCSServiceAccountJSON,
 		GCSBucketPolicyOnly:   req.GCSBucketPolicyOnly,
+		SFTPHost:              req.SFTPHost,
+		SFTPPort:              req.SFTPPort,
+		SFTPUser:              req.SFTPUser,
+		SFTPPassword:          req.SFTPPassword,
+		SFTPKeyPEM:            req.SFTPKeyPEM,
+		WebDAVURL:             req.WebDAVURL,
+		WebDAVVendor:          req.WebDAVVendor,
+		WebDAVUser:            req.WebDAVUser,
+		WebDAVPassword:        req.WebDAVPassword,
+		WebDAVBearerToken:     req.WebDAVBearerToken,
+		SwiftAuthURL:          req.SwiftAuthURL,
+		SwiftUser:             req.SwiftUser,
+		SwiftKey:              req.SwiftKey,
+		SwiftTenant:           req.SwiftTenant,
+		SwiftDomain:           req.SwiftDomain,
+		B2Account:             req.B2Account,
+		B2Key:                 req.B2Key,
+		ExternalCredentials:   req.CredentialsSecret != "",
+		RemoteName:            req.remoteName,
 	}
-
-	conf, err := rclone.GenerateConfig(opts)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate rclone config: %w", err)
-	}
-
-	return conf, nil
 }
 
 func buildRemotePath(req *Request) (string, error) {
-	if req.RcloneConfigFile != "" {
+	if req.RcloneConfigRemote != "" {
+		if req.RcloneConfigFile == "" {
+			return "", errors.New("--rclone-config-remote requires --rclone-config")
+		}
+
+		if req.Remote != "" {
+			return "", errors.New("--remote and --rclone-config-remote cannot be used together")
+		}
+
+		if err := rclone.ValidateRemoteName(req.RcloneConfigRemote); err != nil {
+			return "", fmt.Errorf("invalid --rclone-config-remote: %w", err)
+		}
+	}
+
+	if isRawRemote(req) {
 		if req.Remote == "" {
-			return "", errors.New("--remote is required when using --rclone-config")
+			return "", errors.New("--remote or --rclone-config-remote is required when using --rclone-config")
 		}
 
 		return rclone.BuildRemotePathRaw(req.Remote), nil
@@ -271,12 +498,155 @@ func buildRemotePath(req *Request) (string, error) {
 		return "", err
 	}
 
-	return rclone.BuildRemotePath(req.Bucket, req.Prefix, req.Name), nil
+	return rclone.BuildRemotePath(managedRemoteName(req), req.Bucket, req.Prefix, req.Name), nil
+}
+
+// isRawRemote reports whether the remote path is the user's opaque --remote spec.
+// A raw config whose remote is named with --rclone-config-remote still gets the
+// managed <prefix>/<name> layout, so it is not raw in this sense.
+func isRawRemote(req *Request) bool {
+	return req.RcloneConfigFile != "" && req.RcloneConfigRemote == ""
+}
+
+// managedRemoteName returns the remote that managed paths are built on: the one
+// named from the user's config file, or the remote of a generated config.
+func managedRemoteName(req *Request) string {
+	if req.RcloneConfigRemote != "" {
+		return req.RcloneConfigRemote
+	}
+
+	if req.remoteName != "" {
+		return req.remoteName
+	}
+
+	return rclone.DefaultRemoteName
+}
+
+func validateCredentialsSecret(req *Request) error {
+	if credentialsFromOtherCluster(req) && req.CredentialsSecret == "" {
+		return errors.New("--credentials-kubeconfig, --credentials-context and --credentials-namespace " +
+			"need --credentials-secret")
+	}
+
+	if req.CredentialsSecret == "" {
+		return nil
+	}
+
+	if errs := validation.IsDNS1123Subdomain(req.CredentialsSecret); len(errs) > 0 {
+		return fmt.Errorf("--credentials-secret %q is not a valid secret name: %s",
+			req.CredentialsSecret, strings.Join(errs, "; "))
+	}
+
+	if _, err := credentialsRemoteName(req); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// credentialsFromOtherCluster reports whether the credentials secret is to be read
+// from a cluster of its own rather than referenced in the PVC's.
+func credentialsFromOtherCluster(req *Request) bool {
+	return req.CredentialsKubeconfigPath != "" || req.CredentialsContext != "" || req.CredentialsNamespace != ""
+}
+
+// readCredentials reads the credentials secret from the cluster it is kept in, for
+// the release to carry into the PVC's. What is not given of that cluster is taken
+// from the PVC's, so a namespace alone reads the secret from another namespace
+// there, and a kubeconfig alone uses its current context.
+func readCredentials(ctx context.Context, req *Request, logger *slog.Logger) error {
+	kubeconfigPath, kubeContext := req.CredentialsKubeconfigPath, req.CredentialsContext
+	if kubeconfigPath == "" {
+		kubeconfigPath = req.KubeconfigPath
+
+		if kubeContext == "" {
+			kubeContext = req.Context
+		}
+	}
+
+	client, err := k8s.GetClusterClient(kubeconfigPath, kubeContext, logger)
+	if err != nil {
+		return fmt.Errorf("failed to get the cluster client of the credentials secret: %w", err)
+	}
+
+	ns := req.CredentialsNamespace
+	if ns == "" {
+		ns = client.NsInContext
+	}
+
+	data, err := k8s.SecretData(ctx, client.KubeClient, ns, req.CredentialsSecret)
+	if err != nil {
+		return fmt.Errorf("invalid --credentials-secret: %w", err)
+	}
+
+	logger.Info("🔑 Read the credentials secret from its cluster", "secret", ns+"/"+req.CredentialsSecret)
+
+	req.credentials = data
+
+	return nil
+}
+
+// credentialsRemoteName returns the remote whose options the credentials secret
+// supplies. With an opaque --remote spec that is the name before the colon.
+func credentialsRemoteName(req *Request) (string, error) {
+	if !isRawRemote(req) {
+		return managedRemoteName(req), nil
+	}
+
+	name, _, found := strings.Cut(req.Remote, ":")
+	if !found || name == "" {
+		return "", fmt.Errorf("--credentials-secret needs a named remote, but --remote %q has none", req.Remote)
+	}
+
+	return name, nil
+}
+
+func checkCredentialsSecret(ctx context.Context, client *k8s.ClusterClient, ns, name string) error {
+	metadataClient, err := metadata.NewForConfig(client.RestConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata client: %w", err)
+	}
+
+	if err = k8s.CheckSecretExists(ctx, metadataClient, ns, name); err != nil {
+		return fmt.Errorf("invalid --credentials-secret: %w", err)
+	}
+
+	return nil
+}
+
+// credentialsEnvFrom maps every key of the credentials secret to the rclone
+// option of the same name on the remote in use, so a key named SECRET_ACCESS_KEY
+// reaches rclone as RCLONE_CONFIG_REMOTE_SECRET_ACCESS_KEY.
+func credentialsEnvFrom(req *Request) []map[string]any {
+	remote, err := credentialsRemoteName(req)
+	if err != nil {
+		return nil
+	}
+
+	return []map[string]any{
+		{
+			"prefix":    rclone.EnvPrefix(remote),
+			"secretRef": map[string]any{"name": req.CredentialsSecret},
+		},
+	}
+}
+
+// applyCredentialsValues has the chart create a secret with the contents of the
+// credentials secret, read from another cluster, and pass its keys to rclone as
+// credentialsEnvFrom does.
+func applyCredentialsValues(rcloneVals map[string]any, req *Request) {
+	remote, err := credentialsRemoteName(req)
+	if err != nil {
+		return
+	}
+
+	rcloneVals["credentials"] = req.credentials
+	rcloneVals["credentialsEnvPrefix"] = rclone.EnvPrefix(remote)
 }
 
 func shouldUploadMetadata(req *Request) bool {
 	return req.Direction == rclone.DirectionBackup &&
-		req.RcloneConfigFile == "" &&
+		!isRawRemote(req) &&
 		!hasRcloneDryRun(req.RcloneExtraArgs)
 }
 
@@ -391,7 +761,7 @@ func validatePrefix(prefix string) error {
 func buildHelmValues(
 	namespace string,
 	req *Request,
-	pvcInfo *pvc.Info,
+	pvcInfos []*pvc.Info,
 	rcloneConf, cmdStr string,
 	readOnly bool,
 	metadataBase64, metadataRemotePath string,
@@ -403,16 +773,19 @@ func buildHelmValues(
 		"config":      rcloneConf,
 		"command":     cmdStr,
 		"extraArgs":   "",
-		"pvcMounts": []map[string]any{
-			{
-				"name":      pvcInfo.Claim.Name,
-				"mountPath": dataMountPath,
-				"readOnly":  readOnly,
-			},
-		},
-		"affinity": pvcInfo.AffinityHelmValues,
+		"pvcMounts":   pvcMounts(req, pvcInfos, readOnly),
+		"affinity":    combinedAffinity(pvcInfos),
 	}
 
+	switch {
+	case req.credentials != nil:
+		applyCredentialsValues(rcloneVals, req)
+	case req.CredentialsSecret != "":
+		rcloneVals["envFrom"] = credentialsEnvFrom(req)
+	}
+
+	applyWorkloadIdentityValues(rcloneVals, req)
+
 	if metadataBase64 != "" {
 		rcloneVals["metadataBase64"] = metadataBase64
 		rcloneVals["metadataRemotePath"] = metadataRemotePath
@@ -446,24 +819,32 @@ func applyNonRootValues(vals map[string]any) {
 	}
 }
 
+func applyVerifyValues(vals map[string]any, verifyCmd string) {
+	rcloneSection, ok := vals["rclone"].(map[string]any)
+	if !ok || verifyCmd == "" {
+		return
+	}
+
+	rcloneSection["verifyCommand"] = verifyCmd
+}
+
 func installHelmChart(
 	helmChart *chart.Chart,
-	pvcInfo *pvc.Info,
-	releaseName string,
+	client *k8s.ClusterClient,
+	namespace, releaseName string,
 	baseValues map[string]any,
 	req *Request,
 	logger *slog.Logger,
 ) error {
 	actionConfig := new(action.Configuration)
 
-	err := actionConfig.Init(pvcInfo.ClusterClient.RESTClientGetter,
-		pvcInfo.Claim.Namespace, os.Getenv("HELM_DRIVER"))
+	err := actionConfig.Init(client.RESTClientGetter, namespace, os.Getenv("HELM_DRIVER"))
 	if err != nil {
 		return fmt.Errorf("failed to initialize helm action config: %w", err)
 	}
 
 	install := action.NewInstall(actionConfig)
-	install.Namespace = pvcInfo.Claim.Namespace
+	install.Namespace = namespace
 	install.ReleaseName = releaseName
 	install.WaitStrategy = kube.LegacyStrategy
 	install.Timeout = req.HelmTimeout
@@ -512,12 +893,11 @@ func mergeHelmValues(baseValues map[string]any, req *Request, logger *slog.Logge
 func handleJobCompletion(
 	ctx context.Context,
 	req *Request,
-	pvcInfo *pvc.Info,
-	releaseName, jobName, operationID string,
+	client *k8s.ClusterClient,
+	namespace, releaseName, jobName, operationID string,
 	logger *slog.Logger,
 ) (retErr error) {
-	kubeClient := pvcInfo.ClusterClient.KubeClient
-	namespace := pvcInfo.Claim.Namespace
+	kubeClient := client.KubeClient
 
 	defer func() {
 		if req.NoCleanup {
@@ -536,7 +916,7 @@ func handleJobCompletion(
 			return
 		}
 
-		if cleanupErr := cleanupRelease(pvcInfo, releaseName, req.HelmTimeout); cleanupErr != nil {
+		if cleanupErr := cleanupRelease(client, namespace, releaseName, req.HelmTimeout); cleanupErr != nil {
 			logger.Warn("🔶 Cleanup failed, you might want to clean up manually", "error", cleanupErr)
 		} else {
 			logger.Info("✨ Cleanup done")
@@ -553,9 +933,16 @@ func handleJobCompletion(
 		return nil
 	}
 
-	if err := k8s.WaitForJobCompletion(ctx, kubeClient, namespace, jobName,
+	err := k8s.WaitForJobCompletion(ctx, kubeClient, namespace, jobName,
 		shouldShowProgressBar(req.Writer), req.StructuredLogs,
-		console.Palette{Enabled: req.ColorOutput}, req.Writer, logger); err != nil {
+		console.Palette{Enabled: req.ColorOutput}, req.Writer, logger)
+	if verifies(req) {
+		err = checkVerification(ctx, req, kubeClient, namespace, jobName, err, logger)
+	}
+
+	err = runPostHook(ctx, req, err)
+
+	if err != nil {
 		// Before the deferred cleanup removes the resources this is about.
 		writeFailure(ctx, req, kubeClient, namespace, releaseName, err, logger)
 
@@ -582,14 +969,26 @@ func writeFailure(
 	cause error,
 	logger *slog.Logger,
 ) {
+	var verifyErr *verificationError
+
+	verificationFailed := errors.As(cause, &verifyErr)
+
 	if req.StructuredLogs {
 		var buf bytes.Buffer
 
 		k8s.WriteWorkloadDiagnostics(ctx, kubeClient, namespace,
 			k8s.InstanceLabelSelector(releaseName), console.Palette{}, &buf, logger)
 
-		logger.Error("❌ What the cluster reported", "release", releaseName,
-			"namespace", namespace, "diagnostics", buf.String())
+		args := []any{"release", releaseName, "namespace", namespace, "diagnostics", buf.String()}
+		if verificationFailed {
+			args = append(args, "differences", differencesAttr(verifyErr.report))
+		}
+
+		if hooks := req.hooks.Report(console.Palette{}); hooks != "" {
+			args = append(args, "hooks", hooks)
+		}
+
+		logger.Error("❌ What the cluster reported", args...)
 
 		return
 	}
@@ -604,11 +1003,76 @@ func writeFailure(
 		}
 	}
 
+	if verificationFailed {
+		writeDifferences(req.Writer, verifyErr.report, palette)
+	}
+
 	fmt.Fprintf(req.Writer, "\n%s\n\n  %s (namespace %s):\n",
 		palette.Bold("What the cluster reported:"), releaseName, namespace)
 	k8s.WriteWorkloadDiagnostics(ctx, kubeClient, namespace,
 		k8s.InstanceLabelSelector(releaseName), palette, req.Writer, logger)
 	fmt.Fprintln(req.Writer)
+	fmt.Fprint(req.Writer, req.hooks.Report(palette))
+}
+
+// runPreHook runs the pre hook before anything is installed. When it fails, the
+// post hook releases the pods it did quiesce before the operation stops.
+func runPreHook(ctx context.Context, req *Request, client *k8s.ClusterClient, ns string, logger *slog.Logger) error {
+	req.hooks = hook.NewRunner(req.Hooks, client, ns, logger).WithExec(req.hookExec)
+
+	err := req.hooks.Run(ctx, hook.PhasePre)
+	if err == nil {
+		return nil
+	}
+
+	err = runPostHook(ctx, req, err)
+	writeHookFailure(req, err, logger)
+
+	return err
+}
+
+// writeHookFailure explains a pre hook that stopped the operation before anything
+// was installed, so there is no cluster report, only what the hooks printed.
+func writeHookFailure(req *Request, cause error, logger *slog.Logger) {
+	if req.StructuredLogs {
+		logger.Error("❌ Hook failed", "hooks", req.hooks.Report(console.Palette{}))
+
+		return
+	}
+
+	palette := console.Palette{Enabled: req.ColorOutput}
+
+	fmt.Fprintf(req.Writer, "%s\n    %s\n\n", palette.Failure(capitalizedDirection(req.Direction)+" failed."), cause)
+	fmt.Fprint(req.Writer, req.hooks.Report(palette))
+}
+
+// runPostHook runs the post hook once the job is done with the volume, whatever
+// its outcome, and adds the hook's failure to the operation's.
+func runPostHook(ctx context.Context, req *Request, err error) error {
+	hookErr := req.hooks.Run(ctx, hook.PhasePost)
+	if hookErr == nil {
+		return err
+	}
+
+	if err == nil {
+		return hookErr //nolint:wrapcheck
+	}
+
+	return errors.Join(err, hookErr)
+}
+
+// validateHooks rejects hooks for a detached operation, whose job is still
+// running when pv-migrate exits, so that there is no point to run the post hook at.
+func validateHooks(req *Request) error {
+	if err := req.Hooks.Validate(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if !req.Hooks.IsEmpty() && req.Detach {
+		return errors.New("hooks cannot be used with --detach, since the post hook runs once the transfer finishes")
+	}
+
+	return nil
 }
 
 func capitalizedDirection(direction string) string {
@@ -619,11 +1083,10 @@ func capitalizedDirection(direction string) string {
 	return strings.ToUpper(direction[:1]) + direction[1:]
 }
 
-func cleanupRelease(pvcInfo *pvc.Info, releaseName string, timeout time.Duration) error {
+func cleanupRelease(client *k8s.ClusterClient, namespace, releaseName string, timeout time.Duration) error {
 	actionConfig := new(action.Configuration)
 
-	err := actionConfig.Init(pvcInfo.ClusterClient.RESTClientGetter,
-		pvcInfo.Claim.Namespace, os.Getenv("HELM_DRIVER"))
+	err := actionConfig.Init(client.RESTClientGetter, namespace, os.Getenv("HELM_DRIVER"))
 	if err != nil {
 		return fmt.Errorf("failed to initialize helm action config: %w", err)
 	}
@@ -655,6 +1118,21 @@ func handleMounted(info *pvc.Info, ignoreMounted bool, logger *slog.Logger) erro
 		"node: %s, claim: %s/%s", info.MountedNode, info.Claim.Namespace, info.Claim.Name)
 }
 
+// validateRestoreSelection checks the options that restore part of a backup.
+func validateRestoreSelection(req *Request) error {
+	if req.Direction == rclone.DirectionBackup && (req.SourcePath != "" || !req.Filter.IsEmpty()) {
+		return errors.New("a source path and filters only apply to restores")
+	}
+
+	if req.SourcePath != "" {
+		if err := validateSubpath(req.SourcePath); err != nil {
+			return fmt.Errorf("invalid --source-path: %w", err)
+		}
+	}
+
+	return req.Filter.Validate() //nolint:wrapcheck
+}
+
 // validateSubpath ensures the path is a relative subpath that stays under the mount root.
 func validateSubpath(p string) error {
 	if path.IsAbs(p) {
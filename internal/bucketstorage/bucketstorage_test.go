@@ -1,15 +1,24 @@
 package bucketstorage_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
 	"github.com/utkuozdemir/pv-migrate/internal/pvc"
 	"github.com/utkuozdemir/pv-migrate/internal/rclone"
 )
@@ -96,7 +105,55 @@ func TestBuildRemotePath(t *testing.T) {
 			req: bucketstorage.Request{
 				RcloneConfigFile: "/tmp/rclone.conf",
 			},
-			wantErr: "--remote is required when using --rclone-config",
+			wantErr: "--remote or --rclone-config-remote is required when using --rclone-config",
+		},
+		{
+			name: "named remote from raw config keeps the managed layout",
+			req: bucketstorage.Request{
+				RcloneConfigFile:   "/tmp/rclone.conf",
+				RcloneConfigRemote: "corp-s3",
+				Bucket:             "bucket",
+				Prefix:             "pv-migrate",
+				Name:               "backup",
+			},
+			expected: "corp-s3:bucket/pv-migrate/backup/",
+		},
+		{
+			name: "named remote still requires name",
+			req: bucketstorage.Request{
+				RcloneConfigFile:   "/tmp/rclone.conf",
+				RcloneConfigRemote: "corp-s3",
+				Bucket:             "bucket",
+			},
+			wantErr: "--name is required",
+		},
+		{
+			name: "named remote requires raw config",
+			req: bucketstorage.Request{
+				RcloneConfigRemote: "corp-s3",
+				Bucket:             "bucket",
+				Name:               "backup",
+			},
+			wantErr: "--rclone-config-remote requires --rclone-config",
+		},
+		{
+			name: "named remote and raw remote are exclusive",
+			req: bucketstorage.Request{
+				RcloneConfigFile:   "/tmp/rclone.conf",
+				RcloneConfigRemote: "corp-s3",
+				Remote:             "corp-s3:bucket/path",
+			},
+			wantErr: "cannot be used together",
+		},
+		{
+			name: "named remote must not carry a path",
+			req: bucketstorage.Request{
+				RcloneConfigFile:   "/tmp/rclone.conf",
+				RcloneConfigRemote: "corp-s3:bucket",
+				Bucket:             "bucket",
+				Name:               "backup",
+			},
+			wantErr: "invalid --rclone-config-remote",
 		},
 		{
 			name: "standard mode requires bucket",
@@ -234,21 +291,149 @@ func TestShouldUploadMetadata_NotFooledByValues(t *testing.T) {
 	}
 }
 
+func TestShouldUploadMetadata_RawConfig(t *testing.T) {
+	t.Parallel()
+
+	raw := &bucketstorage.Request{
+		Direction:        rclone.DirectionBackup,
+		RcloneConfigFile: "/tmp/rclone.conf",
+		Remote:           "corp-s3:bucket/path",
+	}
+	assert.False(t, bucketstorage.ShouldUploadMetadata(raw), "an opaque remote spec has no managed layout")
+
+	named := &bucketstorage.Request{
+		Direction:          rclone.DirectionBackup,
+		RcloneConfigFile:   "/tmp/rclone.conf",
+		RcloneConfigRemote: "corp-s3",
+	}
+	assert.True(t, bucketstorage.ShouldUploadMetadata(named))
+}
+
+func TestBuildRcloneConfig_NamedRemoteMustExist(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rclone.conf")
+	require.NoError(t, os.WriteFile(path, []byte("[corp-s3]\ntype = s3\n"), 0o600))
+
+	conf, err := bucketstorage.BuildRcloneConfig(&bucketstorage.Request{
+		RcloneConfigFile:   path,
+		RcloneConfigRemote: "corp-s3",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, conf, "[corp-s3]")
+
+	_, err = bucketstorage.BuildRcloneConfig(&bucketstorage.Request{
+		RcloneConfigFile:   path,
+		RcloneConfigRemote: "other",
+	})
+	require.ErrorContains(t, err, `--rclone-config-remote "other" is not defined`)
+}
+
 func TestBuildHelmValues_MetadataPresentWhenGenerated(t *testing.T) {
 	t.Parallel()
 
-	info := testPVCInfo("src")
+	infos := testPVCInfos("src")
 	req := &bucketstorage.Request{
 		Direction: rclone.DirectionBackup,
 	}
 
-	got := bucketstorage.BuildHelmValues("default", req, info, "conf", "cmd", true, "metadata", "remote:path.meta.yaml")
+	got := bucketstorage.BuildHelmValues("default", req, infos, "conf", "cmd", true, "metadata", "remote:path.meta.yaml")
 	rcloneVals := got["rclone"].(map[string]any) //nolint:forcetypeassert
 
 	assert.Equal(t, "metadata", rcloneVals["metadataBase64"])
 	assert.Equal(t, "remote:path.meta.yaml", rcloneVals["metadataRemotePath"])
 }
 
+func TestBuildHelmValues_CredentialsSecretBecomesRemoteEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		req        bucketstorage.Request
+		wantPrefix string
+	}{
+		{
+			name:       "generated config",
+			req:        bucketstorage.Request{Bucket: "bucket", Name: "backup"},
+			wantPrefix: "RCLONE_CONFIG_REMOTE_",
+		},
+		{
+			name: "named remote from a raw config",
+			req: bucketstorage.Request{
+				RcloneConfigFile: "/tmp/rclone.conf", RcloneConfigRemote: "corp-s3", Bucket: "bucket", Name: "backup",
+			},
+			wantPrefix: "RCLONE_CONFIG_CORP_S3_",
+		},
+		{
+			name:       "raw remote spec",
+			req:        bucketstorage.Request{RcloneConfigFile: "/tmp/rclone.conf", Remote: "corp:bucket/path"},
+			wantPrefix: "RCLONE_CONFIG_CORP_",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.req.CredentialsSecret = "backup-creds"
+
+			require.NoError(t, bucketstorage.ValidateCredentialsSecret(&tt.req))
+
+			got := bucketstorage.BuildHelmValues("default", &tt.req, testPVCInfos("src"), "conf", "cmd", true, "", "")
+			rcloneVals := got["rclone"].(map[string]any) //nolint:forcetypeassert
+
+			assert.Equal(t, []map[string]any{{
+				"prefix":    tt.wantPrefix,
+				"secretRef": map[string]any{"name": "backup-creds"},
+			}}, rcloneVals["envFrom"])
+		})
+	}
+}
+
+func TestValidateCredentialsSecret(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, bucketstorage.ValidateCredentialsSecret(&bucketstorage.Request{}))
+
+	err := bucketstorage.ValidateCredentialsSecret(&bucketstorage.Request{CredentialsSecret: "Bad_Name"})
+	require.ErrorContains(t, err, `--credentials-secret "Bad_Name" is not a valid secret name`)
+
+	err = bucketstorage.ValidateCredentialsSecret(&bucketstorage.Request{
+		CredentialsSecret: "creds",
+		RcloneConfigFile:  "/tmp/rclone.conf",
+		Remote:            "/local/path",
+	})
+	require.ErrorContains(t, err, "needs a named remote")
+
+	err = bucketstorage.ValidateCredentialsSecret(&bucketstorage.Request{CredentialsContext: "backups"})
+	require.ErrorContains(t, err, "need --credentials-secret")
+}
+
+func TestBuildHelmValues_CredentialsFromOtherClusterGetTheirOwnSecret(t *testing.T) {
+	t.Parallel()
+
+	req := bucketstorage.Request{
+		Bucket: "bucket", Name: "backup", CredentialsSecret: "backup-creds", CredentialsContext: "backups",
+	}
+	bucketstorage.SetCredentials(&req, map[string]string{"SECRET_ACCESS_KEY": "secret"})
+
+	got := bucketstorage.BuildHelmValues("default", &req, testPVCInfos("dest"), "conf", "cmd", false, "", "")
+	rcloneVals := got["rclone"].(map[string]any) //nolint:forcetypeassert
+
+	assert.Equal(t, map[string]string{"SECRET_ACCESS_KEY": "secret"}, rcloneVals["credentials"])
+	assert.Equal(t, "RCLONE_CONFIG_REMOTE_", rcloneVals["credentialsEnvPrefix"])
+	assert.NotContains(t, rcloneVals, "envFrom", "the secret is not in the PVC's cluster to be referenced")
+}
+
+func testPVCInfos(names ...string) []*pvc.Info {
+	infos := make([]*pvc.Info, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, testPVCInfo(name))
+	}
+
+	return infos
+}
+
 func testPVCInfo(name string) *pvc.Info {
 	return &pvc.Info{
 		Claim: &corev1.PersistentVolumeClaim{
@@ -273,3 +458,107 @@ func rcloneImageTag(t *testing.T, vals map[string]any) string {
 
 	return tag
 }
+
+func TestValidateRestoreSelection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     bucketstorage.Request
+		wantErr string
+	}{
+		{name: "nothing", req: bucketstorage.Request{Direction: rclone.DirectionBackup}},
+		{
+			name: "restore",
+			req: bucketstorage.Request{
+				Direction: rclone.DirectionRestore, SourcePath: "db",
+				Filter: rclone.Filter{Exclude: []string{"*.tmp"}},
+			},
+		},
+		{
+			name:    "backup",
+			req:     bucketstorage.Request{Direction: rclone.DirectionBackup, SourcePath: "db"},
+			wantErr: "a source path and filters only apply to restores",
+		},
+		{
+			name:    "escaping source path",
+			req:     bucketstorage.Request{Direction: rclone.DirectionRestore, SourcePath: "db/../.."},
+			wantErr: "invalid --source-path: must not escape the volume root",
+		},
+		{
+			name: "invalid filter",
+			req: bucketstorage.Request{
+				Direction: rclone.DirectionVerify, Filter: rclone.Filter{Rules: []string{"db/**"}},
+			},
+			wantErr: `invalid filter rule "db/**"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := bucketstorage.ValidateRestoreSelection(&tt.req)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	t.Parallel()
+
+	hooks := hook.Config{Pre: "sync", Selector: "app=db"}
+
+	require.NoError(t, bucketstorage.ValidateHooks(&bucketstorage.Request{Hooks: hooks}))
+	require.NoError(t, bucketstorage.ValidateHooks(&bucketstorage.Request{Detach: true}))
+	require.ErrorContains(t, bucketstorage.ValidateHooks(&bucketstorage.Request{Hooks: hooks, Detach: true}),
+		"hooks cannot be used with --detach")
+	require.ErrorContains(t, bucketstorage.ValidateHooks(&bucketstorage.Request{Hooks: hook.Config{Post: "sync"}}),
+		"--hook-selector is required")
+}
+
+func TestRunPreHookReleasesTheQuiescedPods(t *testing.T) {
+	t.Parallel()
+
+	pod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db", Labels: map[string]string{"app": "db"}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	client := &k8s.ClusterClient{KubeClient: fake.NewClientset(pod("db-0"), pod("db-1"))}
+
+	var (
+		ran []string
+		out bytes.Buffer
+	)
+
+	req := &bucketstorage.Request{
+		Direction: rclone.DirectionBackup,
+		Hooks:     hook.Config{Pre: "freeze", Post: "thaw", Selector: "app=db"},
+		Writer:    &out,
+	}
+
+	bucketstorage.SetHookExec(req, func(_ context.Context, _ *k8s.ClusterClient, run *k8s.ExecRequest, _ io.Writer) error {
+		ran = append(ran, run.Command[2]+" "+run.PodName)
+
+		if run.Command[2] == "freeze" && run.PodName == "db-1" {
+			return errors.New("cannot freeze")
+		}
+
+		return nil
+	})
+
+	err := bucketstorage.RunPreHook(t.Context(), req, client, "db", slog.New(slog.DiscardHandler))
+	require.ErrorContains(t, err, "pre hook failed: pod db-1")
+	assert.Equal(t, []string{"freeze db-0", "freeze db-1", "thaw db-0"}, ran,
+		"the pod the pre hook froze is thawed before the operation stops")
+	assert.Contains(t, out.String(), "post hook in pod db/db-0: succeeded")
+}
@@ -0,0 +1,245 @@
+package bucketstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+
+	"github.com/utkuozdemir/pv-migrate/internal/helm"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+// The remotes of the config a copy generates, one for each backend.
+const (
+	copySourceRemote = "source"
+	copyDestRemote   = "dest"
+)
+
+// Copy copies a managed backup, its data, its manifest and then its metadata,
+// from the backend of req to the backend of dest, in an rclone job that mounts
+// no PVC. The job runs in req's namespace and is configured by req, so dest only
+// contributes its backend: the storage fields, the credentials and the workload
+// identity of its cloud.
+func Copy(ctx context.Context, req, dest *Request) error {
+	if req.Writer == nil {
+		req.Writer = io.Discard
+	}
+
+	req.Direction = rclone.DirectionCopy
+
+	operationID := req.ID
+	if operationID == "" {
+		operationID = opid.Generate()
+	}
+
+	logger := req.Logger.With("id", operationID, "direction", req.Direction)
+
+	rcloneConf, cmdStr, err := buildCopy(req, dest)
+	if err != nil {
+		return err
+	}
+
+	warnAmbientAuth(req, logger.With("side", "source"))
+	warnAmbientAuth(dest, logger.With("side", "destination"))
+
+	identity, err := copyIdentity(req, dest)
+	if err != nil {
+		return err
+	}
+
+	client, err := k8s.GetClusterClient(req.KubeconfigPath, req.Context, logger)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster client: %w", err)
+	}
+
+	ns := req.Namespace
+	if ns == "" {
+		ns = client.NsInContext
+	}
+
+	for _, side := range []*Request{req, dest} {
+		if side.CredentialsSecret != "" {
+			if err = checkCredentialsSecret(ctx, client, ns, side.CredentialsSecret); err != nil {
+				return err
+			}
+		}
+	}
+
+	helmChart, err := helm.LoadChart(req.ChartVersion)
+	if err != nil {
+		return fmt.Errorf("failed to load helm chart: %w", err)
+	}
+
+	helmVals := buildCopyHelmValues(ns, req, dest, identity, rcloneConf, cmdStr)
+
+	releaseName := opid.ReleasePrefix + operationID + "-" + req.Direction
+
+	logger = logger.With("release", releaseName)
+	logger.Info("📦 Installing Helm chart")
+
+	if err = installHelmChart(helmChart, client, ns, releaseName, helmVals, req, logger); err != nil {
+		writeFailure(ctx, req, client.KubeClient, ns, releaseName, err, logger)
+
+		return fmt.Errorf("failed to install helm chart: %w", err)
+	}
+
+	jobName := releaseName + "-rclone"
+
+	return handleJobCompletion(ctx, req, client, ns, releaseName, jobName, operationID, logger)
+}
+
+// buildCopy validates both backends and returns the config holding a remote for
+// each, along with the command that copies between them. The service account is
+// the job's rather than a backend's, so dest takes req's before it is validated.
+func buildCopy(req, dest *Request) (string, string, error) {
+	req.remoteName = copySourceRemote
+	dest.remoteName = copyDestRemote
+	dest.ServiceAccountName = req.ServiceAccountName
+	dest.ServiceAccountAnnotations = req.ServiceAccountAnnotations
+
+	sides := []struct {
+		name string
+		req  *Request
+	}{
+		{"source", req},
+		{"destination", dest},
+	}
+
+	confs := make([]string, 0, len(sides))
+	paths := make([]string, 0, len(sides))
+
+	for _, side := range sides {
+		conf, remotePath, err := buildCopySide(side.req)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", side.name, err)
+		}
+
+		confs = append(confs, conf)
+		paths = append(paths, remotePath)
+	}
+
+	if sameBackup(req, dest) {
+		return "", "", errors.New("the source and the destination are the same backup")
+	}
+
+	copyCmd := rclone.CopyCmd{
+		SourcePath:         paths[0],
+		DestPath:           paths[1],
+		SourceMetadataPath: rclone.BuildMetadataRemotePath(copySourceRemote, req.Bucket, req.Prefix, req.Name),
+		DestMetadataPath:   rclone.BuildMetadataRemotePath(copyDestRemote, dest.Bucket, dest.Prefix, dest.Name),
+		SourceManifestPath: rclone.BuildManifestRemotePath(copySourceRemote, req.Bucket, req.Prefix, req.Name),
+		DestManifestPath:   rclone.BuildManifestRemotePath(copyDestRemote, dest.Bucket, dest.Prefix, dest.Name),
+		ConfigPath:         "/etc/rclone/rclone.conf",
+		ExtraArgs:          req.RcloneExtraArgs,
+	}
+
+	cmdStr, err := copyCmd.Build()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build rclone command: %w", err)
+	}
+
+	return confs[0] + "\n" + confs[1], cmdStr, nil
+}
+
+func buildCopySide(req *Request) (string, string, error) {
+	// Two user configs could define the same remote twice, and a raw remote spec
+	// has no metadata to carry over, so both sides are generated.
+	if req.RcloneConfigFile != "" || req.RcloneConfigRemote != "" || req.Remote != "" {
+		return "", "", errors.New("a raw rclone config cannot be copied from or to, only a generated one")
+	}
+
+	for _, validate := range []func(*Request) error{
+		validateCredentialsSecret, validateWorkloadIdentity,
+	} {
+		if err := validate(req); err != nil {
+			return "", "", err
+		}
+	}
+
+	conf, err := buildRcloneConfig(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build rclone config: %w", err)
+	}
+
+	remotePath, err := buildRemotePath(req)
+	if err != nil {
+		return "", "", err
+	}
+
+	return conf, remotePath, nil
+}
+
+// sameBackup reports whether both sides name the same location, which would
+// copy every object onto itself.
+func sameBackup(req, dest *Request) bool {
+	return req.Backend == dest.Backend && req.Endpoint == dest.Endpoint &&
+		req.StorageAccount == dest.StorageAccount && req.SFTPHost == dest.SFTPHost &&
+		req.WebDAVURL == dest.WebDAVURL && req.SwiftAuthURL == dest.SwiftAuthURL &&
+		req.Bucket == dest.Bucket && req.Prefix == dest.Prefix && req.Name == dest.Name
+}
+
+// copyIdentity joins the workload identities of both backends onto the one
+// service account of the job, which a copy between two clouds needs both of.
+func copyIdentity(req, dest *Request) (*Request, error) {
+	annotations := serviceAccountAnnotations(req)
+
+	for key, value := range serviceAccountAnnotations(dest) {
+		if existing, ok := annotations[key]; ok && existing != value {
+			return nil, fmt.Errorf("the source and the destination set the service account annotation %q "+
+				"to different values", key)
+		}
+	}
+
+	maps.Copy(annotations, serviceAccountAnnotations(dest))
+
+	azureClientID := req.AzureClientID
+	if azureClientID == "" {
+		azureClientID = dest.AzureClientID
+	}
+
+	return &Request{
+		ServiceAccountName:        req.ServiceAccountName,
+		ServiceAccountAnnotations: annotations,
+		AzureClientID:             azureClientID,
+	}, nil
+}
+
+func buildCopyHelmValues(namespace string, req, dest, identity *Request, rcloneConf, cmdStr string) map[string]any {
+	rcloneVals := map[string]any{
+		"enabled":     true,
+		"namespace":   namespace,
+		"configMount": true,
+		"config":      rcloneConf,
+		"command":     cmdStr,
+		"extraArgs":   "",
+		"pvcMounts":   []map[string]any{},
+	}
+
+	var envFrom []map[string]any
+
+	for _, side := range []*Request{req, dest} {
+		if side.CredentialsSecret != "" {
+			envFrom = append(envFrom, credentialsEnvFrom(side)...)
+		}
+	}
+
+	if len(envFrom) > 0 {
+		rcloneVals["envFrom"] = envFrom
+	}
+
+	applyWorkloadIdentityValues(rcloneVals, identity)
+
+	vals := map[string]any{
+		"rclone": rcloneVals,
+	}
+
+	if req.NonRoot {
+		applyNonRootValues(vals)
+	}
+
+	return vals
+}
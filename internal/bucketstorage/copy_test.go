@@ -0,0 +1,164 @@
+package bucketstorage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func copySides() (*bucketstorage.Request, *bucketstorage.Request) {
+	source := &bucketstorage.Request{
+		Backend:   rclone.BackendS3,
+		Bucket:    "source-bucket",
+		AccessKey: "AKID",
+		SecretKey: "SKEY",
+		Prefix:    "pv-migrate",
+		Name:      "db",
+	}
+
+	dest := &bucketstorage.Request{
+		Backend:               rclone.BackendGCS,
+		Bucket:                "dest-bucket",
+		GCSServiceAccountJSON: `{"type":"service_account"}`,
+		Prefix:                "archive",
+		Name:                  "db",
+	}
+
+	return source, dest
+}
+
+func TestBuildCopy(t *testing.T) {
+	t.Parallel()
+
+	source, dest := copySides()
+
+	conf, cmdStr, err := bucketstorage.BuildCopy(source, dest)
+	require.NoError(t, err)
+
+	assert.True(t, rclone.ConfigHasRemote(conf, "source"))
+	assert.True(t, rclone.ConfigHasRemote(conf, "dest"))
+	assert.Contains(t, conf, "type = s3")
+	assert.Contains(t, conf, "type = google cloud storage")
+
+	assert.Contains(t, cmdStr,
+		"'source:source-bucket/pv-migrate/db/' 'dest:dest-bucket/archive/db/'")
+	assert.Contains(t, cmdStr,
+		"rclone copyto --config '/etc/rclone/rclone.conf' "+
+			"'source:source-bucket/pv-migrate/db.meta.yaml' 'dest:dest-bucket/archive/db.meta.yaml'")
+}
+
+func TestBuildCopy_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		modify  func(source, dest *bucketstorage.Request)
+		wantErr string
+	}{
+		{
+			name:    "missing source name",
+			modify:  func(source, _ *bucketstorage.Request) { source.Name = "" },
+			wantErr: "source: --name is required",
+		},
+		{
+			name:    "invalid destination bucket",
+			modify:  func(_, dest *bucketstorage.Request) { dest.Bucket = "bad/bucket" },
+			wantErr: `destination: --bucket "bad/bucket" contains invalid characters`,
+		},
+		{
+			name:    "raw config",
+			modify:  func(_, dest *bucketstorage.Request) { dest.RcloneConfigFile = "/tmp/rclone.conf" },
+			wantErr: "destination: a raw rclone config cannot be copied from or to",
+		},
+		{
+			name: "same backup",
+			modify: func(source, dest *bucketstorage.Request) {
+				*dest = *source
+			},
+			wantErr: "the source and the destination are the same backup",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			source, dest := copySides()
+			tt.modify(source, dest)
+
+			_, _, err := bucketstorage.BuildCopy(source, dest)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestBuildCopy_ServiceAccountCoversBothSides(t *testing.T) {
+	t.Parallel()
+
+	source, dest := copySides()
+	dest.GCSServiceAccountJSON = ""
+	source.ServiceAccountName = "backup-copier"
+
+	_, _, err := bucketstorage.BuildCopy(source, dest)
+	require.NoError(t, err)
+
+	dest.GCPServiceAccount = "copier@project.iam.gserviceaccount.com"
+
+	_, _, err = bucketstorage.BuildCopy(source, dest)
+	require.ErrorContains(t, err, "destination: --service-account uses an existing service account")
+}
+
+func TestCopyIdentity(t *testing.T) {
+	t.Parallel()
+
+	source, dest := copySides()
+	source.AWSRoleARN = "arn:aws:iam::123456789012:role/backup"
+	dest.GCPServiceAccount = "copier@project.iam.gserviceaccount.com"
+
+	identity, err := bucketstorage.CopyIdentity(source, dest)
+	require.NoError(t, err)
+
+	vals := bucketstorage.BuildCopyHelmValues("ns", source, dest, identity, "conf", "cmd")
+	rcloneVals := vals["rclone"].(map[string]any) //nolint:forcetypeassert
+
+	assert.Equal(t, map[string]any{
+		"annotations": map[string]any{
+			"eks.amazonaws.com/role-arn":     "arn:aws:iam::123456789012:role/backup",
+			"iam.gke.io/gcp-service-account": "copier@project.iam.gserviceaccount.com",
+		},
+	}, rcloneVals["serviceAccount"])
+	assert.Empty(t, rcloneVals["pvcMounts"])
+
+	source.ServiceAccountAnnotations = map[string]string{
+		"iam.gke.io/gcp-service-account": "other@x.iam.gserviceaccount.com",
+	}
+
+	_, err = bucketstorage.CopyIdentity(source, dest)
+	require.ErrorContains(t, err, `annotation "iam.gke.io/gcp-service-account" to different values`)
+}
+
+func TestBuildCopyHelmValues_CredentialsSecrets(t *testing.T) {
+	t.Parallel()
+
+	source, dest := copySides()
+	source.CredentialsSecret = "s3-creds"
+	dest.CredentialsSecret = "gcs-creds"
+
+	_, _, err := bucketstorage.BuildCopy(source, dest)
+	require.NoError(t, err)
+
+	identity, err := bucketstorage.CopyIdentity(source, dest)
+	require.NoError(t, err)
+
+	vals := bucketstorage.BuildCopyHelmValues("ns", source, dest, identity, "conf", "cmd")
+	rcloneVals := vals["rclone"].(map[string]any) //nolint:forcetypeassert
+
+	assert.Equal(t, []map[string]any{
+		{"prefix": "RCLONE_CONFIG_SOURCE_", "secretRef": map[string]any{"name": "s3-creds"}},
+		{"prefix": "RCLONE_CONFIG_DEST_", "secretRef": map[string]any{"name": "gcs-creds"}},
+	}, rcloneVals["envFrom"])
+}
@@ -1,9 +1,59 @@
 package bucketstorage
 
+import (
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
 var (
+	BuildRcloneConfig    = buildRcloneConfig
 	BuildRemotePath      = buildRemotePath
 	BuildHelmValues      = buildHelmValues
 	MergeHelmValues      = mergeHelmValues
 	ShouldUploadMetadata = shouldUploadMetadata
 	ValidateSubpath      = validateSubpath
+
+	ValidateCredentialsSecret = validateCredentialsSecret
+	ValidateWorkloadIdentity  = validateWorkloadIdentity
+	WarnAmbientAuth           = warnAmbientAuth
+
+	ValidatePVCSet     = validatePVCSet
+	ResolvePVCNames    = resolvePVCNames
+	BuildRcloneCommand = buildRcloneCommand
+	CheckMountedNodes  = checkMountedNodes
+
+	BuildManifestCommand = buildManifestCommand
+	ValidateArchive      = validateArchive
+	BuildFormatCheck     = buildFormatCheck
+)
+
+var (
+	BuildCopy           = buildCopy
+	CopyIdentity        = copyIdentity
+	BuildCopyHelmValues = buildCopyHelmValues
+)
+
+var (
+	ValidateVerify           = validateVerify
+	ValidateRestoreSelection = validateRestoreSelection
+	ValidateHooks            = validateHooks
+	RunPreHook               = runPreHook
+	ValidateFromLatest       = validateFromLatest
+	LatestVersion            = latestVersion
+	BuildVerifyCommand       = buildVerifyCommand
+	WriteDifferences         = writeDifferences
 )
+
+func NewVerificationError(report rclone.CheckReport, err error) error {
+	return &verificationError{report: report, err: err}
+}
+
+// SetCredentials stands in for reading the credentials secret from another cluster.
+func SetCredentials(req *Request, data map[string]string) {
+	req.credentials = data
+}
+
+// SetHookExec stands in for running the hooks in the pods of a cluster.
+func SetHookExec(req *Request, exec hook.ExecFunc) {
+	req.hookExec = exec
+}
@@ -0,0 +1,174 @@
+package bucketstorage
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+// The annotations and the label each cloud's workload identity integration reads
+// off the pod's service account, and off the pod in Azure's case.
+const (
+	awsRoleARNAnnotation        = "eks.amazonaws.com/role-arn"
+	azureClientIDAnnotation     = "azure.workload.identity/client-id"
+	azureTenantIDAnnotation     = "azure.workload.identity/tenant-id"
+	azureUseLabel               = "azure.workload.identity/use"
+	gcpServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+)
+
+var (
+	awsRoleARN = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/.+$`)
+	azureUUID  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	gcpSAEmail = regexp.MustCompile(`^[a-z][a-z0-9-]*@[a-z0-9-]+\.iam\.gserviceaccount\.com$`)
+)
+
+// validateWorkloadIdentity rejects identity options that cannot take effect: one
+// for another cloud's backend, a malformed value the cloud would only refuse once
+// the job is running, or an annotation for a service account pv-migrate does not
+// create.
+//
+//nolint:cyclop
+func validateWorkloadIdentity(req *Request) error {
+	identityFlags := []struct {
+		flag, value, backend string
+		pattern              *regexp.Regexp
+	}{
+		{"aws-role-arn", req.AWSRoleARN, rclone.BackendS3, awsRoleARN},
+		{"azure-client-id", req.AzureClientID, rclone.BackendAzure, azureUUID},
+		{"azure-tenant-id", req.AzureTenantID, rclone.BackendAzure, azureUUID},
+		{"gcp-service-account", req.GCPServiceAccount, rclone.BackendGCS, gcpSAEmail},
+	}
+
+	for _, identity := range identityFlags {
+		if identity.value == "" {
+			continue
+		}
+
+		// A raw config can point at any backend, so only a generated one is checked.
+		if req.RcloneConfigFile == "" && req.Backend != identity.backend {
+			return fmt.Errorf("--%s only applies to the %s backend", identity.flag, identity.backend)
+		}
+
+		if !identity.pattern.MatchString(identity.value) {
+			return fmt.Errorf("--%s %q is malformed", identity.flag, identity.value)
+		}
+	}
+
+	if req.AzureTenantID != "" && req.AzureClientID == "" {
+		return errors.New("--azure-tenant-id requires --azure-client-id")
+	}
+
+	for key := range req.ServiceAccountAnnotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("--service-account-annotations key %q is invalid: %s", key, strings.Join(errs, "; "))
+		}
+	}
+
+	if req.ServiceAccountName == "" {
+		return nil
+	}
+
+	if errs := validation.IsDNS1123Subdomain(req.ServiceAccountName); len(errs) > 0 {
+		return fmt.Errorf("--service-account %q is invalid: %s", req.ServiceAccountName, strings.Join(errs, "; "))
+	}
+
+	if len(serviceAccountAnnotations(req)) > 0 {
+		return errors.New("--service-account uses an existing service account, which pv-migrate does not annotate: " +
+			"annotate it directly instead of passing --service-account-annotations or an identity flag")
+	}
+
+	return nil
+}
+
+// hasWorkloadIdentity reports whether the rclone pod runs with an identity the
+// user attached, as opposed to whatever the namespace's default service account
+// happens to carry.
+func hasWorkloadIdentity(req *Request) bool {
+	return req.ServiceAccountName != "" || len(serviceAccountAnnotations(req)) > 0
+}
+
+// warnAmbientAuth warns about a generated config that authenticates with nothing
+// the user passed. rclone then falls back to whatever it finds in the pod, which
+// is what a node with an instance profile relies on, but is usually nothing and
+// surfaces as an opaque permission error only once the job runs. EnvAuth says the
+// fallback is intended, which silences the warning.
+func warnAmbientAuth(req *Request, logger *slog.Logger) {
+	if !reliesOnAmbientAuth(req) {
+		return
+	}
+
+	logger.Warn("🔶 No credentials given, rclone will use what the node provides, if anything. "+
+		"Pass them, --credentials-secret or a workload identity (--aws-role-arn, --azure-client-id, "+
+		"--gcp-service-account, --service-account), or --env-auth if the node is meant to grant access",
+		"backend", req.Backend)
+}
+
+func reliesOnAmbientAuth(req *Request) bool {
+	return req.RcloneConfigFile == "" && !req.EnvAuth && configOptions(req).UsesAmbientAuth() &&
+		!hasWorkloadIdentity(req)
+}
+
+func serviceAccountAnnotations(req *Request) map[string]string {
+	annotations := maps.Clone(req.ServiceAccountAnnotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	for key, value := range map[string]string{
+		awsRoleARNAnnotation:        req.AWSRoleARN,
+		azureClientIDAnnotation:     req.AzureClientID,
+		azureTenantIDAnnotation:     req.AzureTenantID,
+		gcpServiceAccountAnnotation: req.GCPServiceAccount,
+	} {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
+
+// applyWorkloadIdentityValues points the rclone job at the identity the user
+// chose: the annotations on the service account the chart creates, or an
+// existing service account in its place.
+func applyWorkloadIdentityValues(rcloneVals map[string]any, req *Request) {
+	if req.ServiceAccountName != "" {
+		rcloneVals["serviceAccount"] = map[string]any{
+			"create": false,
+			"name":   req.ServiceAccountName,
+		}
+
+		return
+	}
+
+	annotations := serviceAccountAnnotations(req)
+	if len(annotations) == 0 {
+		return
+	}
+
+	rcloneVals["serviceAccount"] = map[string]any{
+		"annotations": toAnyMap(annotations),
+	}
+
+	// Azure's webhook only mutates pods that opt in with this label.
+	if req.AzureClientID != "" {
+		rcloneVals["podLabels"] = map[string]any{azureUseLabel: "true"}
+	}
+}
+
+func toAnyMap(m map[string]string) map[string]any {
+	result := make(map[string]any, len(m))
+
+	for key, value := range m {
+		result[key] = value
+	}
+
+	return result
+}
@@ -0,0 +1,212 @@
+package bucketstorage_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+const testAzureClientID = "00000000-1111-2222-3333-444444444444"
+
+func TestValidateWorkloadIdentity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     bucketstorage.Request
+		wantErr string
+	}{
+		{
+			name: "no identity",
+			req:  bucketstorage.Request{Backend: rclone.BackendS3},
+		},
+		{
+			name: "aws role on s3",
+			req: bucketstorage.Request{
+				Backend: rclone.BackendS3, AWSRoleARN: "arn:aws:iam::123456789012:role/backup",
+			},
+		},
+		{
+			name: "aws role on a gov partition",
+			req: bucketstorage.Request{
+				Backend: rclone.BackendS3, AWSRoleARN: "arn:aws-us-gov:iam::123456789012:role/path/backup",
+			},
+		},
+		{
+			name:    "aws role on azure",
+			req:     bucketstorage.Request{Backend: rclone.BackendAzure, AWSRoleARN: "arn:aws:iam::123456789012:role/x"},
+			wantErr: "--aws-role-arn only applies to the s3 backend",
+		},
+		{
+			name:    "malformed aws role",
+			req:     bucketstorage.Request{Backend: rclone.BackendS3, AWSRoleARN: "backup-role"},
+			wantErr: `--aws-role-arn "backup-role" is malformed`,
+		},
+		{
+			name: "azure client and tenant",
+			req: bucketstorage.Request{
+				Backend: rclone.BackendAzure, AzureClientID: testAzureClientID, AzureTenantID: testAzureClientID,
+			},
+		},
+		{
+			name:    "azure tenant without client",
+			req:     bucketstorage.Request{Backend: rclone.BackendAzure, AzureTenantID: testAzureClientID},
+			wantErr: "--azure-tenant-id requires --azure-client-id",
+		},
+		{
+			name:    "malformed azure client",
+			req:     bucketstorage.Request{Backend: rclone.BackendAzure, AzureClientID: "my-identity"},
+			wantErr: `--azure-client-id "my-identity" is malformed`,
+		},
+		{
+			name: "gcp service account",
+			req: bucketstorage.Request{
+				Backend: rclone.BackendGCS, GCPServiceAccount: "backup@my-project.iam.gserviceaccount.com",
+			},
+		},
+		{
+			name:    "malformed gcp service account",
+			req:     bucketstorage.Request{Backend: rclone.BackendGCS, GCPServiceAccount: "backup"},
+			wantErr: `--gcp-service-account "backup" is malformed`,
+		},
+		{
+			name: "backend not checked with a raw config",
+			req: bucketstorage.Request{
+				RcloneConfigFile: "/tmp/rclone.conf", GCPServiceAccount: "backup@my-project.iam.gserviceaccount.com",
+			},
+		},
+		{
+			name: "invalid annotation key",
+			req: bucketstorage.Request{
+				Backend: rclone.BackendS3, ServiceAccountAnnotations: map[string]string{"not a key": "x"},
+			},
+			wantErr: `--service-account-annotations key "not a key" is invalid`,
+		},
+		{
+			name: "existing service account",
+			req:  bucketstorage.Request{Backend: rclone.BackendS3, ServiceAccountName: "backup"},
+		},
+		{
+			name:    "invalid service account",
+			req:     bucketstorage.Request{Backend: rclone.BackendS3, ServiceAccountName: "Backup_SA"},
+			wantErr: `--service-account "Backup_SA" is invalid`,
+		},
+		{
+			name: "existing service account with an identity",
+			req: bucketstorage.Request{
+				Backend: rclone.BackendS3, ServiceAccountName: "backup",
+				AWSRoleARN: "arn:aws:iam::123456789012:role/backup",
+			},
+			wantErr: "--service-account uses an existing service account",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := bucketstorage.ValidateWorkloadIdentity(&tt.req)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestBuildHelmValues_WorkloadIdentity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		req                bucketstorage.Request
+		wantServiceAccount any
+		wantPodLabels      any
+	}{
+		{
+			name: "none",
+			req:  bucketstorage.Request{Backend: rclone.BackendS3},
+		},
+		{
+			name: "aws role merged with extra annotations",
+			req: bucketstorage.Request{
+				Backend:                   rclone.BackendS3,
+				AWSRoleARN:                "arn:aws:iam::123456789012:role/backup",
+				ServiceAccountAnnotations: map[string]string{"eks.amazonaws.com/sts-regional-endpoints": "true"},
+			},
+			wantServiceAccount: map[string]any{"annotations": map[string]any{
+				"eks.amazonaws.com/role-arn":               "arn:aws:iam::123456789012:role/backup",
+				"eks.amazonaws.com/sts-regional-endpoints": "true",
+			}},
+		},
+		{
+			name: "azure labels the pod",
+			req:  bucketstorage.Request{Backend: rclone.BackendAzure, AzureClientID: testAzureClientID},
+			wantServiceAccount: map[string]any{"annotations": map[string]any{
+				"azure.workload.identity/client-id": testAzureClientID,
+			}},
+			wantPodLabels: map[string]any{"azure.workload.identity/use": "true"},
+		},
+		{
+			name:               "existing service account",
+			req:                bucketstorage.Request{Backend: rclone.BackendGCS, ServiceAccountName: "backup"},
+			wantServiceAccount: map[string]any{"create": false, "name": "backup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := bucketstorage.BuildHelmValues("default", &tt.req, testPVCInfos("src"), "conf", "cmd", true, "", "")
+			rcloneVals := got["rclone"].(map[string]any) //nolint:forcetypeassert
+
+			assert.Equal(t, tt.wantServiceAccount, rcloneVals["serviceAccount"])
+			assert.Equal(t, tt.wantPodLabels, rcloneVals["podLabels"])
+		})
+	}
+}
+
+// TestWarnAmbientAuth pins that a keyless config is let through with a warning:
+// a node with an instance profile is a setup that works without any flag.
+func TestWarnAmbientAuth(t *testing.T) {
+	t.Parallel()
+
+	keyless := bucketstorage.Request{Backend: rclone.BackendS3, Bucket: "bucket", Name: "backup"}
+
+	var out bytes.Buffer
+
+	bucketstorage.WarnAmbientAuth(&keyless, slog.New(slog.NewTextHandler(&out, nil)))
+	assert.Contains(t, out.String(), "No credentials given")
+	assert.Contains(t, out.String(), "backend=s3")
+
+	for name, mutate := range map[string]func(*bucketstorage.Request){
+		"keys":               func(r *bucketstorage.Request) { r.AccessKey, r.SecretKey = "a", "s" },
+		"credentials secret": func(r *bucketstorage.Request) { r.CredentialsSecret = "creds" },
+		"workload identity":  func(r *bucketstorage.Request) { r.AWSRoleARN = "arn:aws:iam::123456789012:role/x" },
+		"existing sa":        func(r *bucketstorage.Request) { r.ServiceAccountName = "backup" },
+		"env auth":           func(r *bucketstorage.Request) { r.EnvAuth = true },
+		"raw config":         func(r *bucketstorage.Request) { r.RcloneConfigFile = "/tmp/rclone.conf" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := keyless
+			mutate(&req)
+
+			var out bytes.Buffer
+
+			bucketstorage.WarnAmbientAuth(&req, slog.New(slog.NewTextHandler(&out, nil)))
+			assert.Empty(t, out.String())
+		})
+	}
+}
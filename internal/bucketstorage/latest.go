@@ -0,0 +1,142 @@
+package bucketstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/helm"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+// resolveReleaseSuffix takes the place of the direction in the name of the
+// release that looks for the latest backup, so that status and cleanup find it
+// under the operation's ID like the others.
+const resolveReleaseSuffix = "resolve"
+
+// validateFromLatest checks that the backups can be found by their metadata,
+// which only the managed layout has.
+func validateFromLatest(req *Request) error {
+	if !req.FromLatest {
+		return nil
+	}
+
+	if req.Direction == rclone.DirectionBackup {
+		return errors.New("--from-latest only applies to restores")
+	}
+
+	if isRawRemote(req) {
+		return errors.New("--from-latest finds the backups by --bucket, --prefix and --name, so it cannot be used " +
+			"with --remote")
+	}
+
+	return nil
+}
+
+// resolveLatest lists the versions of the backup named req.Name in a job that
+// mounts no PVC, and points req at the one taken last, as its metadata records.
+// The job is removed once its listing is read, unless cleanup is turned off.
+func resolveLatest(
+	ctx context.Context,
+	req *Request,
+	client *k8s.ClusterClient,
+	namespace, operationID, rcloneConf string,
+	logger *slog.Logger,
+) (retErr error) {
+	listCmd := rclone.ListBackupsCmd{
+		DirPath:    rclone.BuildBackupsDirPath(managedRemoteName(req), req.Bucket, req.Prefix),
+		ConfigPath: "/etc/rclone/rclone.conf",
+		Name:       req.Name,
+		ExtraArgs:  req.RcloneExtraArgs,
+	}
+
+	cmdStr, err := listCmd.Build()
+	if err != nil {
+		return fmt.Errorf("failed to build rclone command: %w", err)
+	}
+
+	helmChart, err := helm.LoadChart(req.ChartVersion)
+	if err != nil {
+		return fmt.Errorf("failed to load helm chart: %w", err)
+	}
+
+	helmVals := buildHelmValues(namespace, req, nil, rcloneConf, cmdStr, true, "", "")
+
+	releaseName := opid.ReleasePrefix + operationID + "-" + resolveReleaseSuffix
+	jobName := releaseName + "-rclone"
+
+	logger = logger.With("release", releaseName)
+	logger.Info("🔍 Looking for the latest backup", "name", req.Name)
+
+	if err = installHelmChart(helmChart, client, namespace, releaseName, helmVals, req, logger); err != nil {
+		writeFailure(ctx, req, client.KubeClient, namespace, releaseName, err, logger)
+
+		return fmt.Errorf("failed to install helm chart: %w", err)
+	}
+
+	defer func() {
+		if req.NoCleanup || (req.NoCleanupOnFailure && retErr != nil) {
+			return
+		}
+
+		if cleanupErr := cleanupRelease(client, namespace, releaseName, req.HelmTimeout); cleanupErr != nil {
+			logger.Warn("🔶 Cleanup failed, you might want to clean up manually", "error", cleanupErr)
+		}
+	}()
+
+	err = k8s.WaitForJobCompletion(ctx, client.KubeClient, namespace, jobName, false, req.StructuredLogs,
+		console.Palette{Enabled: req.ColorOutput}, req.Writer, logger)
+	if err != nil {
+		writeFailure(ctx, req, client.KubeClient, namespace, releaseName, err, logger)
+
+		return fmt.Errorf("failed to list the backups: %w", err)
+	}
+
+	versions := rclone.VersionsOf(req.Name,
+		rclone.ParseBackupList(k8s.JobReportLog(ctx, client.KubeClient, namespace, jobName, logger)))
+
+	latest, err := latestVersion(req.Name, versions)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("🔖 Found the latest backup", "name", latest.Name,
+		"backup_time", latest.BackupTime.Format(time.RFC3339))
+
+	req.Name = latest.Name
+
+	return nil
+}
+
+// latestVersion picks the version taken last. A version whose metadata could not
+// be read has no time to compare, so it is left out rather than guessed at.
+func latestVersion(name string, versions []rclone.BackupVersion) (rclone.BackupVersion, error) {
+	var latest rclone.BackupVersion
+
+	for _, version := range versions {
+		if version.BackupTime.IsZero() {
+			continue
+		}
+
+		if version.BackupTime.After(latest.BackupTime) ||
+			(version.BackupTime.Equal(latest.BackupTime) && version.Name > latest.Name) {
+			latest = version
+		}
+	}
+
+	if latest.Name != "" {
+		return latest, nil
+	}
+
+	if len(versions) == 0 {
+		return rclone.BackupVersion{}, fmt.Errorf("no backup named %s or %s-<version> was found", name, name)
+	}
+
+	return rclone.BackupVersion{}, fmt.Errorf("found %d backups named %s or %s-<version>, "+
+		"but the metadata of none of them records when it was taken", len(versions), name, name)
+}
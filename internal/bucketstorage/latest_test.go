@@ -0,0 +1,99 @@
+package bucketstorage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestValidateFromLatest(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, bucketstorage.ValidateFromLatest(&bucketstorage.Request{
+		Direction: rclone.DirectionBackup,
+	}))
+	require.NoError(t, bucketstorage.ValidateFromLatest(&bucketstorage.Request{
+		Direction: rclone.DirectionRestore, FromLatest: true, Bucket: "bucket", Name: "db",
+	}))
+	require.NoError(t, bucketstorage.ValidateFromLatest(&bucketstorage.Request{
+		Direction: rclone.DirectionVerify, FromLatest: true, RcloneConfigFile: "/tmp/rclone.conf",
+		RcloneConfigRemote: "corp", Bucket: "bucket", Name: "db",
+	}), "a named remote of a raw config keeps the managed layout")
+
+	require.ErrorContains(t, bucketstorage.ValidateFromLatest(&bucketstorage.Request{
+		Direction: rclone.DirectionBackup, FromLatest: true,
+	}), "--from-latest only applies to restores")
+	require.ErrorContains(t, bucketstorage.ValidateFromLatest(&bucketstorage.Request{
+		Direction: rclone.DirectionRestore, FromLatest: true, RcloneConfigFile: "/tmp/rclone.conf",
+		Remote: "corp:bucket/db",
+	}), "cannot be used with --remote")
+}
+
+func TestLatestVersion(t *testing.T) {
+	t.Parallel()
+
+	night := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		versions []rclone.BackupVersion
+		want     string
+		wantErr  string
+	}{
+		{
+			name: "newest by backup time, not by name",
+			versions: []rclone.BackupVersion{
+				{Name: "db-20260301", BackupTime: night},
+				{Name: "db", BackupTime: night.Add(time.Hour)},
+				{Name: "db-20260228", BackupTime: night.Add(-24 * time.Hour)},
+			},
+			want: "db",
+		},
+		{
+			name: "a version without metadata is left out",
+			versions: []rclone.BackupVersion{
+				{Name: "db-20260301", BackupTime: night},
+				{Name: "db-20260302"},
+			},
+			want: "db-20260301",
+		},
+		{
+			name: "the later name breaks a tie",
+			versions: []rclone.BackupVersion{
+				{Name: "db-2", BackupTime: night},
+				{Name: "db-10", BackupTime: night},
+			},
+			want: "db-2",
+		},
+		{
+			name:    "nothing found",
+			wantErr: "no backup named db or db-<version> was found",
+		},
+		{
+			name:     "no metadata at all",
+			versions: []rclone.BackupVersion{{Name: "db"}, {Name: "db-1"}},
+			wantErr:  "found 2 backups named db or db-<version>, but the metadata of none of them records",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := bucketstorage.LatestVersion("db", tt.versions)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Name)
+		})
+	}
+}
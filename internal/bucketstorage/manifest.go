@@ -0,0 +1,58 @@
+package bucketstorage
+
+import (
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+// buildManifestCommand lists the hash of every file of the backup, PVC by PVC,
+// under the path each has in the bucket, and uploads the list next to the
+// metadata.
+func buildManifestCommand(req *Request, pvcNames []string) (string, error) {
+	sources := make([]rclone.ManifestSource, 0, len(pvcNames))
+
+	for _, pvcName := range pvcNames {
+		source := rclone.ManifestSource{LocalPath: localPath(req, pvcName)}
+		if isPVCSet(req) {
+			source.Dir = pvcName
+		}
+
+		sources = append(sources, source)
+	}
+
+	manifestCmd := rclone.ManifestCmd{
+		Sources:    sources,
+		RemotePath: rclone.BuildManifestRemotePath(managedRemoteName(req), req.Bucket, req.Prefix, req.Name),
+		ConfigPath: "/etc/rclone/rclone.conf",
+		ExtraArgs:  req.RcloneExtraArgs,
+	}
+
+	return manifestCmd.Build() //nolint:wrapcheck
+}
+
+// buildManifestCheckCommand checks the objects of the backup against its
+// integrity manifest, when the verification compares hashes. Only the managed
+// layout has a manifest, and downloading every object is what a check by hash
+// costs, so a check by size leaves it out.
+func buildManifestCheckCommand(req *Request, remotePath string) (string, error) {
+	if verifyMode(req) != rclone.CheckModeChecksum || isRawRemote(req) {
+		return "", nil
+	}
+
+	manifestCheckCmd := rclone.ManifestCheckCmd{
+		ManifestPath: rclone.BuildManifestRemotePath(managedRemoteName(req), req.Bucket, req.Prefix, req.Name),
+		RemotePath:   remotePath,
+		ConfigPath:   "/etc/rclone/rclone.conf",
+		ExtraArgs:    req.RcloneExtraArgs,
+	}
+
+	return manifestCheckCmd.Build() //nolint:wrapcheck
+}
+
+func applyManifestValues(vals map[string]any, manifestCmd string) {
+	rcloneSection, ok := vals["rclone"].(map[string]any)
+	if !ok || manifestCmd == "" {
+		return
+	}
+
+	rcloneSection["manifestCommand"] = manifestCmd
+}
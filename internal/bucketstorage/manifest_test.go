@@ -0,0 +1,43 @@
+package bucketstorage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestBuildManifestCommand_Single(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{
+		Direction: rclone.DirectionBackup, PVCName: "data", Path: "sub", Bucket: "bucket", Prefix: "pv-migrate", Name: "pg",
+	}
+
+	cmd, err := bucketstorage.BuildManifestCommand(req, []string{"data"})
+	require.NoError(t, err)
+
+	assert.Contains(t, cmd, "rclone lsf --config '/etc/rclone/rclone.conf' -R --files-only --format hsp "+
+		"--hash sha256 --separator '  ' '/data/sub' ")
+	assert.Contains(t, cmd, "cat /tmp/pv-migrate-manifest.part >> ")
+	assert.Contains(t, cmd, " 'remote:bucket/pv-migrate/pg.manifest.sha256'")
+}
+
+func TestBuildManifestCommand_Set(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{
+		Direction: rclone.DirectionBackup, PVCNames: []string{"data", "wal"}, Bucket: "bucket", Name: "pg",
+	}
+
+	cmd, err := bucketstorage.BuildManifestCommand(req, req.PVCNames)
+	require.NoError(t, err)
+
+	assert.Contains(t, cmd, "'/data/data' > /tmp/pv-migrate-manifest.part && sed "+
+		`'s|^\([^ ]*  [0-9]*\)  |\1  data/|'`)
+	assert.Contains(t, cmd, "'/data/wal' > /tmp/pv-migrate-manifest.part && sed "+
+		`'s|^\([^ ]*  [0-9]*\)  |\1  wal/|'`)
+}
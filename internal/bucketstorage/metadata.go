@@ -6,22 +6,52 @@ import (
 	"time"
 
 	"go.yaml.in/yaml/v4"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
 )
 
+// metadataVersion is the version every metadata states. Fields are added within
+// a version, as sourcePvcs, format and manifest were, and a reader leaves out
+// the ones it does not know. It changes only when a field is removed or changes
+// meaning.
+const metadataVersion = 1
+
 // Metadata holds information about a backup stored alongside the data in the bucket.
 type Metadata struct {
 	Version         int       `yaml:"version"`
 	BackupTime      time.Time `yaml:"backupTime"`
 	SourceNamespace string    `yaml:"sourceNamespace"`
-	SourcePVC       string    `yaml:"sourcePvc"`
+	SourcePVC       string    `yaml:"sourcePvc,omitempty"`
+	// SourcePVCs lists the PVCs of a backup of several, each stored in a
+	// directory named after it. SourcePVC is empty then.
+	SourcePVCs []string `yaml:"sourcePvcs,omitempty"`
+	// Format is rclone.ArchiveFormat for a backup of one tarball per PVC, and
+	// empty for a tree of files.
+	Format string `yaml:"format,omitempty"`
+	// Manifest names the integrity manifest next to the metadata, which lists the
+	// SHA-256 and the size of every file of the backup. Backups taken without one leave it out.
+	Manifest string `yaml:"manifest,omitempty"`
 }
 
-func generateMetadataBase64(namespace, pvcName string) (string, error) {
+func generateMetadataBase64(namespace string, req *Request, pvcNames []string) (string, error) {
 	meta := Metadata{
-		Version:         1,
+		Version:         metadataVersion,
 		BackupTime:      time.Now().UTC(),
 		SourceNamespace: namespace,
-		SourcePVC:       pvcName,
+	}
+
+	if req.Archive {
+		meta.Format = rclone.ArchiveFormat
+	}
+
+	if !req.NoManifest {
+		meta.Manifest = req.Name + rclone.ManifestSuffix
+	}
+
+	if isPVCSet(req) {
+		meta.SourcePVCs = pvcNames
+	} else {
+		meta.SourcePVC = req.PVCName
 	}
 
 	data, err := yaml.Marshal(meta)
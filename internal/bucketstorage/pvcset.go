@@ -0,0 +1,242 @@
+package bucketstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utkuozdemir/pv-migrate/internal/pvc"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+// isPVCSet reports whether the operation covers a set of PVCs rather than the
+// single PVCName. A set keeps each PVC in a directory of its own, locally and in
+// the bucket, even when the selector matches only one.
+func isPVCSet(req *Request) bool {
+	return len(req.PVCNames) > 0 || req.PVCSelector != ""
+}
+
+func validatePVCSet(req *Request) error {
+	if !isPVCSet(req) {
+		return nil
+	}
+
+	if req.PVCName != "" {
+		return errors.New("a single PVC cannot be combined with a set of PVCs")
+	}
+
+	if len(req.PVCNames) > 0 && req.PVCSelector != "" {
+		return errors.New("PVC names and a PVC selector cannot be used together")
+	}
+
+	if req.Path != "" {
+		return errors.New("--path cannot be used with several PVCs")
+	}
+
+	if req.SourcePath != "" {
+		return errors.New("--source-path cannot be used with several PVCs")
+	}
+
+	if req.PVCSelector != "" {
+		if _, err := labels.Parse(req.PVCSelector); err != nil {
+			return fmt.Errorf("invalid PVC selector %q: %w", req.PVCSelector, err)
+		}
+
+		return nil
+	}
+
+	for i, name := range req.PVCNames {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("PVC name %q is invalid: %s", name, strings.Join(errs, "; "))
+		}
+
+		if slices.Contains(req.PVCNames[:i], name) {
+			return fmt.Errorf("PVC %q is given more than once", name)
+		}
+	}
+
+	return nil
+}
+
+// resolvePVCNames returns the PVCs of the operation, in the order they are
+// transferred.
+func resolvePVCNames(ctx context.Context, cli kubernetes.Interface, ns string, req *Request) ([]string, error) {
+	switch {
+	case req.PVCSelector != "":
+		list, err := cli.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: req.PVCSelector,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PVCs: %w", err)
+		}
+
+		if len(list.Items) == 0 {
+			return nil, fmt.Errorf("no PVCs in namespace %s match the selector %q", ns, req.PVCSelector)
+		}
+
+		names := make([]string, 0, len(list.Items))
+		for _, claim := range list.Items {
+			names = append(names, claim.Name)
+		}
+
+		slices.Sort(names)
+
+		return names, nil
+	case len(req.PVCNames) > 0:
+		return req.PVCNames, nil
+	default:
+		return []string{req.PVCName}, nil
+	}
+}
+
+// localPath returns where the rclone pod mounts the PVC, with --path applied.
+func localPath(req *Request, pvcName string) string {
+	if isPVCSet(req) {
+		return path.Join(dataMountPath, pvcName)
+	}
+
+	if req.Path != "" {
+		return path.Join(dataMountPath, req.Path)
+	}
+
+	return dataMountPath
+}
+
+// pvcRemotePath returns where the PVC's data is stored: the remote path itself
+// for a single PVC, or a directory named after the PVC beneath it for a set. A
+// restore from a source path inside the backup reads a directory beneath that.
+func pvcRemotePath(req *Request, remotePath, pvcName string) string {
+	if isPVCSet(req) {
+		return joinRemotePath(remotePath, pvcName)
+	}
+
+	if sourcePath := path.Clean(req.SourcePath); req.SourcePath != "" && sourcePath != "." {
+		return joinRemotePath(remotePath, sourcePath)
+	}
+
+	return remotePath
+}
+
+// joinRemotePath puts a directory beneath a remote path. A raw remote can name
+// the root of its remote, as in "myremote:", where a slash would make the
+// directory absolute.
+func joinRemotePath(remotePath, dir string) string {
+	if strings.HasSuffix(remotePath, ":") {
+		return remotePath + dir + "/"
+	}
+
+	return strings.TrimSuffix(remotePath, "/") + "/" + dir + "/"
+}
+
+// buildRcloneCommand chains one rclone transfer per PVC. The chart's retry loop
+// runs the chain as a whole, which is safe as a repeated transfer only copies
+// what the previous attempt missed.
+func buildRcloneCommand(req *Request, remotePath string, pvcNames []string) (string, error) {
+	cmds := make([]string, 0, len(pvcNames))
+
+	for _, pvcName := range pvcNames {
+		rcloneCmd := rclone.Cmd{
+			Direction:  req.Direction,
+			RemotePath: pvcRemotePath(req, remotePath, pvcName),
+			LocalPath:  localPath(req, pvcName),
+			ConfigPath: "/etc/rclone/rclone.conf",
+			Filter:     req.Filter,
+			BWLimit:    req.BWLimit,
+			ExtraArgs:  req.RcloneExtraArgs,
+			Delete:     req.DeleteExtraneousFiles,
+
+			Archive:       req.Archive,
+			DetectArchive: detectsArchive(req),
+		}
+
+		cmdStr, err := rcloneCmd.Build()
+		if err != nil {
+			return "", err //nolint:wrapcheck
+		}
+
+		cmds = append(cmds, cmdStr)
+	}
+
+	return strings.Join(cmds, " && "), nil
+}
+
+// checkMountedNodes rejects a set whose PVCs are mounted on different nodes when
+// one of them can only be attached to its node, since a single rclone pod has to
+// mount them all.
+func checkMountedNodes(infos []*pvc.Info) error {
+	var nodes []string
+
+	pinned := false
+
+	for _, info := range infos {
+		if info.MountedNode == "" {
+			continue
+		}
+
+		if !slices.Contains(nodes, info.MountedNode) {
+			nodes = append(nodes, info.MountedNode)
+		}
+
+		pinned = pinned || requiresMountedNode(info)
+	}
+
+	if len(nodes) > 1 && pinned {
+		return fmt.Errorf("the PVCs are mounted on different nodes (%s), "+
+			"but one pod has to mount them all", strings.Join(nodes, ", "))
+	}
+
+	return nil
+}
+
+// combinedAffinity returns the affinity of the PVC that constrains the pod the
+// most: one that can only be attached to its node, or else any that is mounted.
+func combinedAffinity(infos []*pvc.Info) map[string]any {
+	var affinity map[string]any
+
+	for _, info := range infos {
+		if info.AffinityHelmValues == nil {
+			continue
+		}
+
+		if requiresMountedNode(info) {
+			return info.AffinityHelmValues
+		}
+
+		if affinity == nil {
+			affinity = info.AffinityHelmValues
+		}
+	}
+
+	return affinity
+}
+
+func requiresMountedNode(info *pvc.Info) bool {
+	return info.MountedNode != "" && !info.SupportsRWX && !info.SupportsROX
+}
+
+func pvcMounts(req *Request, infos []*pvc.Info, readOnly bool) []map[string]any {
+	mounts := make([]map[string]any, 0, len(infos))
+
+	for _, info := range infos {
+		mountPath := dataMountPath
+		if isPVCSet(req) {
+			mountPath = path.Join(dataMountPath, info.Claim.Name)
+		}
+
+		mounts = append(mounts, map[string]any{
+			"name":      info.Claim.Name,
+			"mountPath": mountPath,
+			"readOnly":  readOnly,
+		})
+	}
+
+	return mounts
+}
@@ -0,0 +1,192 @@
+package bucketstorage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/pvc"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestValidatePVCSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     bucketstorage.Request
+		wantErr string
+	}{
+		{name: "single", req: bucketstorage.Request{PVCName: "data", Path: "sub"}},
+		{name: "names", req: bucketstorage.Request{PVCNames: []string{"data", "wal"}}},
+		{name: "selector", req: bucketstorage.Request{PVCSelector: "app=db,tier in (primary)"}},
+		{
+			name:    "single and set",
+			req:     bucketstorage.Request{PVCName: "data", PVCNames: []string{"wal"}},
+			wantErr: "a single PVC cannot be combined with a set of PVCs",
+		},
+		{
+			name:    "names and selector",
+			req:     bucketstorage.Request{PVCNames: []string{"data"}, PVCSelector: "app=db"},
+			wantErr: "PVC names and a PVC selector cannot be used together",
+		},
+		{
+			name:    "path",
+			req:     bucketstorage.Request{PVCNames: []string{"data", "wal"}, Path: "sub"},
+			wantErr: "--path cannot be used with several PVCs",
+		},
+		{
+			name:    "source path",
+			req:     bucketstorage.Request{PVCNames: []string{"data", "wal"}, SourcePath: "sub"},
+			wantErr: "--source-path cannot be used with several PVCs",
+		},
+		{
+			name:    "duplicate",
+			req:     bucketstorage.Request{PVCNames: []string{"data", "wal", "data"}},
+			wantErr: `PVC "data" is given more than once`,
+		},
+		{
+			name:    "invalid name",
+			req:     bucketstorage.Request{PVCNames: []string{"../data"}},
+			wantErr: `PVC name "../data" is invalid`,
+		},
+		{
+			name:    "invalid selector",
+			req:     bucketstorage.Request{PVCSelector: "app in"},
+			wantErr: `invalid PVC selector "app in"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := bucketstorage.ValidatePVCSet(&tt.req)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestResolvePVCNames_Selector(t *testing.T) {
+	t.Parallel()
+
+	claim := func(name string, labels map[string]string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db", Labels: labels},
+		}
+	}
+
+	cli := fake.NewClientset(
+		claim("wal", map[string]string{"app": "pg"}),
+		claim("data", map[string]string{"app": "pg"}),
+		claim("cache", map[string]string{"app": "redis"}),
+	)
+
+	names, err := bucketstorage.ResolvePVCNames(t.Context(), cli, "db", &bucketstorage.Request{PVCSelector: "app=pg"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data", "wal"}, names)
+
+	_, err = bucketstorage.ResolvePVCNames(t.Context(), cli, "db", &bucketstorage.Request{PVCSelector: "app=mysql"})
+	require.ErrorContains(t, err, `no PVCs in namespace db match the selector "app=mysql"`)
+}
+
+func TestBuildRcloneCommand_Set(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{Direction: rclone.DirectionRestore, PVCNames: []string{"data", "wal"}}
+
+	cmd, err := bucketstorage.BuildRcloneCommand(req, "remote:bucket/pv-migrate/pg/", req.PVCNames)
+	require.NoError(t, err)
+
+	assert.Contains(t, cmd, "'remote:bucket/pv-migrate/pg/data/' '/data/data'; fi && if ")
+	assert.Contains(t, cmd, "'remote:bucket/pv-migrate/pg/wal/' '/data/wal'")
+}
+
+func TestBuildRcloneCommand_Single(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{Direction: rclone.DirectionBackup, PVCName: "data", Path: "sub"}
+
+	cmd, err := bucketstorage.BuildRcloneCommand(req, "remote:bucket/pv-migrate/pg/", []string{"data"})
+	require.NoError(t, err)
+
+	assert.Contains(t, cmd, " '/data/sub' 'remote:bucket/pv-migrate/pg/'")
+	assert.NotContains(t, cmd, "&&")
+}
+
+func TestBuildRcloneCommand_SourcePath(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{
+		Direction:  rclone.DirectionRestore,
+		PVCName:    "data",
+		Path:       "restored",
+		SourcePath: "db/./tables/",
+		Filter:     rclone.Filter{Include: []string{"*.{dat,idx}"}},
+	}
+
+	cmd, err := bucketstorage.BuildRcloneCommand(req, "remote:bucket/pv-migrate/pg/", []string{"data"})
+	require.NoError(t, err)
+	assert.Contains(t, cmd, " 'remote:bucket/pv-migrate/pg/db/tables/' '/data/restored' --include '*.{dat,idx}'")
+
+	// A raw remote naming the root of its remote stays relative to it.
+	cmd, err = bucketstorage.BuildRcloneCommand(req, "myremote:", []string{"data"})
+	require.NoError(t, err)
+	assert.Contains(t, cmd, " 'myremote:db/tables/' '/data/restored'")
+
+	req.SourcePath = "."
+
+	cmd, err = bucketstorage.BuildRcloneCommand(req, "remote:bucket/pv-migrate/pg/", []string{"data"})
+	require.NoError(t, err)
+	assert.Contains(t, cmd, " 'remote:bucket/pv-migrate/pg/' '/data/restored'")
+}
+
+func TestCheckMountedNodes(t *testing.T) {
+	t.Parallel()
+
+	mounted := func(name, node string, rwx bool) *pvc.Info {
+		info := testPVCInfo(name)
+		info.MountedNode = node
+		info.SupportsRWX = rwx
+
+		return info
+	}
+
+	require.NoError(t, bucketstorage.CheckMountedNodes([]*pvc.Info{
+		mounted("data", "node-a", false), mounted("wal", "node-a", false), mounted("tmp", "", false),
+	}))
+
+	require.NoError(t, bucketstorage.CheckMountedNodes([]*pvc.Info{
+		mounted("data", "node-a", true), mounted("wal", "node-b", true),
+	}))
+
+	err := bucketstorage.CheckMountedNodes([]*pvc.Info{
+		mounted("data", "node-a", false), mounted("wal", "node-b", true),
+	})
+	require.ErrorContains(t, err, "the PVCs are mounted on different nodes (node-a, node-b)")
+}
+
+func TestBuildHelmValues_SetMountsEachPVC(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{Direction: rclone.DirectionBackup, PVCNames: []string{"data", "wal"}}
+
+	got := bucketstorage.BuildHelmValues("db", req, testPVCInfos("data", "wal"), "conf", "cmd", true, "", "")
+	rcloneVals := got["rclone"].(map[string]any) //nolint:forcetypeassert
+
+	assert.Equal(t, []map[string]any{
+		{"name": "data", "mountPath": "/data/data", "readOnly": true},
+		{"name": "wal", "mountPath": "/data/wal", "readOnly": true},
+	}, rcloneVals["pvcMounts"])
+}
@@ -0,0 +1,176 @@
+package bucketstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+// verifies reports whether the job checks the volume against the backup, either
+// after a restore or as the whole operation.
+func verifies(req *Request) bool {
+	return req.Verify || req.Direction == rclone.DirectionVerify
+}
+
+func validateVerify(req *Request) error {
+	if !verifies(req) {
+		return nil
+	}
+
+	if req.Direction == rclone.DirectionBackup {
+		return errors.New("verification is only supported for restores")
+	}
+
+	if !slices.Contains(rclone.CheckModes, verifyMode(req)) {
+		return fmt.Errorf("invalid verification mode: %q, must be one of %s",
+			req.VerifyMode, strings.Join(rclone.CheckModes, ", "))
+	}
+
+	if req.Verify && hasRcloneDryRun(req.RcloneExtraArgs) {
+		return errors.New("a restore cannot be verified when rclone only does a dry run")
+	}
+
+	return nil
+}
+
+// verifyMode returns the mode of the check, by size unless set.
+func verifyMode(req *Request) string {
+	if req.VerifyMode == "" {
+		return rclone.CheckModeSize
+	}
+
+	return req.VerifyMode
+}
+
+// buildVerifyCommand chains one rclone check per PVC. Every PVC is checked, even
+// after one of them differs, so the report covers the whole set. Files the
+// volume has on top of the backup only count as differences when a restore
+// deleted them, since otherwise nothing was meant to remove them.
+func buildVerifyCommand(req *Request, remotePath string, pvcNames []string) (string, error) {
+	if !verifies(req) {
+		return "", nil
+	}
+
+	cmds := make([]string, 0, len(pvcNames))
+
+	for _, pvcName := range pvcNames {
+		var reportPrefix string
+		if isPVCSet(req) {
+			reportPrefix = pvcName + "/"
+		}
+
+		checkCmd := rclone.CheckCmd{
+			RemotePath:   pvcRemotePath(req, remotePath, pvcName),
+			LocalPath:    localPath(req, pvcName),
+			ConfigPath:   "/etc/rclone/rclone.conf",
+			Mode:         verifyMode(req),
+			OneWay:       req.Direction == rclone.DirectionVerify || !req.DeleteExtraneousFiles,
+			ReportPrefix: reportPrefix,
+			Filter:       req.Filter,
+			ExtraArgs:    req.RcloneExtraArgs,
+		}
+
+		cmdStr, err := checkCmd.Build()
+		if err != nil {
+			return "", err //nolint:wrapcheck
+		}
+
+		cmds = append(cmds, "{ "+cmdStr+"; } || verify_rc=1")
+	}
+
+	manifestCheck, err := buildManifestCheckCommand(req, remotePath)
+	if err != nil {
+		return "", err
+	}
+
+	if manifestCheck != "" {
+		cmds = append(cmds, "{ "+manifestCheck+"; } || verify_rc=1")
+	}
+
+	return "verify_rc=0; " + strings.Join(cmds, "; ") + `; [ "$verify_rc" -eq 0 ]`, nil
+}
+
+// verificationError is a job failure that the check caused, along with what it
+// found.
+type verificationError struct {
+	report rclone.CheckReport
+	err    error
+}
+
+func (e *verificationError) Error() string {
+	return fmt.Sprintf("verification failed: %d %s between the backup and the volume\n%s",
+		e.report.Total, pluralDifferences(e.report.Total), e.err)
+}
+
+func (e *verificationError) Unwrap() error {
+	return e.err
+}
+
+func pluralDifferences(count int) string {
+	if count == 1 {
+		return "difference"
+	}
+
+	return "differences"
+}
+
+// checkVerification reads the check's report off the finished job's log. A
+// failed job whose report lists differences fails because of them, so its error
+// is replaced by one that carries them; any other failure is returned as it is.
+func checkVerification(
+	ctx context.Context,
+	req *Request,
+	kubeClient kubernetes.Interface,
+	namespace, jobName string,
+	jobErr error,
+	logger *slog.Logger,
+) error {
+	report := rclone.ParseCheckReport(k8s.JobReportLog(ctx, kubeClient, namespace, jobName, logger))
+
+	if jobErr == nil {
+		logger.Info("✅ Verification passed", "mode", verifyMode(req))
+
+		return nil
+	}
+
+	if report.Total == 0 {
+		return jobErr
+	}
+
+	return &verificationError{report: report, err: jobErr}
+}
+
+// writeDifferences lists the paths a failed verification reported, after the
+// cause in the failure block.
+func writeDifferences(w io.Writer, report rclone.CheckReport, palette console.Palette) {
+	fmt.Fprintf(w, "\n%s\n", palette.Bold("Differences:"))
+
+	for _, difference := range report.Differences {
+		fmt.Fprintf(w, "  %s (%s)\n", difference.Path, difference.Kind)
+	}
+
+	if omitted := report.Total - len(report.Differences); omitted > 0 {
+		fmt.Fprintf(w, "  %s\n", palette.Dim(fmt.Sprintf("... and %d more", omitted)))
+	}
+}
+
+// differencesAttr renders the reported paths for a structured log record.
+func differencesAttr(report rclone.CheckReport) []string {
+	differences := make([]string, 0, len(report.Differences))
+
+	for _, difference := range report.Differences {
+		differences = append(differences, difference.Path+" ("+difference.Kind+")")
+	}
+
+	return differences
+}
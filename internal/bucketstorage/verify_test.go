@@ -0,0 +1,144 @@
+package bucketstorage_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestValidateVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     bucketstorage.Request
+		wantErr string
+	}{
+		{name: "not verifying", req: bucketstorage.Request{Direction: rclone.DirectionBackup}},
+		{name: "restore", req: bucketstorage.Request{Direction: rclone.DirectionRestore, Verify: true}},
+		{
+			name: "verify by checksum",
+			req:  bucketstorage.Request{Direction: rclone.DirectionVerify, VerifyMode: rclone.CheckModeChecksum},
+		},
+		{
+			name:    "backup",
+			req:     bucketstorage.Request{Direction: rclone.DirectionBackup, Verify: true},
+			wantErr: "verification is only supported for restores",
+		},
+		{
+			name:    "invalid mode",
+			req:     bucketstorage.Request{Direction: rclone.DirectionVerify, VerifyMode: "bytes"},
+			wantErr: `invalid verification mode: "bytes"`,
+		},
+		{
+			name: "dry run",
+			req: bucketstorage.Request{
+				Direction: rclone.DirectionRestore, Verify: true, RcloneExtraArgs: "-nv",
+			},
+			wantErr: "a restore cannot be verified when rclone only does a dry run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := bucketstorage.ValidateVerify(&tt.req)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestBuildVerifyCommand(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{Direction: rclone.DirectionRestore}
+
+	cmd, err := bucketstorage.BuildVerifyCommand(req, "remote:bucket/pv-migrate/db/", []string{"data"})
+	require.NoError(t, err)
+	assert.Empty(t, cmd, "a restore without --verify checks nothing")
+
+	req.Verify = true
+	req.Path = "sub"
+
+	cmd, err = bucketstorage.BuildVerifyCommand(req, "remote:bucket/pv-migrate/db/", []string{"data"})
+	require.NoError(t, err)
+	assert.Contains(t, cmd, "--size-only --one-way 'remote:bucket/pv-migrate/db/' '/data/sub'")
+	assert.Contains(t, cmd, `s|^\(.\) |pv-migrate check: \1 |`)
+
+	req.DeleteExtraneousFiles = true
+
+	cmd, err = bucketstorage.BuildVerifyCommand(req, "remote:bucket/pv-migrate/db/", []string{"data"})
+	require.NoError(t, err)
+	assert.NotContains(t, cmd, "--one-way", "a restore that deletes is checked both ways")
+	assert.NotContains(t, cmd, "rclone checksum", "a check by size does not download the backup")
+}
+
+func TestBuildVerifyCommand_Set(t *testing.T) {
+	t.Parallel()
+
+	req := &bucketstorage.Request{
+		Direction:             rclone.DirectionVerify,
+		PVCNames:              []string{"data", "wal"},
+		VerifyMode:            rclone.CheckModeChecksum,
+		DeleteExtraneousFiles: true,
+		Bucket:                "bucket",
+		Prefix:                "pv-migrate",
+		Name:                  "db",
+	}
+
+	cmd, err := bucketstorage.BuildVerifyCommand(req, "remote:bucket/pv-migrate/db/", req.PVCNames)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(cmd, "verify_rc=0; { rclone check"))
+	assert.Contains(t, cmd, "--one-way 'remote:bucket/pv-migrate/db/data/' '/data/data'")
+	assert.Contains(t, cmd, "--one-way 'remote:bucket/pv-migrate/db/wal/' '/data/wal'")
+	assert.Contains(t, cmd, `\1 wal/|`)
+	assert.NotContains(t, cmd, "--size-only")
+	assert.Contains(t, cmd, "rclone copyto --config '/etc/rclone/rclone.conf' "+
+		"'remote:bucket/pv-migrate/db.manifest.sha256' /tmp/pv-migrate-manifest.sha256")
+	assert.Contains(t, cmd, "/tmp/pv-migrate-manifest.sum 'remote:bucket/pv-migrate/db/'",
+		"the bucket is checked against the manifest from the root of the backup")
+	assert.Equal(t, 3, strings.Count(cmd, "|| verify_rc=1"), "every PVC is checked, and then the manifest")
+	assert.True(t, strings.HasSuffix(cmd, `; [ "$verify_rc" -eq 0 ]`))
+}
+
+func TestVerificationError(t *testing.T) {
+	t.Parallel()
+
+	jobErr := errors.New("pod ns/pv-migrate-abc-restore-rclone-x failed: the data mover exited with code 1")
+	report := rclone.CheckReport{
+		Total: 3,
+		Differences: []rclone.CheckDifference{
+			{Kind: rclone.DifferenceContent, Path: "db/changed.txt"},
+			{Kind: rclone.DifferenceMissing, Path: "gone.txt"},
+		},
+	}
+
+	err := bucketstorage.NewVerificationError(report, jobErr)
+	require.ErrorIs(t, err, jobErr)
+	assert.Equal(t, "verification failed: 3 differences between the backup and the volume\n"+jobErr.Error(),
+		err.Error())
+
+	var buf bytes.Buffer
+
+	bucketstorage.WriteDifferences(&buf, report, console.Palette{})
+	assert.Equal(t, "\nDifferences:\n"+
+		"  db/changed.txt (differs)\n"+
+		"  gone.txt (missing from the volume)\n"+
+		"  ... and 1 more\n", buf.String())
+}
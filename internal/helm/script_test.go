@@ -201,6 +201,45 @@ func TestRcloneScriptRetriesUsageErrors(t *testing.T) {
 	assert.Equal(t, 2, countLines(t, counter))
 }
 
+// TestRcloneScriptVerifiesOnceAfterTheTransfer pins that the check runs once,
+// only after a transfer that succeeded, and that its failure is the job's.
+func TestRcloneScriptVerifiesOnceAfterTheTransfer(t *testing.T) {
+	t.Parallel()
+
+	counter := filepath.Join(t.TempDir(), "checks")
+	check := fmt.Sprintf("sh -c 'echo x >> %s; exit 1'", counter)
+
+	code, out := runScript(t, rcloneScript(t, map[string]any{
+		"command":       exitingMover(0),
+		"verifyCommand": check,
+		"maxRetries":    2,
+	}))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "verification failed with exit code 1")
+	assert.Equal(t, 1, countLines(t, counter), "differences are not retried")
+
+	code, out = runScript(t, rcloneScript(t, map[string]any{
+		"command":       exitingMover(3),
+		"verifyCommand": check,
+	}))
+	assert.Equal(t, 3, code)
+	assert.Contains(t, out, "rclone job failed with exit code 3")
+	assert.Equal(t, 1, countLines(t, counter), "a failed transfer is not checked")
+}
+
+// TestRcloneScriptVerifiesWithoutATransfer covers the verify job, which has a
+// check and no transfer.
+func TestRcloneScriptVerifiesWithoutATransfer(t *testing.T) {
+	t.Parallel()
+
+	code, _ := runScript(t, rcloneScript(t, map[string]any{"verifyCommand": exitingMover(0)}))
+	assert.Equal(t, 0, code)
+
+	code, out := runScript(t, rcloneScript(t, map[string]any{"verifyCommand": exitingMover(1)}))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "verification failed with exit code 1")
+}
+
 // failingRcloneDir returns a directory holding an rclone that always fails with
 // the given code, to be put in front of PATH.
 func failingRcloneDir(t *testing.T, code int) string {
@@ -0,0 +1,3 @@
+package hook
+
+const MaxOutput = maxOutput
@@ -0,0 +1,379 @@
+// Package hook runs commands in the pods of an application around a transfer of
+// its volume, such as pg_backup_start or fsfreeze before the copy and the reverse
+// after it.
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+)
+
+// The points of an operation a hook runs at.
+const (
+	PhasePre  = "pre"
+	PhasePost = "post"
+)
+
+// The ways an operation treats a failed hook: fail it, or log the failure and
+// carry on.
+const (
+	OnErrorFail     = "fail"
+	OnErrorContinue = "continue"
+)
+
+// OnErrorPolicies lists the failure policies, in the order the CLI presents them.
+var OnErrorPolicies = []string{OnErrorFail, OnErrorContinue}
+
+// DefaultTimeout bounds each run of a hook when Config.Timeout is unset.
+const DefaultTimeout = 5 * time.Minute
+
+// maxOutput bounds what is kept of the output of one run. The end is kept, since
+// that is where a failing command explains itself.
+const maxOutput = 4096
+
+// Config describes the hooks of an operation.
+type Config struct {
+	// Pre runs before the data mover is installed, and Post once it finishes,
+	// whatever the outcome. Each is run with sh -c, so the container needs a shell.
+	Pre  string
+	Post string
+
+	// Selector picks the pods to run the hooks in, and Container the container in
+	// each of them. The container can be left out for pods that have only one.
+	Selector  string
+	Container string
+
+	// OnError is OnErrorFail, the default, or OnErrorContinue.
+	OnError string
+	Timeout time.Duration
+}
+
+// IsEmpty reports whether there is no hook to run.
+func (c *Config) IsEmpty() bool {
+	return c.Pre == "" && c.Post == ""
+}
+
+// Validate checks the config before anything is deployed.
+func (c *Config) Validate() error {
+	if c.IsEmpty() {
+		if c.Selector != "" || c.Container != "" {
+			return errors.New("--hook-selector and --hook-container need --pre-hook or --post-hook")
+		}
+
+		return nil
+	}
+
+	if c.Selector == "" {
+		return errors.New("--hook-selector is required with --pre-hook or --post-hook")
+	}
+
+	if _, err := labels.Parse(c.Selector); err != nil {
+		return fmt.Errorf("invalid --hook-selector: %w", err)
+	}
+
+	if c.OnError != "" && !slices.Contains(OnErrorPolicies, c.OnError) {
+		return fmt.Errorf("invalid --hook-on-error: %q, must be one of %s",
+			c.OnError, strings.Join(OnErrorPolicies, ", "))
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("--hook-timeout cannot be negative")
+	}
+
+	return nil
+}
+
+// Result is one run of a hook in one pod.
+type Result struct {
+	Phase  string
+	Pod    string
+	Output string
+	Err    error
+}
+
+// ExecFunc runs a command in a pod, as k8s.Exec does.
+type ExecFunc func(ctx context.Context, client *k8s.ClusterClient, req *k8s.ExecRequest, out io.Writer) error
+
+// Runner runs the hooks of one operation and keeps what they printed, for the
+// failure block of the operation. A nil Runner runs nothing.
+type Runner struct {
+	config    Config
+	client    *k8s.ClusterClient
+	namespace string
+	logger    *slog.Logger
+	exec      ExecFunc
+	results   []Result
+
+	// preRan is set once the pre hook has run, and quiesced then holds the pods
+	// it succeeded in, the ones the post hook has to release.
+	preRan   bool
+	quiesced []string
+}
+
+// NewRunner returns a runner for the hooks of config, which run in the pods of
+// namespace, or nil when config holds no hook.
+func NewRunner(config Config, client *k8s.ClusterClient, namespace string, logger *slog.Logger) *Runner {
+	if config.IsEmpty() {
+		return nil
+	}
+
+	return &Runner{
+		config:    config,
+		client:    client,
+		namespace: namespace,
+		logger:    logger,
+		exec:      k8s.Exec,
+	}
+}
+
+// WithExec replaces the way the runner runs a command in a pod, such as with a
+// stand-in for a cluster in tests. A nil exec keeps k8s.Exec.
+func (r *Runner) WithExec(exec ExecFunc) *Runner {
+	if r != nil && exec != nil {
+		r.exec = exec
+	}
+
+	return r
+}
+
+// Run runs the hook of phase in every running pod the selector matches. It
+// returns an error only when the hook fails and the policy is to fail.
+//
+// Once a pre hook has run, the post hook runs in the pods it succeeded in
+// instead, so that a pre hook that fails in one pod still has the others
+// released, and a pod that was not quiesced is left alone.
+//
+// The post hook undoes what the pre hook did, so it runs even when ctx is
+// already cancelled, such as after a Ctrl-C, bounded by the hook timeout
+// instead. Leaving the application quiesced is what hooks are there to avoid.
+func (r *Runner) Run(ctx context.Context, phase string) error {
+	if r == nil {
+		return nil
+	}
+
+	if phase == PhasePost {
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	command := r.config.Pre
+	if phase == PhasePost {
+		command = r.config.Post
+	}
+
+	if command == "" {
+		return nil
+	}
+
+	var podNames []string
+
+	if phase == PhasePost && r.preRan {
+		if len(r.quiesced) == 0 {
+			return nil
+		}
+
+		podNames = r.quiesced
+	} else {
+		// Marked before listing the pods, so that a pre hook that cannot list
+		// them leaves the post hook nothing to release.
+		if phase == PhasePre {
+			r.preRan = true
+		}
+
+		var err error
+		if podNames, err = r.runningPodNames(ctx); err != nil {
+			return r.fail(phase, err)
+		}
+	}
+
+	var errs []error
+
+	for _, podName := range podNames {
+		r.logger.Info("🔧 Running hook", "phase", phase, "pod", r.namespace+"/"+podName)
+
+		result := r.runInPod(ctx, phase, command, podName)
+		r.results = append(r.results, result)
+
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("pod %s: %w", podName, result.Err))
+
+			continue
+		}
+
+		if phase == PhasePre {
+			r.quiesced = append(r.quiesced, podName)
+		}
+	}
+
+	if len(errs) > 0 {
+		return r.fail(phase, errors.Join(errs...))
+	}
+
+	r.logger.Info("✅ Hook succeeded", "phase", phase)
+
+	return nil
+}
+
+func (r *Runner) runningPodNames(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout())
+	defer cancel()
+
+	pods, err := k8s.RunningPods(ctx, r.client.KubeClient, r.namespace, r.config.Selector)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no running pod in namespace %s matches %q", r.namespace, r.config.Selector)
+	}
+
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+
+	return names, nil
+}
+
+func (r *Runner) runInPod(ctx context.Context, phase, command, podName string) Result {
+	timeout := r.timeout()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var output tailBuffer
+
+	err := r.exec(ctx, r.client, &k8s.ExecRequest{
+		Namespace: r.namespace,
+		PodName:   podName,
+		Container: r.config.Container,
+		Command:   []string{"sh", "-c", command},
+	}, &output)
+
+	var exitErr utilexec.ExitError
+
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		err = fmt.Errorf("exited with code %d", exitErr.ExitStatus())
+	default:
+		err = fmt.Errorf("failed to run: %w", err)
+	}
+
+	return Result{Phase: phase, Pod: podName, Output: output.String(), Err: err}
+}
+
+func (r *Runner) timeout() time.Duration {
+	if r.config.Timeout == 0 {
+		return DefaultTimeout
+	}
+
+	return r.config.Timeout
+}
+
+func (r *Runner) fail(phase string, err error) error {
+	err = fmt.Errorf("%s hook failed: %w", phase, err)
+
+	if r.config.OnError == OnErrorContinue {
+		r.logger.Warn("🔶 Hook failed, continuing as --hook-on-error is continue", "phase", phase, "error", err)
+
+		return nil
+	}
+
+	return err
+}
+
+// Results returns the runs so far, in the order they happened.
+func (r *Runner) Results() []Result {
+	if r == nil {
+		return nil
+	}
+
+	return r.results
+}
+
+// Report renders the runs so far as a block for the failure report of the
+// operation, with what each printed indented beneath it. It is empty when no
+// hook ran.
+func (r *Runner) Report(palette console.Palette) string {
+	results := r.Results()
+	if len(results) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%s\n\n", palette.Bold("What the hooks printed:"))
+
+	for _, result := range results {
+		outcome := palette.Good("succeeded")
+		if result.Err != nil {
+			outcome = palette.Bad(result.Err.Error())
+		}
+
+		fmt.Fprintf(&builder, "  %s hook in pod %s/%s: %s\n", result.Phase, r.namespace, result.Pod, outcome)
+
+		output := strings.TrimRight(result.Output, "\n")
+		if output == "" {
+			fmt.Fprintf(&builder, "    %s\n", palette.Dim("(no output)"))
+
+			continue
+		}
+
+		for line := range strings.SplitSeq(output, "\n") {
+			fmt.Fprintf(&builder, "    %s\n", line)
+		}
+	}
+
+	builder.WriteString("\n")
+
+	return builder.String()
+}
+
+// tailBuffer keeps the last maxOutput bytes written to it. It takes both stdout
+// and stderr of the command, which the exec streams copy from goroutines of their
+// own, so it is safe for concurrent use.
+type tailBuffer struct {
+	mu        sync.Mutex
+	data      []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, p...)
+
+	if excess := len(b.data) - maxOutput; excess > 0 {
+		b.data = b.data[excess:]
+		b.truncated = true
+	}
+
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.truncated {
+		return "...\n" + string(b.data)
+	}
+
+	return string(b.data)
+}
@@ -0,0 +1,311 @@
+package hook_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neilotoole/slogt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+)
+
+const namespace = "app"
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		config  hook.Config
+		wantErr string
+	}{
+		{name: "empty", config: hook.Config{}},
+		{name: "pre hook", config: hook.Config{Pre: "sync", Selector: "app=db"}},
+		{
+			name:   "post hook with policy",
+			config: hook.Config{Post: "sync", Selector: "app=db", OnError: hook.OnErrorContinue},
+		},
+		{name: "no selector", config: hook.Config{Pre: "sync"}, wantErr: "--hook-selector is required"},
+		{name: "selector without hook", config: hook.Config{Selector: "app=db"}, wantErr: "need --pre-hook"},
+		{name: "bad selector", config: hook.Config{Pre: "sync", Selector: "app==="}, wantErr: "invalid --hook-selector"},
+		{
+			name:    "bad policy",
+			config:  hook.Config{Pre: "sync", Selector: "app=db", OnError: "ignore"},
+			wantErr: "invalid --hook-on-error",
+		},
+		{
+			name:    "negative timeout",
+			config:  hook.Config{Pre: "sync", Selector: "app=db", Timeout: -time.Second},
+			wantErr: "cannot be negative",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.config.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestNewRunnerWithoutHooks(t *testing.T) {
+	t.Parallel()
+
+	runner := hook.NewRunner(hook.Config{}, nil, namespace, slogt.New(t))
+	require.Nil(t, runner)
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePre))
+	assert.Empty(t, runner.Report(console.Palette{}))
+}
+
+func TestRunRunsInEveryRunningPod(t *testing.T) {
+	t.Parallel()
+
+	var ran []string
+
+	runner := newRunner(t, hook.Config{Pre: "psql -c 'select 1'", Selector: "app=db", Container: "postgres"},
+		func(_ context.Context, _ *k8s.ClusterClient, req *k8s.ExecRequest, out io.Writer) error {
+			assert.Equal(t, []string{"sh", "-c", "psql -c 'select 1'"}, req.Command)
+			assert.Equal(t, "postgres", req.Container)
+
+			ran = append(ran, req.PodName)
+			fmt.Fprintf(out, "ok from %s\n", req.PodName)
+
+			return nil
+		})
+
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePre))
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePost))
+
+	assert.Equal(t, []string{"db-0", "db-1"}, ran)
+
+	report := runner.Report(console.Palette{})
+	assert.Contains(t, report, "pre hook in pod app/db-0: succeeded\n    ok from db-0\n")
+	assert.Contains(t, report, "pre hook in pod app/db-1: succeeded\n    ok from db-1\n")
+	assert.NotContains(t, report, "post hook")
+}
+
+func TestRunFailurePolicy(t *testing.T) {
+	t.Parallel()
+
+	exec := func(_ context.Context, _ *k8s.ClusterClient, req *k8s.ExecRequest, out io.Writer) error {
+		fmt.Fprintln(out, "could not freeze")
+
+		if req.PodName == "db-1" {
+			return utilexec.CodeExitError{Err: assert.AnError, Code: 3}
+		}
+
+		return nil
+	}
+
+	runner := newRunner(t, hook.Config{Post: "fsfreeze -u /data", Selector: "app=db"}, exec)
+
+	err := runner.Run(t.Context(), hook.PhasePost)
+	require.ErrorContains(t, err, "post hook failed: pod db-1: exited with code 3")
+	assert.Contains(t, runner.Report(console.Palette{}), "post hook in pod app/db-1: exited with code 3\n")
+
+	runner = newRunner(t, hook.Config{Post: "fsfreeze -u /data", Selector: "app=db", OnError: hook.OnErrorContinue},
+		exec)
+
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePost))
+	assert.Len(t, runner.Results(), 2)
+}
+
+func TestRunWithoutMatchingPods(t *testing.T) {
+	t.Parallel()
+
+	runner := newRunner(t, hook.Config{Pre: "sync", Selector: "app=web"},
+		func(context.Context, *k8s.ClusterClient, *k8s.ExecRequest, io.Writer) error {
+			t.Fatal("no pod should be exec'd into")
+
+			return nil
+		})
+
+	require.ErrorContains(t, runner.Run(t.Context(), hook.PhasePre), `no running pod in namespace app matches "app=web"`)
+}
+
+func TestRunTimesOut(t *testing.T) {
+	t.Parallel()
+
+	runner := newRunner(t, hook.Config{Pre: "sleep 60", Selector: "app=db", Timeout: time.Millisecond},
+		func(ctx context.Context, _ *k8s.ClusterClient, _ *k8s.ExecRequest, _ io.Writer) error {
+			<-ctx.Done()
+
+			return ctx.Err()
+		})
+
+	require.ErrorContains(t, runner.Run(t.Context(), hook.PhasePre), "timed out after 1ms")
+}
+
+func TestRunPostHookAfterCancellation(t *testing.T) {
+	t.Parallel()
+
+	var ran []string
+
+	exec := func(ctx context.Context, _ *k8s.ClusterClient, req *k8s.ExecRequest, _ io.Writer) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ran = append(ran, req.PodName)
+
+		return nil
+	}
+
+	runner := newRunner(t, hook.Config{Post: "fsfreeze -u /data", Selector: "app=db"}, exec)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	require.NoError(t, runner.Run(ctx, hook.PhasePost), "the post hook outlives the cancellation")
+	assert.Equal(t, []string{"db-0", "db-1"}, ran)
+}
+
+func TestRunPostHookOnlyWherePreHookSucceeded(t *testing.T) {
+	t.Parallel()
+
+	var ran []string
+
+	runner := newRunner(t, hook.Config{Pre: "fsfreeze -f /data", Post: "fsfreeze -u /data", Selector: "app=db"},
+		func(_ context.Context, _ *k8s.ClusterClient, req *k8s.ExecRequest, _ io.Writer) error {
+			ran = append(ran, req.Command[2]+" "+req.PodName)
+
+			if req.Command[2] == "fsfreeze -f /data" && req.PodName == "db-1" {
+				return utilexec.CodeExitError{Err: assert.AnError, Code: 1}
+			}
+
+			return nil
+		})
+
+	require.ErrorContains(t, runner.Run(t.Context(), hook.PhasePre), "pre hook failed: pod db-1")
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePost))
+	assert.Equal(t, []string{"fsfreeze -f /data db-0", "fsfreeze -f /data db-1", "fsfreeze -u /data db-0"}, ran,
+		"only the pod that was frozen is thawed")
+}
+
+func TestRunNoPostHookWhenPreHookCannotListPods(t *testing.T) {
+	t.Parallel()
+
+	var ran []string
+
+	listFails := true
+
+	kubeClient := newKubeClient()
+	kubeClient.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return listFails, nil, assert.AnError
+	})
+
+	config := hook.Config{Pre: "fsfreeze -f /data", Post: "fsfreeze -u /data", Selector: "app=db"}
+	runner := hook.NewRunner(config, &k8s.ClusterClient{KubeClient: kubeClient}, namespace, slogt.New(t)).
+		WithExec(func(_ context.Context, _ *k8s.ClusterClient, req *k8s.ExecRequest, _ io.Writer) error {
+			ran = append(ran, req.Command[2]+" "+req.PodName)
+
+			return nil
+		})
+
+	require.ErrorContains(t, runner.Run(t.Context(), hook.PhasePre), "pre hook failed")
+
+	listFails = false
+
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePost))
+	assert.Empty(t, ran, "no pod was frozen, so none is thawed")
+}
+
+func TestReportKeepsTheEndOfLongOutput(t *testing.T) {
+	t.Parallel()
+
+	runner := newRunner(t, hook.Config{Pre: "dump", Selector: "app=db"},
+		func(_ context.Context, _ *k8s.ClusterClient, _ *k8s.ExecRequest, out io.Writer) error {
+			fmt.Fprint(out, strings.Repeat("x", 2*hook.MaxOutput))
+			fmt.Fprint(out, "the end")
+
+			return nil
+		})
+
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePre))
+
+	output := runner.Results()[0].Output
+	assert.True(t, strings.HasPrefix(output, "...\n"))
+	assert.True(t, strings.HasSuffix(output, "the end"))
+	assert.Len(t, output, len("...\n")+hook.MaxOutput)
+}
+
+// TestRunCollectsBothStreamsConcurrently stands in for the exec streams, which
+// write stdout and stderr to the same writer from goroutines of their own. Run
+// it with -race to see the writer hold up.
+func TestRunCollectsBothStreamsConcurrently(t *testing.T) {
+	t.Parallel()
+
+	const lines = 200
+
+	runner := newRunner(t, hook.Config{Pre: "sync", Selector: "app=db"},
+		func(_ context.Context, _ *k8s.ClusterClient, _ *k8s.ExecRequest, out io.Writer) error {
+			var wg sync.WaitGroup
+
+			for _, stream := range []string{"stdout", "stderr"} {
+				wg.Go(func() {
+					for range lines {
+						_, _ = io.WriteString(out, stream+"\n")
+					}
+				})
+			}
+
+			wg.Wait()
+
+			return nil
+		})
+
+	require.NoError(t, runner.Run(t.Context(), hook.PhasePre))
+
+	for _, result := range runner.Results() {
+		assert.Equal(t, lines, strings.Count(result.Output, "stdout\n"))
+		assert.Equal(t, lines, strings.Count(result.Output, "stderr\n"))
+	}
+}
+
+func newRunner(
+	t *testing.T,
+	config hook.Config,
+	exec func(ctx context.Context, client *k8s.ClusterClient, req *k8s.ExecRequest, out io.Writer) error,
+) *hook.Runner {
+	t.Helper()
+
+	return hook.NewRunner(config, &k8s.ClusterClient{KubeClient: newKubeClient()}, namespace, slogt.New(t)).
+		WithExec(exec)
+}
+
+// newKubeClient has two running pods matching app=db, and a pending one.
+func newKubeClient() *fake.Clientset {
+	pod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": "db"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	return fake.NewClientset(
+		pod("db-1", corev1.PodRunning),
+		pod("db-0", corev1.PodRunning),
+		pod("db-2", corev1.PodPending),
+	)
+}
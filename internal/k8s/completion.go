@@ -80,3 +80,19 @@ func GetPVCs(
 
 	return pvcNames, nil
 }
+
+// GetCurrentContext returns the context the kubeconfig selects when none is
+// given.
+func GetCurrentContext(kubeconfigPath string, logger *slog.Logger) (string, error) {
+	client, err := GetClusterClient(kubeconfigPath, "", logger)
+	if err != nil {
+		return "", err
+	}
+
+	rawConfig, err := client.RESTClientGetter.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	return rawConfig.CurrentContext, nil
+}
@@ -0,0 +1,91 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/streaming/pkg/httpstream"
+)
+
+// ExecRequest is a command to run in a container of a running pod.
+type ExecRequest struct {
+	Namespace string
+	PodName   string
+	// Container may be left empty for a pod with a single container.
+	Container string
+	Command   []string
+}
+
+// Exec runs the command of req, writing what it prints on stdout and stderr to
+// out. The two streams are copied concurrently, so out must be safe for
+// concurrent use. It uses the websocket protocol, falling back to SPDY for API servers that
+// do not support it, as kubectl exec does. A command that exits with a non-zero
+// code returns a k8s.io/client-go/util/exec.ExitError.
+func Exec(ctx context.Context, client *ClusterClient, req *ExecRequest, out io.Writer) error {
+	execURL := client.KubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(req.Namespace).
+		Name(req.PodName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: req.Container,
+			Command:   req.Command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec).
+		URL()
+
+	spdyExec, err := remotecommand.NewSPDYExecutor(client.RestConfig, http.MethodPost, execURL)
+	if err != nil {
+		return fmt.Errorf("failed to create SPDY executor: %w", err)
+	}
+
+	websocketExec, err := remotecommand.NewWebSocketExecutor(client.RestConfig, http.MethodGet, execURL.String())
+	if err != nil {
+		return fmt.Errorf("failed to create websocket executor: %w", err)
+	}
+
+	executor, err := remotecommand.NewFallbackExecutor(websocketExec, spdyExec, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{ //nolint:wrapcheck
+		Stdout: out,
+		Stderr: out,
+	})
+}
+
+// RunningPods returns the running pods in ns that match labelSelector, ordered
+// by name so that repeated runs visit them in the same order.
+func RunningPods(ctx context.Context, cli kubernetes.Interface, ns, labelSelector string) ([]corev1.Pod, error) {
+	list, err := cli.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	pods := make([]corev1.Pod, 0, len(list.Items))
+
+	for _, pod := range list.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+
+	slices.SortFunc(pods, func(a, b corev1.Pod) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return pods, nil
+}
@@ -24,6 +24,10 @@ import (
 
 const jobLogTailLines = 100
 
+// reportTailLines bounds the log read for the report a job script prints as it
+// finishes, which follows everything the transfer wrote before it.
+const reportTailLines = 2000
+
 // FindJobPod returns a pod for the given job, preferring a Running pod.
 func FindJobPod(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job) (*corev1.Pod, error) {
 	pods, err := cli.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{
@@ -55,6 +59,10 @@ const (
 
 var jobSuffixes = []string{rsyncJobSuffix, rcloneJobSuffix}
 
+// ErrJobNotFound is returned by FindDataMoverJob when no job matches, which for
+// an operation across clusters only means the job is in the other one.
+var ErrJobNotFound = errors.New("no job found")
+
 // FindDataMoverJob finds the data-mover job (rsync or rclone) for a migration by listing
 // all Helm-managed jobs and matching by the release name prefix plus a known suffix.
 // If nothing is found in the given namespace, it retries across all namespaces.
@@ -93,7 +101,7 @@ func FindDataMoverJob(
 		return FindDataMoverJob(ctx, cli, "", releasePrefix, logger)
 	}
 
-	return nil, fmt.Errorf("no job found for migration %s", releasePrefix)
+	return nil, fmt.Errorf("%w for migration %s", ErrJobNotFound, releasePrefix)
 }
 
 // WaitForJobStart waits until the job's pod transitions out of the Pending phase.
@@ -241,7 +249,7 @@ func WaitForJobCompletion(ctx context.Context, cli kubernetes.Interface,
 
 	// The progress parser consumed the log, so the marker the job script prints
 	// for skipped files has to be fetched back to be seen at all.
-	warnIfSourceFilesVanished(recentPodLogs(ctx, cli, finalPod, logger), logger)
+	warnIfSourceFilesVanished(recentPodLogs(ctx, cli, finalPod, jobLogTailLines, logger), logger)
 
 	return nil
 }
@@ -353,7 +361,7 @@ func finishTerminalPod(
 		return failedPodError(ctx, cli, jobName, pod)
 	}
 
-	tail := recentPodLogs(ctx, cli, pod, logger)
+	tail := recentPodLogs(ctx, cli, pod, jobLogTailLines, logger)
 	if !structuredLogs {
 		writeTail(pod, jobName, tail, palette, writer, logger)
 	}
@@ -376,7 +384,7 @@ func writeFailureTail(
 	writer io.Writer,
 	logger *slog.Logger,
 ) {
-	tail := recentPodLogs(ctx, cli, pod, logger)
+	tail := recentPodLogs(ctx, cli, pod, jobLogTailLines, logger)
 	if tail == "" {
 		return
 	}
@@ -524,6 +532,19 @@ func mapRcloneRecords(lines []string) {
 	}
 }
 
+// JobReportLog returns the end of the log of the job's finished pod, where the
+// job script prints its report, or an empty string when it cannot be read.
+func JobReportLog(ctx context.Context, cli kubernetes.Interface, ns, jobName string, logger *slog.Logger) string {
+	pod, _, err := findTerminalJobPod(ctx, cli, ns, jobName)
+	if err != nil || pod == nil {
+		logger.Debug("failed to find the finished job pod", "job", ns+"/"+jobName, "error", err)
+
+		return ""
+	}
+
+	return recentPodLogs(ctx, cli, pod, reportTailLines, logger)
+}
+
 // podLogFetchTimeout bounds the evidence fetch on paths where the parent
 // context may already be over, which is often the very failure being explained.
 const podLogFetchTimeout = 10 * time.Second
@@ -536,13 +557,12 @@ func recentPodLogs(
 	ctx context.Context,
 	cli kubernetes.Interface,
 	pod *corev1.Pod,
+	tailLines int64,
 	logger *slog.Logger,
 ) string {
 	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), podLogFetchTimeout)
 	defer cancel()
 
-	tailLines := int64(jobLogTailLines)
-
 	stream, err := cli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name,
 		&corev1.PodLogOptions{TailLines: &tailLines}).Stream(ctx)
 	if err != nil {
@@ -0,0 +1,136 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxLogLineBytes bounds a single line of a streamed log. rsync and rclone
+// print short lines; a longer one is cut at the bound by the split function,
+// which drops the rest of it, rather than failing the stream.
+const maxLogLineBytes = 1024 * 1024
+
+// FindSshdPods returns the sshd pods of the releases whose names start with
+// releasePrefix, in namespace ns, or in every namespace when it is empty.
+func FindSshdPods(ctx context.Context, cli kubernetes.Interface, ns, releasePrefix string) ([]corev1.Pod, error) {
+	pods, err := cli.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/component=sshd",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sshd pods: %w", err)
+	}
+
+	var found []corev1.Pod
+
+	for i := range pods.Items {
+		if strings.HasPrefix(pods.Items[i].Labels[instanceLabel], releasePrefix) {
+			found = append(found, pods.Items[i])
+		}
+	}
+
+	slices.SortFunc(found, func(a, b corev1.Pod) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	return found, nil
+}
+
+// RenderJobLogLine makes a line of the log of a job's pod readable the way a
+// failure tail is: rclone's JSON records become "level: message" lines, and
+// every other line is kept as it came.
+func RenderJobLogLine(jobName, line string) string {
+	if !strings.HasSuffix(jobName, rcloneJobSuffix) {
+		return line
+	}
+
+	lines := []string{line}
+	mapRcloneRecords(lines)
+
+	return lines[0]
+}
+
+// StreamPodLogs hands the log of the pod to writeLine a line at a time, until
+// the log ends, or with options.Follow, until the container stops or ctx is
+// done. rsync redraws its progress line with bare carriage returns, which end
+// a line here as a newline does.
+func StreamPodLogs(
+	ctx context.Context,
+	cli kubernetes.Interface,
+	pod *corev1.Pod,
+	options *corev1.PodLogOptions,
+	writeLine func(line string) error,
+) error {
+	stream, err := cli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the logs of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(nil, maxLogLineBytes)
+	scanner.Split(scanLogLines(maxLogLineBytes))
+
+	for scanner.Scan() {
+		if err = writeLine(scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	if err = scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read the logs of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	return nil
+}
+
+// scanLogLines returns a split function that splits on "\n", "\r\n" and a bare
+// "\r", and drops the empty lines a progress redraw leaves behind. A line longer
+// than maxLine is cut to its first maxLine bytes, and the rest of it up to the
+// next line break is dropped, so the scanner never asks for a buffer larger than
+// maxLine and fails with bufio.ErrTooLong.
+func scanLogLines(maxLine int) bufio.SplitFunc {
+	discarding := false
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		start := 0
+
+		if discarding {
+			end := bytes.IndexAny(data, "\r\n")
+			if end < 0 {
+				return len(data), nil, nil
+			}
+
+			discarding = false
+			start = end
+		}
+
+		for start < len(data) && (data[start] == '\n' || data[start] == '\r') {
+			start++
+		}
+
+		if end := bytes.IndexAny(data[start:], "\r\n"); end >= 0 && end <= maxLine {
+			return start + end + 1, data[start : start+end], nil
+		}
+
+		if len(data)-start >= maxLine {
+			discarding = true
+
+			return start + maxLine, data[start : start+maxLine], nil
+		}
+
+		if atEOF && start < len(data) {
+			return len(data), data[start:], nil
+		}
+
+		return start, nil, nil
+	}
+}
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScanLogLines pins how a streamed log is cut into lines: rsync's progress
+// redraws end a line as a newline does, without leaving empty lines behind,
+// and a last line without a newline still counts.
+func TestScanLogLines(t *testing.T) {
+	t.Parallel()
+
+	scanner := bufio.NewScanner(strings.NewReader("first\r\n  10%\r  55%\r 100%\n\nsent 1 bytes\nlast"))
+	scanner.Split(scanLogLines(maxLogLineBytes))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"first", "  10%", "  55%", " 100%", "sent 1 bytes", "last"}, lines)
+}
+
+// TestScanLogLinesCutsLongLines checks that a line over the bound is cut instead
+// of failing the scan, which is what a scanner does on its own, and that the
+// lines after it are read as usual.
+func TestScanLogLinesCutsLongLines(t *testing.T) {
+	t.Parallel()
+
+	const maxLine = 16
+
+	long := strings.Repeat("x", 5*maxLine)
+	input := "short\n" + long + "\r\nafter\n" + strings.Repeat("y", maxLine) + "\n" + long
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	scanner.Buffer(make([]byte, 4), maxLine)
+	scanner.Split(scanLogLines(maxLine))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{
+		"short", long[:maxLine], "after", strings.Repeat("y", maxLine), long[:maxLine],
+	}, lines)
+}
@@ -0,0 +1,59 @@
+package k8s_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+)
+
+func TestFindSshdPods(t *testing.T) {
+	t.Parallel()
+
+	pod := func(ns, name, component, release string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    map[string]string{"app.kubernetes.io/component": component, "app.kubernetes.io/instance": release},
+		}}
+	}
+
+	cli := fake.NewClientset(
+		pod("db", "sshd-b", "sshd", "pv-migrate-brave-otter-clusterip-src"),
+		pod("apps", "sshd-a", "sshd", "pv-migrate-brave-otter-loadbalancer-src"),
+		pod("apps", "rsync", "rsync", "pv-migrate-brave-otter-clusterip-dest"),
+		pod("apps", "sshd-other", "sshd", "pv-migrate-other-clusterip-src"),
+	)
+
+	pods, err := k8s.FindSshdPods(t.Context(), cli, "", "pv-migrate-brave-otter-")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(pods))
+	for _, found := range pods {
+		names = append(names, found.Namespace+"/"+found.Name)
+	}
+
+	assert.Equal(t, []string{"apps/sshd-a", "db/sshd-b"}, names)
+
+	pods, err = k8s.FindSshdPods(t.Context(), cli, "db", "pv-migrate-brave-otter-")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "sshd-b", pods[0].Name)
+}
+
+func TestRenderJobLogLine(t *testing.T) {
+	t.Parallel()
+
+	record := `{"level":"error","msg":"Failed to copy: access denied","time":"2025-01-01T00:00:00Z"}`
+
+	assert.Equal(t, "error: Failed to copy: access denied",
+		k8s.RenderJobLogLine("pv-migrate-brave-otter-backup-rclone", record))
+	assert.Equal(t, "plain line", k8s.RenderJobLogLine("pv-migrate-brave-otter-backup-rclone", "plain line"))
+	assert.Equal(t, record, k8s.RenderJobLogLine("pv-migrate-brave-otter-clusterip-dest-rsync", record),
+		"only rclone writes JSON records")
+}
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+)
+
+// CheckSecretExists fails when the named secret is not there to be referenced by
+// a pod. Without this, the pod would sit in CreateContainerConfigError until the
+// wait for it gave up.
+//
+// It reads the secret's metadata only. A secret is referenced instead of passed
+// in precisely so that its contents stay in the cluster, and a plain get would
+// download them.
+func CheckSecretExists(ctx context.Context, cli metadata.Interface, ns, name string) error {
+	_, err := cli.Resource(corev1.SchemeGroupVersion.WithResource("secrets")).Namespace(ns).
+		Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("secret %s/%s does not exist", ns, name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s/%s: %w", ns, name, err)
+	}
+
+	return nil
+}
+
+// SecretData returns the keys and values of the named secret. It is for a secret
+// in another cluster than the pod that needs it, which can only be handed its
+// contents, so unlike CheckSecretExists it downloads them.
+func SecretData(ctx context.Context, cli kubernetes.Interface, ns, name string) (map[string]string, error) {
+	secret, err := cli.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("secret %s/%s does not exist", ns, name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %w", ns, name, err)
+	}
+
+	data := make(map[string]string, len(secret.Data))
+
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("secret %s/%s holds no keys", ns, name)
+	}
+
+	return data, nil
+}
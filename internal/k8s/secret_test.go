@@ -0,0 +1,53 @@
+package k8s_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+
+	"github.com/utkuozdemir/pv-migrate/internal/k8s"
+)
+
+func TestCheckSecretExists(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, metav1.AddMetaToScheme(scheme))
+
+	cli := metadatafake.NewSimpleMetadataClient(scheme, &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-creds", Namespace: "app"},
+	})
+
+	require.NoError(t, k8s.CheckSecretExists(t.Context(), cli, "app", "backup-creds"))
+	require.ErrorContains(t, k8s.CheckSecretExists(t.Context(), cli, "other", "backup-creds"),
+		"secret other/backup-creds does not exist")
+}
+
+func TestSecretData(t *testing.T) {
+	t.Parallel()
+
+	cli := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-creds", Namespace: "app"},
+			Data:       map[string][]byte{"ACCESS_KEY_ID": []byte("id"), "SECRET_ACCESS_KEY": []byte("secret")},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "app"}},
+	)
+
+	data, err := k8s.SecretData(t.Context(), cli, "app", "backup-creds")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ACCESS_KEY_ID": "id", "SECRET_ACCESS_KEY": "secret"}, data)
+
+	_, err = k8s.SecretData(t.Context(), cli, "other", "backup-creds")
+	require.ErrorContains(t, err, "secret other/backup-creds does not exist")
+
+	_, err = k8s.SecretData(t.Context(), cli, "app", "empty")
+	require.ErrorContains(t, err, "secret app/empty holds no keys")
+}
@@ -6,6 +6,7 @@ import (
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
 	"github.com/utkuozdemir/pv-migrate/internal/pvc"
 )
 
@@ -46,6 +47,8 @@ type Request struct {
 	NoCompress            bool
 	NonRoot               bool
 	RsyncExtraArgs        string
+	BWLimit               string
+	Hooks                 hook.Config
 	Writer                io.Writer
 
 	// StructuredLogs reports that the logger writes machine-readable records to
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/utkuozdemir/pv-migrate/internal/helm"
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
 	"github.com/utkuozdemir/pv-migrate/internal/k8s"
 	"github.com/utkuozdemir/pv-migrate/internal/migration"
 	"github.com/utkuozdemir/pv-migrate/internal/opid"
@@ -24,6 +25,8 @@ type (
 type Migrator struct {
 	getKubeClient  clusterClientGetter
 	getStrategyMap strategyMapGetter
+	// hookExec runs the hooks in the pods, k8s.Exec when nil.
+	hookExec hook.ExecFunc
 }
 
 // New creates a new migrator.
@@ -41,6 +44,10 @@ func (m *Migrator) Run(ctx context.Context, request *migration.Request, logger *
 		return err
 	}
 
+	if err = validateHooks(request); err != nil {
+		return err
+	}
+
 	// Only the public API defaults the writer, so a direct caller can leave it
 	// unset. Everything below writes to it without checking.
 	if request.Writer == nil {
@@ -72,6 +79,22 @@ func (m *Migrator) Run(ctx context.Context, request *migration.Request, logger *
 		return err
 	}
 
+	// The hooks run around the whole ladder rather than each attempt, so an
+	// application is quiesced once however many strategies it takes.
+	hooks := hook.NewRunner(request.Hooks, mig.SourceInfo.ClusterClient, mig.SourceInfo.Claim.Namespace, logger).
+		WithExec(m.hookExec)
+	if err = hooks.Run(ctx, hook.PhasePre); err != nil {
+		// A pre hook that failed in one pod may have quiesced the others, which
+		// are released before giving up.
+		if hookErr := hooks.Run(ctx, hook.PhasePost); hookErr != nil {
+			err = errors.Join(err, hookErr)
+		}
+
+		reportHookFailure(request, hooks, err, logger)
+
+		return err
+	}
+
 	outcomes := make([]attemptOutcome, 0, len(strategies))
 
 	for strategyIndex, name := range strategies {
@@ -107,14 +130,77 @@ func (m *Migrator) Run(ctx context.Context, request *migration.Request, logger *
 			return nil
 		}
 
+		if err = hooks.Run(ctx, hook.PhasePost); err != nil {
+			reportHookFailure(request, hooks, err, logger)
+
+			return err
+		}
+
 		attemptLogger.Info("✅ Migration succeeded")
 
 		return nil
 	}
 
+	hookErr := hooks.Run(ctx, hook.PhasePost)
+
 	reportOutcomes(request, outcomes, logger)
+	reportHooks(request, hooks, logger)
+
+	return newLadderExhaustedError(outcomes, hookErr)
+}
+
+// Verdict is what a strategy tells about a migration before it is run.
+type Verdict struct {
+	Strategy string
+
+	// Reason is why the strategy declines the migration, empty when it would try
+	// it.
+	Reason string
+}
+
+// Plan looks up the PVCs of a migration and runs the same checks as Run, then
+// reports, for each strategy in the order Run would try them, whether it would
+// decline the migration. It installs nothing.
+func (m *Migrator) Plan(ctx context.Context, request *migration.Request, logger *slog.Logger) ([]Verdict, error) {
+	nameToStrategyMap, err := m.getStrategyMap(request.Strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateHooks(request); err != nil {
+		return nil, err
+	}
 
-	return newLadderExhaustedError(outcomes)
+	mig, err := m.buildMigration(ctx, request, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	strategies := dedup(request.Strategies)
+	verdicts := make([]Verdict, 0, len(strategies))
+
+	for _, name := range strategies {
+		verdicts = append(verdicts, Verdict{
+			Strategy: name,
+			Reason:   strategy.CannotDoReason(nameToStrategyMap[name], mig),
+		})
+	}
+
+	return verdicts, nil
+}
+
+// validateHooks rejects hooks for a detached migration, whose job is still
+// running when pv-migrate exits, so that there is no point to run the post hook at.
+func validateHooks(request *migration.Request) error {
+	if err := request.Hooks.Validate(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if !request.Hooks.IsEmpty() && request.Detach {
+		return errors.New("hooks cannot be used with --detach, since the post hook runs once the transfer finishes")
+	}
+
+	return nil
 }
 
 // recordFailedAttempt logs the attempt as it happens, the way it always has, and
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"strings"
 	"testing"
@@ -15,7 +17,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
 	"github.com/utkuozdemir/pv-migrate/internal/k8s"
 	"github.com/utkuozdemir/pv-migrate/internal/migration"
 	"github.com/utkuozdemir/pv-migrate/internal/strategy"
@@ -256,6 +260,142 @@ func TestRunStatesItsIdentityOnce(t *testing.T) {
 	assert.Equal(t, total, identified, "every record carries the identifier")
 }
 
+func TestRunRejectsHooksWithDetach(t *testing.T) {
+	t.Parallel()
+
+	migrator := Migrator{
+		getKubeClient: fakeClusterClientGetter(),
+		getStrategyMap: func([]string) (map[string]strategy.Strategy, error) {
+			return map[string]strategy.Strategy{"str1": &mockStrategy{}}, nil
+		},
+	}
+
+	req := buildMigrationRequestWithStrategies([]string{"str1"}, true)
+	req.Detach = true
+	req.Hooks = hook.Config{Pre: "sync", Selector: "app=db"}
+
+	require.ErrorContains(t, migrator.Run(t.Context(), req, slogt.New(t)), "hooks cannot be used with --detach")
+}
+
+func TestRunStopsWhenThePreHookFails(t *testing.T) {
+	t.Parallel()
+
+	attempted := false
+
+	migrator := Migrator{
+		getKubeClient: fakeClusterClientGetter(),
+		getStrategyMap: func([]string) (map[string]strategy.Strategy, error) {
+			return map[string]strategy.Strategy{"str1": &mockStrategy{
+				runFunc: func(context.Context, *migration.Attempt) error {
+					attempted = true
+
+					return nil
+				},
+			}}, nil
+		},
+	}
+
+	var out bytes.Buffer
+
+	req := buildMigrationRequestWithStrategies([]string{"str1"}, true)
+	req.Writer = &out
+	req.Hooks = hook.Config{Pre: "sync", Post: "true", Selector: "app=db"}
+
+	err := migrator.Run(t.Context(), req, slogt.New(t))
+	require.ErrorContains(t, err, `pre hook failed: no running pod in namespace namespace1 matches "app=db"`)
+	assert.False(t, attempted, "no strategy runs after a failed pre hook")
+	assert.Contains(t, out.String(), "Migration failed.\n    pre hook failed")
+}
+
+func TestRunReleasesThePodsAFailedPreHookQuiesced(t *testing.T) {
+	t.Parallel()
+
+	getFakeClient := fakeClusterClientGetter()
+
+	var ran []string
+
+	migrator := Migrator{
+		getKubeClient: func(kubeconfigPath, kubeContext string, logger *slog.Logger) (*k8s.ClusterClient, error) {
+			client, err := getFakeClient(kubeconfigPath, kubeContext, logger)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, name := range []string{"db-0", "db-1"} {
+				if _, err = client.KubeClient.CoreV1().Pods(sourceNS).Create(t.Context(), &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: sourceNS, Labels: map[string]string{"app": "db"}},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				}, metav1.CreateOptions{}); err != nil {
+					return nil, err
+				}
+			}
+
+			return client, nil
+		},
+		getStrategyMap: func([]string) (map[string]strategy.Strategy, error) {
+			return map[string]strategy.Strategy{"str1": &mockStrategy{
+				runFunc: func(context.Context, *migration.Attempt) error {
+					t.Fatal("no strategy runs after a failed pre hook")
+
+					return nil
+				},
+			}}, nil
+		},
+		hookExec: func(_ context.Context, _ *k8s.ClusterClient, req *k8s.ExecRequest, _ io.Writer) error {
+			ran = append(ran, req.Command[2]+" "+req.PodName)
+
+			if req.Command[2] == "freeze" && req.PodName == "db-1" {
+				return errors.New("cannot freeze")
+			}
+
+			return nil
+		},
+	}
+
+	req := buildMigrationRequestWithStrategies([]string{"str1"}, true)
+	req.Writer = io.Discard
+	req.Hooks = hook.Config{Pre: "freeze", Post: "thaw", Selector: "app=db"}
+
+	err := migrator.Run(t.Context(), req, slogt.New(t))
+	require.ErrorContains(t, err, "pre hook failed: pod db-1")
+	assert.Equal(t, []string{"freeze db-0", "freeze db-1", "thaw db-0"}, ran,
+		"the pod the pre hook froze is thawed before the migration stops")
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	getFakeClient := fakeClusterClientGetter()
+	migrator := Migrator{
+		getKubeClient: func(kubeconfigPath, context string, logger *slog.Logger) (*k8s.ClusterClient, error) {
+			client, err := getFakeClient(kubeconfigPath, context, logger)
+			if err != nil {
+				return nil, err
+			}
+
+			client.RestConfig = &rest.Config{Host: "https://cluster.example"}
+
+			return client, nil
+		},
+		getStrategyMap: strategy.GetStrategiesMapForNames,
+	}
+
+	req := buildMigrationRequestWithStrategies([]string{"mount", "clusterip", "local", "mount"}, true)
+	req.Detach = true
+
+	verdicts, err := migrator.Plan(t.Context(), req, slogt.New(t))
+	require.NoError(t, err)
+	assert.Equal(t, []Verdict{
+		{Strategy: "mount", Reason: "source and destination are in different namespaces"},
+		{Strategy: "clusterip"},
+		{Strategy: "local", Reason: "local strategy requires a persistent connection through the local machine"},
+	}, verdicts)
+
+	_, err = migrator.Plan(t.Context(), buildMigrationRequestWithStrategies([]string{"clusterip"}, false),
+		slogt.New(t))
+	require.Error(t, err, "a plan runs the checks a run does, the one for mounted PVCs included")
+}
+
 func buildMigration(ignoreMounted bool) *migration.Request {
 	return buildMigrationRequestWithStrategies([]string{"mount", "clusterip", "loadbalancer"}, ignoreMounted)
 }
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/utkuozdemir/pv-migrate/internal/console"
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
 	"github.com/utkuozdemir/pv-migrate/internal/migration"
 	"github.com/utkuozdemir/pv-migrate/internal/strategy"
 )
@@ -67,8 +68,10 @@ func (e *ladderExhaustedError) Unwrap() []error {
 	return e.errs
 }
 
-func newLadderExhaustedError(outcomes []attemptOutcome) error {
-	errs := make([]error, 0, len(outcomes))
+// newLadderExhaustedError collects the errors of the outcomes, and that of the
+// post hook when it failed too.
+func newLadderExhaustedError(outcomes []attemptOutcome, hookErr error) error {
+	errs := make([]error, 0, len(outcomes)+1)
 
 	for _, outcome := range outcomes {
 		if outcome.err == nil {
@@ -78,6 +81,10 @@ func newLadderExhaustedError(outcomes []attemptOutcome) error {
 		errs = append(errs, fmt.Errorf("%s: %w", outcome.strategy, outcome.err))
 	}
 
+	if hookErr != nil {
+		errs = append(errs, hookErr)
+	}
+
 	return &ladderExhaustedError{errs: errs}
 }
 
@@ -111,6 +118,35 @@ func reportOutcomes(request *migration.Request, outcomes []attemptOutcome, logge
 	writeSummary(request.Writer, outcomes, console.Palette{Enabled: request.ColorOutput})
 }
 
+// reportHookFailure explains a migration that a hook failed on its own, before
+// the first attempt or after a successful one.
+func reportHookFailure(request *migration.Request, hooks *hook.Runner, cause error, logger *slog.Logger) {
+	if !request.StructuredLogs {
+		palette := console.Palette{Enabled: request.ColorOutput}
+
+		fmt.Fprintln(request.Writer)
+		fmt.Fprintln(request.Writer, palette.Failure("Migration failed."))
+		writeIndented(request.Writer, cause.Error())
+		fmt.Fprintln(request.Writer)
+	}
+
+	reportHooks(request, hooks, logger)
+}
+
+// reportHooks prints what the hooks printed, after the summary of a failed
+// migration or on its own when a hook failed it.
+func reportHooks(request *migration.Request, hooks *hook.Runner, logger *slog.Logger) {
+	if request.StructuredLogs {
+		if report := hooks.Report(console.Palette{}); report != "" {
+			logger.Info("🔧 What the hooks printed", "hooks", report)
+		}
+
+		return
+	}
+
+	fmt.Fprint(request.Writer, hooks.Report(console.Palette{Enabled: request.ColorOutput}))
+}
+
 // writeSummary explains the exhausted ladder. Decline reasons are short and sit
 // in their row; failure messages are printed whole on their own indented lines,
 // since a long error chain inside a table cell defeats the table on any real
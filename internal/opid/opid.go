@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 
 	petname "github.com/dustinkirkland/golang-petname"
 )
@@ -65,3 +66,60 @@ func Validate(id string) error {
 
 	return nil
 }
+
+// The sides of an operation whose strategy installs a release on each, after
+// which the releases are named.
+const (
+	SideSource      = "src"
+	SideDestination = "dest"
+)
+
+// Release is what the name of a release created for an operation says about it.
+type Release struct {
+	ID string
+	// Kind is the strategy of a migration, or the direction of a transfer to or
+	// from a bucket.
+	Kind string
+	// Side is SideSource or SideDestination for a strategy that installs a release
+	// on each side, and empty otherwise.
+	Side string
+}
+
+// ParseReleaseName reads the operation back from the name of one of its
+// releases: the prefix, the identifier, the kind and possibly the side. Kinds and
+// sides are single words, so the identifier is everything between the prefix and
+// them, hyphens included.
+func ParseReleaseName(name string) (Release, bool) {
+	rest, ok := strings.CutPrefix(name, ReleasePrefix)
+	if !ok {
+		return Release{}, false
+	}
+
+	var release Release
+
+	for _, side := range []string{SideSource, SideDestination} {
+		if trimmed, found := strings.CutSuffix(rest, "-"+side); found {
+			rest, release.Side = trimmed, side
+
+			break
+		}
+	}
+
+	id, kind, found := cutLast(rest, "-")
+	if !found || id == "" || kind == "" {
+		return Release{}, false
+	}
+
+	release.ID, release.Kind = id, kind
+
+	return release, true
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
@@ -0,0 +1,45 @@
+package opid_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+)
+
+func TestParseReleaseName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		want   opid.Release
+		wantOK bool
+	}{
+		{name: "pv-migrate-brave-otter-mount", want: opid.Release{ID: "brave-otter", Kind: "mount"}, wantOK: true},
+		{
+			name:   "pv-migrate-brave-otter-loadbalancer-src",
+			want:   opid.Release{ID: "brave-otter", Kind: "loadbalancer", Side: opid.SideSource},
+			wantOK: true,
+		},
+		{
+			name:   "pv-migrate-nightly-dest-local-dest",
+			want:   opid.Release{ID: "nightly-dest", Kind: "local", Side: opid.SideDestination},
+			wantOK: true,
+		},
+		{name: "pv-migrate-db1-backup", want: opid.Release{ID: "db1", Kind: "backup"}, wantOK: true},
+		{name: "pv-migrate-backup"},
+		{name: "pv-migrate-"},
+		{name: "other-brave-otter-mount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := opid.ParseReleaseName(tt.name)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package progresslog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// boardBarWidth is the number of cells between the ends of each bar of a Board.
+const boardBarWidth = 30
+
+// Board draws a progress bar per transfer, one per line, for following several
+// transfers at once. The bar of a Logger redraws the one line it is on, so two
+// of them on a terminal would overwrite each other in turn; a Board redraws all
+// of its lines together instead.
+type Board struct {
+	mu     sync.Mutex
+	writer io.Writer
+	rows   []*BoardRow
+	drawn  int
+}
+
+// BoardRow is the line of one transfer on a Board.
+type BoardRow struct {
+	board      *Board
+	label      string
+	percentage int
+	status     string
+}
+
+func NewBoard(writer io.Writer) *Board {
+	return &Board{writer: writer}
+}
+
+// AddRow adds a line for a transfer, below the ones added before it.
+func (b *Board) AddRow(label string) *BoardRow {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	row := &BoardRow{board: b, label: label}
+	b.rows = append(b.rows, row)
+
+	return row
+}
+
+// Set records how far the transfer is and what state it is in. It shows on the
+// next Draw.
+func (r *BoardRow) Set(percentage int, status string) {
+	r.board.mu.Lock()
+	defer r.board.mu.Unlock()
+
+	r.percentage = min(max(percentage, 0), barMaximum)
+	r.status = status
+}
+
+// SetStatus records the state the transfer is in, leaving the bar where it is.
+func (r *BoardRow) SetStatus(status string) {
+	r.board.mu.Lock()
+	defer r.board.mu.Unlock()
+
+	r.status = status
+}
+
+// Draw paints every line of the board, over the ones it painted last time.
+func (b *Board) Draw() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	labelWidth := 0
+	for _, row := range b.rows {
+		labelWidth = max(labelWidth, len(row.label))
+	}
+
+	var builder strings.Builder
+
+	if b.drawn > 0 {
+		fmt.Fprintf(&builder, "\x1b[%dA", b.drawn)
+	}
+
+	for _, row := range b.rows {
+		filled := row.percentage * boardBarWidth / barMaximum
+
+		fmt.Fprintf(&builder, "\r\x1b[2K%-*s |%s%s| %3d%% %s\n", labelWidth, row.label,
+			strings.Repeat("█", filled), strings.Repeat(" ", boardBarWidth-filled), row.percentage, row.status)
+	}
+
+	b.drawn = len(b.rows)
+
+	// One write per frame, so that nothing else written to the terminal lands
+	// between the lines of a frame.
+	_, _ = io.WriteString(b.writer, builder.String())
+}
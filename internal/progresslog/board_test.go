@@ -0,0 +1,35 @@
+package progresslog_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/utkuozdemir/pv-migrate/internal/progresslog"
+)
+
+func TestBoardRedrawsEveryRow(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	board := progresslog.NewBoard(&buf)
+	first := board.AddRow("brave-otter")
+	second := board.AddRow("db1")
+
+	first.Set(50, "Running")
+	second.Set(150, "Succeeded")
+	board.Draw()
+
+	assert.Equal(t, "\r\x1b[2Kbrave-otter |"+strings.Repeat("█", 15)+strings.Repeat(" ", 15)+"|  50% Running\n"+
+		"\r\x1b[2Kdb1         |"+strings.Repeat("█", 30)+"| 100% Succeeded\n", buf.String())
+
+	buf.Reset()
+	first.Set(60, "Running")
+	board.Draw()
+
+	assert.True(t, strings.HasPrefix(buf.String(), "\x1b[2A\r\x1b[2Kbrave-otter |"+strings.Repeat("█", 18)),
+		"the second frame goes back over the two lines of the first: %q", buf.String())
+}
@@ -0,0 +1,137 @@
+package rclone
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/utkuozdemir/pv-migrate/internal/shell"
+)
+
+// ArchiveFormat is the format the metadata of a backup records when the backup
+// is a compressed tarball per PVC rather than a tree of files. The metadata of a
+// tree leaves the format out.
+const ArchiveFormat = "tar.zst"
+
+// ArchiveObject names the tarball, in the directory that would otherwise hold
+// the files.
+const ArchiveObject = "archive.tar.zst"
+
+// FormatVar is the shell variable the command of DetectFormatCmd sets.
+const FormatVar = "format"
+
+// formatMetadataFile is where the metadata is downloaded to in the pod, and
+// sourcePVCsFile where the PVCs it lists are written one per line.
+const (
+	formatMetadataFile = "/tmp/pv-migrate-format.yaml"
+	sourcePVCsFile     = "/tmp/pv-migrate-source-pvcs.txt"
+)
+
+// DetectFormatCmd holds the parameters for reading the format of a backup off its
+// metadata before a restore.
+type DetectFormatCmd struct {
+	MetadataPath string
+	ConfigPath   string
+	ExtraArgs    string
+	// PVCs are the PVCs of a set the job restores to or verifies. Each has to be
+	// one of the sourcePvcs the metadata lists, or the command exits with 2, as
+	// the data of any other is not in the backup.
+	PVCs []string
+}
+
+// Build produces a shell command that sets FormatVar to the format the metadata
+// records. A backup without metadata, as taken before it existed, is a tree.
+// Failing to read the metadata otherwise fails the command, since guessing a
+// tree would copy the tarball itself onto the volume.
+func (c *DetectFormatCmd) Build() (string, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"metadata remote path", c.MetadataPath},
+		{"rclone config path", c.ConfigPath},
+	} {
+		if err := shell.CheckSingleLine(field.name, field.value); err != nil {
+			return "", err
+		}
+	}
+
+	for _, pvc := range c.PVCs {
+		if err := shell.CheckSingleLine("PVC name", pvc); err != nil {
+			return "", err
+		}
+	}
+
+	config := ""
+	if c.ConfigPath != "" {
+		config = " --config " + shell.Quote(c.ConfigPath)
+	}
+
+	extraArgs := ""
+	if c.ExtraArgs != "" {
+		extraArgs = " " + c.ExtraArgs
+	}
+
+	// rclone exits with 3 and 4 for a directory and a file that is not found.
+	return fmt.Sprintf("{ %s=; rclone copyto%s %s %s%s; meta_rc=$?; case $meta_rc in "+
+		"0) %s=$(sed -n 's/^format: *//p' %s | head -n 1)%s ;; 3|4) ;; *) false ;; esac; }",
+		FormatVar, config, shell.Quote(c.MetadataPath), formatMetadataFile, extraArgs,
+		FormatVar, formatMetadataFile, c.sourcePVCsCheck()), nil
+}
+
+// sourcePVCsCheck checks that the metadata lists every PVC of c.PVCs. The names
+// are DNS subdomains, which YAML writes as plain scalars, so the items of the
+// sourcePvcs list are read off as they are.
+func (c *DetectFormatCmd) sourcePVCsCheck() string {
+	if len(c.PVCs) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, 0, len(c.PVCs))
+	for _, pvc := range c.PVCs {
+		quoted = append(quoted, shell.Quote(pvc))
+	}
+
+	return fmt.Sprintf("; awk %s %s > %s; for pvc in %s; do grep -qxF \"$pvc\" %s || "+
+		"{ have=$(tr '\\n' ' ' < %s); echo \"the backup has no PVC named $pvc${have:+, it has: ${have%% }}\" >&2; "+
+		"exit 2; }; done",
+		shell.Quote(`/^sourcePvcs:/ { list = 1; next } list && !/^ *- / { list = 0 } list { sub(/^ *- */, ""); print }`),
+		formatMetadataFile, sourcePVCsFile, strings.Join(quoted, " "), sourcePVCsFile, sourcePVCsFile)
+}
+
+// IsArchiveCondition is a shell test for whether FormatVar names ArchiveFormat.
+func IsArchiveCondition() string {
+	return fmt.Sprintf("[ \"$%s\" = %s ]", FormatVar, shell.Quote(ArchiveFormat))
+}
+
+// archiveObjectPath returns the path of the tarball beneath a remote directory.
+func archiveObjectPath(remotePath string) string {
+	if strings.HasSuffix(remotePath, ":") {
+		return remotePath + ArchiveObject
+	}
+
+	return strings.TrimSuffix(remotePath, "/") + "/" + ArchiveObject
+}
+
+// buildArchiveBackup streams the files of the local path as a tarball, compressed
+// with zstd, to ArchiveObject beneath the remote path. pipefail makes a failure
+// of tar fail the upload too, rather than leave a truncated archive behind.
+func (c *Cmd) buildArchiveBackup(config, transferFlags string) string {
+	return fmt.Sprintf("(set -o pipefail; tar -C %s --numeric-owner -cf - . | zstd -q -T0 | "+
+		"rclone rcat%s %s %s%s)",
+		shell.Quote(c.LocalPath), config, defaultProgressFlags, shell.Quote(archiveObjectPath(c.RemotePath)),
+		transferFlags)
+}
+
+// buildArchiveRestore extracts the tarball beneath the remote path into the local
+// path. With Delete, the local path is emptied first, which is what a sync to it
+// would leave of the files the backup does not have.
+func (c *Cmd) buildArchiveRestore(config, transferFlags string) string {
+	emptyFirst := ""
+	if c.Delete {
+		emptyFirst = fmt.Sprintf("find %s -mindepth 1 -delete && ", shell.Quote(c.LocalPath))
+	}
+
+	return fmt.Sprintf("(set -o pipefail; %srclone cat%s %s %s%s | zstd -d -q | tar -C %s --numeric-owner -xf -)",
+		emptyFirst, config, defaultProgressFlags, shell.Quote(archiveObjectPath(c.RemotePath)), transferFlags,
+		shell.Quote(c.LocalPath))
+}
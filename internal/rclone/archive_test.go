@@ -0,0 +1,169 @@
+package rclone_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestBuildArchiveBackupCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.Cmd{
+		Direction:  rclone.DirectionBackup,
+		RemotePath: "remote:bucket/pv-migrate/app/",
+		LocalPath:  "/data",
+		ConfigPath: "/etc/rclone/rclone.conf",
+		BWLimit:    "10M",
+		Archive:    true,
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "(set -o pipefail; tar -C '/data' --numeric-owner -cf - . | zstd -q -T0 | "+
+		"rclone rcat --config '/etc/rclone/rclone.conf' --stats 1s --stats-log-level NOTICE --use-json-log "+
+		"--stats-one-line 'remote:bucket/pv-migrate/app/archive.tar.zst' --bwlimit '10M')", result)
+
+	cmd.Direction = rclone.DirectionRestore
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "only a backup can be written as an archive")
+}
+
+func TestBuildRestoreCommand_DetectArchive(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.Cmd{
+		Direction:     rclone.DirectionRestore,
+		RemotePath:    "remote:bucket/pv-migrate/app/",
+		LocalPath:     "/data",
+		Delete:        true,
+		DetectArchive: true,
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result, `if [ "$format" = 'tar.zst' ]; then (set -o pipefail; `+
+		"find '/data' -mindepth 1 -delete && rclone cat --stats 1s"), result)
+	assert.Contains(t, result, "'remote:bucket/pv-migrate/app/archive.tar.zst' | zstd -d -q | "+
+		"tar -C '/data' --numeric-owner -xf -); else rclone sync ")
+	assert.True(t, strings.HasSuffix(result, "'remote:bucket/pv-migrate/app/' '/data'; fi"), result)
+
+	cmd.Filter = rclone.Filter{Include: []string{"*.txt"}}
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "only a restore of all files can extract an archive")
+}
+
+// TestDetectFormatCommand runs the format detection under a real shell with a
+// stand-in rclone, for each way reading the metadata can go.
+//
+//nolint:paralleltest // the cases download the metadata to the same path in /tmp
+func TestDetectFormatCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		metadata   string
+		exitCode   string
+		wantFormat string
+		wantErr    bool
+	}{
+		{name: "archive", metadata: "version: 1\nformat: tar.zst\n", exitCode: "0", wantFormat: "tar.zst"},
+		{name: "tree", metadata: "version: 1\n", exitCode: "0"},
+		{name: "no metadata", exitCode: "3"},
+		{name: "unreadable metadata", exitCode: "7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fakeRclone := "#!/bin/sh\n" +
+				"eval dest=\\${$#}\n" +
+				"[ \"$EXIT_CODE\" -eq 0 ] && printf '%s' \"$METADATA\" > \"$dest\"\n" +
+				"exit \"$EXIT_CODE\"\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "rclone"), []byte(fakeRclone), 0o755)) //nolint:gosec
+
+			cmd := rclone.DetectFormatCmd{MetadataPath: "remote:bucket/pv-migrate/app.meta.yaml"}
+
+			script, err := cmd.Build()
+			require.NoError(t, err)
+
+			run := exec.CommandContext(t.Context(), "sh", "-c", script+` && echo "format=$format"`)
+			run.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"),
+				"METADATA="+tt.metadata, "EXIT_CODE="+tt.exitCode)
+
+			out, err := run.CombinedOutput()
+			if tt.wantErr {
+				require.Error(t, err, string(out))
+
+				return
+			}
+
+			require.NoError(t, err, string(out))
+			assert.Equal(t, "format="+tt.wantFormat+"\n", string(out))
+		})
+	}
+}
+
+// TestDetectFormatCommandChecksSourcePVCs runs the check of a set's PVCs under a
+// real shell, against metadata as the backup writes it.
+//
+//nolint:paralleltest // the cases download the metadata to the same path in /tmp
+func TestDetectFormatCommandChecksSourcePVCs(t *testing.T) {
+	const setMetadata = "version: 1\nsourceNamespace: db\nsourcePvcs:\n    - pg-data\n    - pg-wal\nformat: tar.zst\n"
+
+	tests := []struct {
+		name     string
+		metadata string
+		pvcs     []string
+		wantErr  string
+	}{
+		{name: "all in the backup", metadata: setMetadata, pvcs: []string{"pg-wal", "pg-data"}},
+		{
+			name: "one missing", metadata: setMetadata, pvcs: []string{"pg-data", "pg-dat"},
+			wantErr: "the backup has no PVC named pg-dat, it has: pg-data pg-wal\n",
+		},
+		{
+			name: "single PVC backup", metadata: "version: 1\nsourcePvc: pg-data\n", pvcs: []string{"pg-data"},
+			wantErr: "the backup has no PVC named pg-data\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fakeRclone := "#!/bin/sh\neval dest=\\${$#}\nprintf '%s' \"$METADATA\" > \"$dest\"\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "rclone"), []byte(fakeRclone), 0o755)) //nolint:gosec
+
+			cmd := rclone.DetectFormatCmd{MetadataPath: "remote:bucket/pv-migrate/pg.meta.yaml", PVCs: tt.pvcs}
+
+			script, err := cmd.Build()
+			require.NoError(t, err)
+
+			run := exec.CommandContext(t.Context(), "sh", "-c", script+` && echo "format=$format"`)
+			run.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"),
+				"METADATA="+tt.metadata)
+
+			out, err := run.CombinedOutput()
+			if tt.wantErr == "" {
+				require.NoError(t, err, string(out))
+				assert.Equal(t, "format=tar.zst\n", string(out))
+
+				return
+			}
+
+			var exitErr *exec.ExitError
+			require.ErrorAs(t, err, &exitErr, string(out))
+			assert.Equal(t, 2, exitErr.ExitCode(), "a PVC missing from the backup is not retried")
+			assert.Equal(t, tt.wantErr, string(out))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package rclone
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// bwLimitRate is one rate of rclone's --bwlimit: "off", or a number of KiB/s with
+// an optional size suffix, as in 512, 10M or 1.5Mi. A rate can be split into an
+// upload and a download rate, as in 10M:100k.
+const bwLimitRate = `(off|\d+(\.\d+)?([BbKkMmGgTtPp](i?B?))?)`
+
+// bwLimitEntry matches an entry of rclone's bandwidth timetable: a rate, from a
+// time of day on, optionally on one day of the week only, as in "08:00,512k" or
+// "Mon-00:00,off".
+var bwLimitEntry = regexp.MustCompile(
+	`^(((Mon|Tue|Wed|Thu|Fri|Sat|Sun)-)?([01]\d|2[0-3]):[0-5]\d,)?` + bwLimitRate + `(:` + bwLimitRate + `)?$`)
+
+// ValidateBWLimit checks a bandwidth limit for rclone, which takes either one
+// rate or a timetable of space-separated entries that each start at a time of
+// day, such as "08:00,512k 18:00,off".
+func ValidateBWLimit(limit string) error {
+	if limit == "" {
+		return nil
+	}
+
+	entries := strings.Fields(limit)
+
+	for _, entry := range entries {
+		if !bwLimitEntry.MatchString(entry) {
+			return fmt.Errorf("invalid --bwlimit %q: %q is neither a rate such as 10M nor a timetable entry "+
+				"such as 08:00,512k or Mon-18:00,off", limit, entry)
+		}
+
+		if len(entries) > 1 && !strings.Contains(entry, ",") {
+			return fmt.Errorf("invalid --bwlimit %q: every entry of a timetable needs a time, as in 08:00,%s",
+				limit, entry)
+		}
+	}
+
+	return nil
+}
+
+// checkBWLimitArgs rejects a bandwidth limit that the extra arguments set as
+// well, which would leave two to pick from.
+func checkBWLimitArgs(limit, extraArgs string) error {
+	if limit == "" {
+		return nil
+	}
+
+	for arg := range strings.FieldsSeq(extraArgs) {
+		if arg == "--bwlimit" || strings.HasPrefix(arg, "--bwlimit=") {
+			return errors.New("--bwlimit is set both as a flag and in the extra rclone arguments")
+		}
+	}
+
+	return nil
+}
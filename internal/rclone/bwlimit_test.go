@@ -0,0 +1,56 @@
+package rclone_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestValidateBWLimit(t *testing.T) {
+	t.Parallel()
+
+	for _, limit := range []string{
+		"", "512", "10M", "1.5Mi", "off", "10M:100k", "10MiB",
+		"08:00,512k 12:00,10M 18:00,off",
+		"Mon-00:00,512 Fri-23:59,10M:off",
+		" 23:00,1G ",
+	} {
+		assert.NoError(t, rclone.ValidateBWLimit(limit), limit)
+	}
+
+	tests := map[string]string{
+		"fast":                 `"fast" is neither a rate such as 10M nor a timetable entry`,
+		"24:00,10M":            `"24:00,10M" is neither a rate`,
+		"Monday-08:00,10M":     `"Monday-08:00,10M" is neither a rate`,
+		"08:00,10M,20M":        `"08:00,10M,20M" is neither a rate`,
+		"10M 18:00,off":        "every entry of a timetable needs a time, as in 08:00,10M",
+		"08:00,10M; rm -rf /*": `"08:00,10M;" is neither a rate`,
+	}
+
+	for limit, wantErr := range tests {
+		require.ErrorContains(t, rclone.ValidateBWLimit(limit), wantErr, limit)
+	}
+}
+
+func TestBuildCommand_BWLimit(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.Cmd{
+		Direction:  rclone.DirectionBackup,
+		RemotePath: "remote:my-bucket/pv-migrate/my-backup/",
+		LocalPath:  "/data",
+		BWLimit:    "08:00,512k 18:00,off",
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Contains(t, result, " '/data' 'remote:my-bucket/pv-migrate/my-backup/' --bwlimit '08:00,512k 18:00,off'")
+
+	cmd.ExtraArgs = "--transfers 8 --bwlimit 1M"
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "--bwlimit is set both as a flag and in the extra rclone arguments")
+}
@@ -0,0 +1,190 @@
+package rclone
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/utkuozdemir/pv-migrate/internal/shell"
+)
+
+// The ways a verification compares the files of the bucket with those of the
+// volume: by size alone, or by size and hash.
+const (
+	CheckModeSize     = "size"
+	CheckModeChecksum = "checksum"
+)
+
+// CheckModes lists the verification modes, in the order the CLI presents them.
+var CheckModes = []string{CheckModeSize, CheckModeChecksum}
+
+// CheckReportPrefix marks the lines of a verification report in the job's log.
+const CheckReportPrefix = "pv-migrate check: "
+
+// maxReportedDifferences bounds the paths one check lists in the log. The count
+// of differences is reported in full.
+const maxReportedDifferences = 100
+
+// checkReportFile is where rclone check writes its combined report in the pod.
+const checkReportFile = "/tmp/pv-migrate-check.txt"
+
+// The kinds of difference rclone check reports, by the symbol its combined
+// report marks a path with. The bucket is always the source of the check.
+const (
+	DifferenceContent     = "differs"
+	DifferenceMissing     = "missing from the volume"
+	DifferenceNotInBackup = "not in the backup"
+	DifferenceError       = "could not be checked"
+	// These two are found by checking the bucket against the integrity manifest.
+	DifferenceManifestContent = "differs from the manifest"
+	DifferenceManifestMissing = "missing from the bucket"
+)
+
+var differenceKinds = map[byte]string{
+	'*': DifferenceContent,
+	'-': DifferenceMissing,
+	'+': DifferenceNotInBackup,
+	'!': DifferenceError,
+	'~': DifferenceManifestContent,
+	'?': DifferenceManifestMissing,
+}
+
+// CheckCmd holds the parameters for building an rclone check of a volume
+// against the bucket.
+type CheckCmd struct {
+	RemotePath string
+	LocalPath  string
+	ConfigPath string
+	Mode       string
+	// OneWay only checks that the files of the bucket are on the volume, leaving
+	// out files the volume has on top.
+	OneWay bool
+	// ReportPrefix is put before every reported path, to tell apart the PVCs of a
+	// set.
+	ReportPrefix string
+	// Filter limits the check to the files the transfer covered.
+	Filter    Filter
+	ExtraArgs string
+}
+
+// Build produces the check as a shell command that succeeds only when nothing
+// differs. It prints the number of differences and the first of them as report
+// lines, whatever the outcome, so that the client can read them off the log.
+func (c *CheckCmd) Build() (string, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"--path", c.LocalPath},
+		{"remote path", c.RemotePath},
+		{"rclone config path", c.ConfigPath},
+		{"report prefix", c.ReportPrefix},
+	} {
+		if err := shell.CheckSingleLine(field.name, field.value); err != nil {
+			return "", err
+		}
+	}
+
+	if err := c.Filter.Validate(); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("rclone check")
+
+	if c.ConfigPath != "" {
+		fmt.Fprintf(&builder, " --config %s", shell.Quote(c.ConfigPath))
+	}
+
+	fmt.Fprintf(&builder, " --use-json-log --combined %s", checkReportFile)
+
+	switch c.Mode {
+	case CheckModeSize:
+		builder.WriteString(" --size-only")
+	case CheckModeChecksum:
+	default:
+		return "", fmt.Errorf("invalid verification mode: %q, must be one of %s", c.Mode, strings.Join(CheckModes, ", "))
+	}
+
+	if c.OneWay {
+		builder.WriteString(" --one-way")
+	}
+
+	fmt.Fprintf(&builder, " %s %s", shell.Quote(c.RemotePath), shell.Quote(c.LocalPath))
+
+	if !c.Filter.IsEmpty() {
+		fmt.Fprintf(&builder, " %s", c.Filter.args())
+	}
+
+	if c.ExtraArgs != "" {
+		fmt.Fprintf(&builder, " %s", c.ExtraArgs)
+	}
+
+	writeCheckReport(&builder, c.ReportPrefix, "")
+
+	return builder.String(), nil
+}
+
+// writeCheckReport follows a check that wrote its combined report to
+// checkReportFile with the report lines, and with the check's outcome. The sed
+// script in rewrite, if any, is applied to a line of the report before it is
+// printed, to turn its symbol into another kind.
+func writeCheckReport(builder *strings.Builder, reportPrefix, rewrite string) {
+	// Matching paths are marked with "=" and left out.
+	fmt.Fprintf(builder, "; check_rc=$?; echo %s\"$(grep -vc '^= ' %s 2>/dev/null) differences\"",
+		shell.Quote(CheckReportPrefix), checkReportFile)
+	fmt.Fprintf(builder, "; grep -v '^= ' %s 2>/dev/null | head -n %d | sed %s",
+		checkReportFile, maxReportedDifferences,
+		shell.Quote(rewrite+`s|^\(.\) |`+CheckReportPrefix+`\1 `+reportPrefix+`|`))
+	builder.WriteString(`; [ "$check_rc" -eq 0 ]`)
+}
+
+// CheckDifference is a path a verification found to differ.
+type CheckDifference struct {
+	Kind string
+	Path string
+}
+
+// CheckReport is what the verification reports of a job's log add up to.
+type CheckReport struct {
+	// Total counts every difference, while Differences lists only the first ones
+	// of each check.
+	Total       int
+	Differences []CheckDifference
+}
+
+var differenceCount = regexp.MustCompile(`^(\d+) differences$`)
+
+// ParseCheckReport collects the verification report lines of a job's log.
+func ParseCheckReport(log string) CheckReport {
+	var report CheckReport
+
+	for line := range strings.SplitSeq(log, "\n") {
+		// Anchored at the start, since the shell's trace of the command that
+		// prints a report line carries the prefix as well.
+		entry, found := strings.CutPrefix(strings.TrimRight(line, "\r"), CheckReportPrefix)
+		if !found {
+			continue
+		}
+
+		if match := differenceCount.FindStringSubmatch(entry); match != nil {
+			if count, err := strconv.Atoi(match[1]); err == nil {
+				report.Total += count
+			}
+
+			continue
+		}
+
+		if len(entry) < 3 || entry[1] != ' ' { //nolint:mnd
+			continue
+		}
+
+		if kind, ok := differenceKinds[entry[0]]; ok {
+			report.Differences = append(report.Differences, CheckDifference{Kind: kind, Path: entry[2:]})
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,108 @@
+package rclone_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestBuildCheckCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.CheckCmd{
+		RemotePath: "remote:my-bucket/pv-migrate/my-backup/",
+		LocalPath:  "/data",
+		ConfigPath: "/etc/rclone/rclone.conf",
+		Mode:       rclone.CheckModeSize,
+		OneWay:     true,
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Contains(t, result, "rclone check --config '/etc/rclone/rclone.conf' --use-json-log "+
+		"--combined /tmp/pv-migrate-check.txt --size-only --one-way "+
+		"'remote:my-bucket/pv-migrate/my-backup/' '/data'; check_rc=$?")
+
+	cmd.Mode = rclone.CheckModeChecksum
+	cmd.OneWay = false
+
+	result, err = cmd.Build()
+	require.NoError(t, err)
+	assert.NotContains(t, result, "--size-only")
+	assert.NotContains(t, result, "--one-way")
+
+	cmd.Mode = "bytes"
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, `invalid verification mode: "bytes"`)
+}
+
+// TestCheckCommandReportsDifferences runs the check under a real shell with a
+// stand-in rclone that writes a combined report, so the report lines the job
+// prints are the ones ParseCheckReport reads.
+func TestCheckCommandReportsDifferences(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fakeRclone := "#!/bin/sh\n" +
+		"printf '= same.txt\\n* db/changed.txt\\n- gone.txt\\n+ extra file.txt\\n' > /tmp/pv-migrate-check.txt\n" +
+		"exit 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rclone"), []byte(fakeRclone), 0o755)) //nolint:gosec
+
+	t.Cleanup(func() { _ = os.Remove("/tmp/pv-migrate-check.txt") })
+
+	cmd := rclone.CheckCmd{
+		RemotePath:   "remote:my-bucket/pv-migrate/my-backup/pvc-a/",
+		LocalPath:    "/data/pvc-a",
+		Mode:         rclone.CheckModeChecksum,
+		ReportPrefix: "pvc-a/",
+	}
+
+	script, err := cmd.Build()
+	require.NoError(t, err)
+
+	run := exec.CommandContext(t.Context(), "sh", "-c", script)
+	run.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	out, err := run.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr, string(out))
+	assert.Equal(t, 1, exitErr.ExitCode())
+
+	report := rclone.ParseCheckReport(string(out))
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, []rclone.CheckDifference{
+		{Kind: rclone.DifferenceContent, Path: "pvc-a/db/changed.txt"},
+		{Kind: rclone.DifferenceMissing, Path: "pvc-a/gone.txt"},
+		{Kind: rclone.DifferenceNotInBackup, Path: "pvc-a/extra file.txt"},
+	}, report.Differences)
+}
+
+func TestParseCheckReport(t *testing.T) {
+	t.Parallel()
+
+	log := `{"level":"notice","msg":"1 differences found"}
++ echo 'pv-migrate check: 9 differences'
+pv-migrate check: 2 differences
+pv-migrate check: ! broken.txt
+pv-migrate check: * a.txt
+pv-migrate check:  differences
+pv-migrate check: 0 differences
+`
+
+	report := rclone.ParseCheckReport(log)
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, []rclone.CheckDifference{
+		{Kind: rclone.DifferenceError, Path: "broken.txt"},
+		{Kind: rclone.DifferenceContent, Path: "a.txt"},
+	}, report.Differences)
+
+	assert.Equal(t, rclone.CheckReport{}, rclone.ParseCheckReport("no report here"))
+}
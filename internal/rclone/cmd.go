@@ -1,6 +1,7 @@
 package rclone
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -10,6 +11,8 @@ import (
 const (
 	DirectionBackup  = "backup"
 	DirectionRestore = "restore"
+	DirectionCopy    = "copy"
+	DirectionVerify  = "verify"
 )
 
 const defaultProgressFlags = "--stats 1s --stats-log-level NOTICE --use-json-log --stats-one-line"
@@ -20,8 +23,18 @@ type Cmd struct {
 	RemotePath string
 	LocalPath  string
 	ConfigPath string
-	ExtraArgs  string
-	Delete     bool
+	Filter     Filter
+	// BWLimit caps the transfer rate, as rclone's --bwlimit does, timetable included.
+	BWLimit   string
+	ExtraArgs string
+	Delete    bool
+
+	// Archive has a backup stream the files to one compressed tarball,
+	// ArchiveObject beneath RemotePath, instead of copying them one by one.
+	Archive bool
+	// DetectArchive has a restore extract ArchiveObject instead when FormatVar,
+	// as the command of DetectFormatCmd sets it, names ArchiveFormat.
+	DetectArchive bool
 }
 
 // Build produces the full rclone command string.
@@ -43,6 +56,26 @@ func (c *Cmd) Build() (string, error) {
 		}
 	}
 
+	if err := c.Filter.Validate(); err != nil {
+		return "", err
+	}
+
+	if err := ValidateBWLimit(c.BWLimit); err != nil {
+		return "", err
+	}
+
+	if err := checkBWLimitArgs(c.BWLimit, c.ExtraArgs); err != nil {
+		return "", err
+	}
+
+	if c.Archive && c.Direction != DirectionBackup {
+		return "", errors.New("only a backup can be written as an archive")
+	}
+
+	if c.DetectArchive && (c.Direction != DirectionRestore || !c.Filter.IsEmpty()) {
+		return "", errors.New("only a restore of all files can extract an archive")
+	}
+
 	var src, dest string
 
 	switch c.Direction {
@@ -61,44 +94,165 @@ func (c *Cmd) Build() (string, error) {
 		action = "copy"
 	}
 
+	config := ""
+	if c.ConfigPath != "" {
+		config = " --config " + shell.Quote(c.ConfigPath)
+	}
+
+	// The flags that shape a transfer, which the archive pipelines take as well.
+	transferFlags := ""
+	if c.BWLimit != "" {
+		transferFlags += " --bwlimit " + shell.Quote(c.BWLimit)
+	}
+
+	if c.ExtraArgs != "" {
+		transferFlags += " " + c.ExtraArgs
+	}
+
+	if c.Archive {
+		return c.buildArchiveBackup(config, transferFlags), nil
+	}
+
 	var builder strings.Builder
 
-	fmt.Fprintf(&builder, "rclone %s", action)
+	fmt.Fprintf(&builder, "rclone %s%s %s %s %s",
+		action, config, defaultProgressFlags, shell.Quote(src), shell.Quote(dest))
 
-	if c.ConfigPath != "" {
-		fmt.Fprintf(&builder, " --config %s", shell.Quote(c.ConfigPath))
+	if !c.Filter.IsEmpty() {
+		fmt.Fprintf(&builder, " %s", c.Filter.args())
 	}
 
-	builder.WriteString(" " + defaultProgressFlags)
+	builder.WriteString(transferFlags)
+
+	if c.DetectArchive {
+		return fmt.Sprintf("if %s; then %s; else %s; fi",
+			IsArchiveCondition(), c.buildArchiveRestore(config, transferFlags), builder.String()), nil
+	}
+
+	return builder.String(), nil
+}
+
+// CopyCmd holds the parameters for building the rclone command that copies a
+// backup from one remote to another.
+type CopyCmd struct {
+	SourcePath         string
+	DestPath           string
+	SourceMetadataPath string
+	DestMetadataPath   string
+	// SourceManifestPath and DestManifestPath copy the integrity manifest along,
+	// when the source has one. They are optional.
+	SourceManifestPath string
+	DestManifestPath   string
+	ConfigPath         string
+	ExtraArgs          string
+}
+
+// Build produces the full rclone command string. The data is copied first and
+// the metadata sidecar last, so a copy only becomes visible to restore once its
+// data is complete. Only the data copy reports progress: a second run of stats
+// for a single small file would restart the progress from zero. The manifest is
+// copied in between, if the source has one, since backups taken before manifests
+// existed do not.
+func (c *CopyCmd) Build() (string, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"source path", c.SourcePath},
+		{"destination path", c.DestPath},
+		{"source metadata path", c.SourceMetadataPath},
+		{"destination metadata path", c.DestMetadataPath},
+		{"rclone config path", c.ConfigPath},
+	} {
+		if field.value == "" {
+			return "", fmt.Errorf("%s must not be empty", field.name)
+		}
 
-	fmt.Fprintf(&builder, " %s %s", shell.Quote(src), shell.Quote(dest))
+		if err := shell.CheckSingleLine(field.name, field.value); err != nil {
+			return "", err
+		}
+	}
 
+	extraArgs := ""
 	if c.ExtraArgs != "" {
-		fmt.Fprintf(&builder, " %s", c.ExtraArgs)
+		extraArgs = " " + c.ExtraArgs
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"source manifest path", c.SourceManifestPath},
+		{"destination manifest path", c.DestManifestPath},
+	} {
+		if err := shell.CheckSingleLine(field.name, field.value); err != nil {
+			return "", err
+		}
+	}
+
+	if (c.SourceManifestPath == "") != (c.DestManifestPath == "") {
+		return "", errors.New("the source and the destination manifest paths must be given together")
 	}
 
+	config := shell.Quote(c.ConfigPath)
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "rclone copy --config %s %s %s %s%s",
+		config, defaultProgressFlags, shell.Quote(c.SourcePath), shell.Quote(c.DestPath), extraArgs)
+
+	if c.SourceManifestPath != "" {
+		fmt.Fprintf(&builder, " && if rclone lsf --config %s %s%s > /dev/null 2>&1; "+
+			"then rclone copyto --config %s %s %s%s; fi",
+			config, shell.Quote(c.SourceManifestPath), extraArgs,
+			config, shell.Quote(c.SourceManifestPath), shell.Quote(c.DestManifestPath), extraArgs)
+	}
+
+	fmt.Fprintf(&builder, " && rclone copyto --config %s %s %s%s",
+		config, shell.Quote(c.SourceMetadataPath), shell.Quote(c.DestMetadataPath), extraArgs)
+
 	return builder.String(), nil
 }
 
 // BuildRemotePath constructs the remote path for backup data:
-// remote:<bucket>/<prefix>/<name>/
+// <remote>:<bucket>/<prefix>/<name>/
 // If prefix is empty, the prefix segment is omitted.
-func BuildRemotePath(bucket, prefix, name string) string {
+func BuildRemotePath(remote, bucket, prefix, name string) string {
 	if prefix == "" {
-		return fmt.Sprintf("%s:%s/%s/", remoteName, bucket, name)
+		return fmt.Sprintf("%s:%s/%s/", remote, bucket, name)
 	}
 
-	return fmt.Sprintf("%s:%s/%s/%s/", remoteName, bucket, prefix, name)
+	return fmt.Sprintf("%s:%s/%s/%s/", remote, bucket, prefix, name)
 }
 
 // BuildMetadataRemotePath constructs the remote path for the metadata sidecar file:
-// remote:<bucket>/<prefix>/<name>.meta.yaml
-func BuildMetadataRemotePath(bucket, prefix, name string) string {
+// <remote>:<bucket>/<prefix>/<name>.meta.yaml
+func BuildMetadataRemotePath(remote, bucket, prefix, name string) string {
+	if prefix == "" {
+		return fmt.Sprintf("%s:%s/%s%s", remote, bucket, name, MetadataSuffix)
+	}
+
+	return fmt.Sprintf("%s:%s/%s/%s%s", remote, bucket, prefix, name, MetadataSuffix)
+}
+
+// BuildManifestRemotePath constructs the remote path for the integrity manifest:
+// <remote>:<bucket>/<prefix>/<name>.manifest.sha256
+func BuildManifestRemotePath(remote, bucket, prefix, name string) string {
+	if prefix == "" {
+		return fmt.Sprintf("%s:%s/%s%s", remote, bucket, name, ManifestSuffix)
+	}
+
+	return fmt.Sprintf("%s:%s/%s/%s%s", remote, bucket, prefix, name, ManifestSuffix)
+}
+
+// BuildBackupsDirPath constructs the remote path of the directory holding the
+// backups and their metadata sidecars: <remote>:<bucket>/<prefix>/
+func BuildBackupsDirPath(remote, bucket, prefix string) string {
 	if prefix == "" {
-		return fmt.Sprintf("%s:%s/%s.meta.yaml", remoteName, bucket, name)
+		return fmt.Sprintf("%s:%s/", remote, bucket)
 	}
 
-	return fmt.Sprintf("%s:%s/%s/%s.meta.yaml", remoteName, bucket, prefix, name)
+	return fmt.Sprintf("%s:%s/%s/", remote, bucket, prefix)
 }
 
 // BuildRemotePathRaw returns the user-provided remote spec as-is (for --rclone-config mode).
@@ -144,34 +144,113 @@ func TestBuildCommand_EmptyDirection_ReturnsError(t *testing.T) {
 func TestBuildRemotePath(t *testing.T) {
 	t.Parallel()
 
-	result := rclone.BuildRemotePath("my-bucket", "pv-migrate", "my-backup")
+	result := rclone.BuildRemotePath(rclone.DefaultRemoteName, "my-bucket", "pv-migrate", "my-backup")
 	assert.Equal(t, "remote:my-bucket/pv-migrate/my-backup/", result)
 }
 
 func TestBuildRemotePath_EmptyPrefix(t *testing.T) {
 	t.Parallel()
 
-	result := rclone.BuildRemotePath("my-bucket", "", "my-backup")
+	result := rclone.BuildRemotePath(rclone.DefaultRemoteName, "my-bucket", "", "my-backup")
 	assert.Equal(t, "remote:my-bucket/my-backup/", result)
 }
 
 func TestBuildMetadataRemotePath(t *testing.T) {
 	t.Parallel()
 
-	result := rclone.BuildMetadataRemotePath("my-bucket", "pv-migrate", "my-backup")
+	result := rclone.BuildMetadataRemotePath(rclone.DefaultRemoteName, "my-bucket", "pv-migrate", "my-backup")
 	assert.Equal(t, "remote:my-bucket/pv-migrate/my-backup.meta.yaml", result)
 }
 
 func TestBuildMetadataRemotePath_EmptyPrefix(t *testing.T) {
 	t.Parallel()
 
-	result := rclone.BuildMetadataRemotePath("my-bucket", "", "my-backup")
+	result := rclone.BuildMetadataRemotePath(rclone.DefaultRemoteName, "my-bucket", "", "my-backup")
 	assert.Equal(t, "remote:my-bucket/my-backup.meta.yaml", result)
 }
 
+func TestBuildManifestRemotePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "remote:my-bucket/pv-migrate/my-backup.manifest.sha256",
+		rclone.BuildManifestRemotePath(rclone.DefaultRemoteName, "my-bucket", "pv-migrate", "my-backup"))
+	assert.Equal(t, "remote:my-bucket/my-backup.manifest.sha256",
+		rclone.BuildManifestRemotePath(rclone.DefaultRemoteName, "my-bucket", "", "my-backup"))
+}
+
 func TestBuildRemotePathRaw(t *testing.T) {
 	t.Parallel()
 
 	result := rclone.BuildRemotePathRaw("myremote:bucket/path")
 	assert.Equal(t, "myremote:bucket/path", result)
 }
+
+func TestBuildCopyCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.CopyCmd{
+		SourcePath:         "source:src-bucket/pv-migrate/my-backup/",
+		DestPath:           "dest:dst-bucket/pv-migrate/my-backup/",
+		SourceMetadataPath: "source:src-bucket/pv-migrate/my-backup.meta.yaml",
+		DestMetadataPath:   "dest:dst-bucket/pv-migrate/my-backup.meta.yaml",
+		ConfigPath:         "/etc/rclone/rclone.conf",
+		ExtraArgs:          "--transfers 8",
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"rclone copy --config '/etc/rclone/rclone.conf' --stats 1s --stats-log-level NOTICE "+
+			"--use-json-log --stats-one-line 'source:src-bucket/pv-migrate/my-backup/' "+
+			"'dest:dst-bucket/pv-migrate/my-backup/' --transfers 8 && "+
+			"rclone copyto --config '/etc/rclone/rclone.conf' "+
+			"'source:src-bucket/pv-migrate/my-backup.meta.yaml' "+
+			"'dest:dst-bucket/pv-migrate/my-backup.meta.yaml' --transfers 8",
+		result,
+	)
+}
+
+func TestBuildCopyCommand_Manifest(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.CopyCmd{
+		SourcePath:         "source:src-bucket/pv-migrate/my-backup/",
+		DestPath:           "dest:dst-bucket/pv-migrate/my-backup/",
+		SourceMetadataPath: "source:src-bucket/pv-migrate/my-backup.meta.yaml",
+		DestMetadataPath:   "dest:dst-bucket/pv-migrate/my-backup.meta.yaml",
+		SourceManifestPath: "source:src-bucket/pv-migrate/my-backup.manifest.sha256",
+		DestManifestPath:   "dest:dst-bucket/pv-migrate/my-backup.manifest.sha256",
+		ConfigPath:         "/etc/rclone/rclone.conf",
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Contains(t, result,
+		"'dest:dst-bucket/pv-migrate/my-backup/' && "+
+			"if rclone lsf --config '/etc/rclone/rclone.conf' "+
+			"'source:src-bucket/pv-migrate/my-backup.manifest.sha256' > /dev/null 2>&1; "+
+			"then rclone copyto --config '/etc/rclone/rclone.conf' "+
+			"'source:src-bucket/pv-migrate/my-backup.manifest.sha256' "+
+			"'dest:dst-bucket/pv-migrate/my-backup.manifest.sha256'; fi && "+
+			"rclone copyto --config '/etc/rclone/rclone.conf' 'source:src-bucket/pv-migrate/my-backup.meta.yaml'",
+		"the manifest is copied before the metadata, and only when the source has one")
+
+	cmd.DestManifestPath = ""
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "must be given together")
+}
+
+func TestBuildCopyCommand_MissingPath(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.CopyCmd{
+		SourcePath: "source:src-bucket/pv-migrate/my-backup/",
+		DestPath:   "dest:dst-bucket/pv-migrate/my-backup/",
+		ConfigPath: "/etc/rclone/rclone.conf",
+	}
+
+	_, err := cmd.Build()
+	require.ErrorContains(t, err, "source metadata path must not be empty")
+}
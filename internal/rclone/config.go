@@ -3,23 +3,45 @@ package rclone
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strings"
 )
 
 const (
-	BackendS3    = "s3"
-	BackendAzure = "azure"
-	BackendGCS   = "gcs"
+	BackendS3     = "s3"
+	BackendAzure  = "azure"
+	BackendGCS    = "gcs"
+	BackendSFTP   = "sftp"
+	BackendWebDAV = "webdav"
+	BackendSwift  = "swift"
+	BackendB2     = "b2"
 
 	// DefaultS3Provider is rclone's generic S3-compatible provider mode.
 	DefaultS3Provider = "Other"
 
-	remoteName = "remote"
+	// DefaultWebDAVVendor is rclone's generic WebDAV server mode.
+	DefaultWebDAVVendor = "other"
+
+	// DefaultRemoteName is the name of the single remote in a generated config.
+	DefaultRemoteName = "remote"
+
+	maxPort = 65535
 )
 
+// Backends lists the backends GenerateConfig supports, in the order the CLI
+// presents them.
+var Backends = []string{BackendS3, BackendAzure, BackendGCS, BackendSFTP, BackendWebDAV, BackendSwift, BackendB2}
+
+// WebDAVVendors lists the values rclone accepts for a WebDAV remote's vendor.
+var WebDAVVendors = []string{
+	"fastmail", "nextcloud", "owncloud", "sharepoint", "sharepoint-ntlm", "rclone", DefaultWebDAVVendor,
+}
+
 // ConfigOptions holds the high-level flags for generating an rclone.conf.
 type ConfigOptions struct {
 	Backend   string
@@ -34,6 +56,37 @@ type ConfigOptions struct {
 
 	GCSServiceAccountJSON string
 	GCSBucketPolicyOnly   *bool
+
+	SFTPHost     string
+	SFTPPort     int
+	SFTPUser     string
+	SFTPPassword string
+	SFTPKeyPEM   string
+
+	WebDAVURL         string
+	WebDAVVendor      string
+	WebDAVUser        string
+	WebDAVPassword    string
+	WebDAVBearerToken string
+
+	SwiftAuthURL string
+	SwiftUser    string
+	SwiftKey     string
+	SwiftTenant  string
+	SwiftDomain  string
+
+	B2Account string
+	B2Key     string
+
+	// ExternalCredentials reports that the credentials reach rclone through
+	// RCLONE_CONFIG_<REMOTE>_* environment variables instead of the config, so a
+	// missing credential is not an error and ambient authentication is not
+	// enabled in their place.
+	ExternalCredentials bool
+
+	// RemoteName names the generated remote (default: DefaultRemoteName), so that
+	// configs generated for different backends can be joined into one.
+	RemoteName string
 }
 
 // GenerateConfig produces an rclone.conf INI string from high-level options.
@@ -49,6 +102,14 @@ func GenerateConfig(opts ConfigOptions) (string, error) {
 		return generateAzureConfig(opts), nil
 	case BackendGCS:
 		return generateGCSConfig(opts)
+	case BackendSFTP:
+		return generateSFTPConfig(opts)
+	case BackendWebDAV:
+		return generateWebDAVConfig(opts)
+	case BackendSwift:
+		return generateSwiftConfig(opts)
+	case BackendB2:
+		return generateB2Config(opts)
 	case "":
 		return "", errors.New("backend must not be empty")
 	default:
@@ -69,6 +130,12 @@ func GenerateConfig(opts ConfigOptions) (string, error) {
 // over from another backend would otherwise fail an operation whose config never
 // contains it.
 func (o ConfigOptions) validate() error {
+	if o.RemoteName != "" {
+		if err := ValidateRemoteName(o.RemoteName); err != nil {
+			return err
+		}
+	}
+
 	for _, field := range o.writtenFields() {
 		if err := validateConfigValue(field.name, field.value); err != nil {
 			return err
@@ -78,6 +145,35 @@ func (o ConfigOptions) validate() error {
 	return nil
 }
 
+func (o ConfigOptions) remoteName() string {
+	if o.RemoteName == "" {
+		return DefaultRemoteName
+	}
+
+	return o.RemoteName
+}
+
+// UsesAmbientAuth reports whether the generated config leaves authentication to
+// rclone's env_auth, that is, to whatever identity the job pod runs with.
+func (o ConfigOptions) UsesAmbientAuth() bool {
+	if o.ExternalCredentials {
+		return false
+	}
+
+	switch o.Backend {
+	case BackendS3:
+		return o.AccessKey == "" && o.SecretKey == ""
+	case BackendAzure:
+		return o.StorageKey == ""
+	case BackendGCS:
+		return o.GCSServiceAccountJSON == ""
+	case BackendSwift:
+		return o.SwiftAuthURL == "" && o.SwiftUser == "" && o.SwiftKey == ""
+	default:
+		return false
+	}
+}
+
 // configField pairs a value with the name of the flag it came from, so an error
 // points at what to change.
 type configField struct {
@@ -88,6 +184,8 @@ type configField struct {
 // writtenFields returns the option values that end up in the generated config for
 // the chosen backend. The GCS backend contributes none: its credential is JSON,
 // which is compacted onto one line and rejected by that compaction if malformed.
+// The SFTP private key is absent for a similar reason: it is a multi-line PEM
+// block by nature, and it is folded onto one line the way rclone expects it.
 func (o ConfigOptions) writtenFields() []configField {
 	switch o.Backend {
 	case BackendS3:
@@ -103,6 +201,34 @@ func (o ConfigOptions) writtenFields() []configField {
 			{"storage-account", o.StorageAccount},
 			{"storage-key", o.StorageKey},
 		}
+	case BackendSFTP:
+		return []configField{
+			{"sftp-host", o.SFTPHost},
+			{"sftp-user", o.SFTPUser},
+			{"sftp-password", o.SFTPPassword},
+		}
+	case BackendWebDAV:
+		return []configField{
+			{"webdav-url", o.WebDAVURL},
+			{"webdav-vendor", o.WebDAVVendor},
+			{"webdav-user", o.WebDAVUser},
+			{"webdav-password", o.WebDAVPassword},
+			{"webdav-bearer-token", o.WebDAVBearerToken},
+		}
+	case BackendSwift:
+		return []configField{
+			{"swift-auth-url", o.SwiftAuthURL},
+			{"swift-user", o.SwiftUser},
+			{"swift-key", o.SwiftKey},
+			{"swift-tenant", o.SwiftTenant},
+			{"swift-domain", o.SwiftDomain},
+			{"region", o.Region},
+		}
+	case BackendB2:
+		return []configField{
+			{"b2-account", o.B2Account},
+			{"b2-key", o.B2Key},
+		}
 	default:
 		return nil
 	}
@@ -124,6 +250,38 @@ func validateConfigValue(name, value string) error {
 	return nil
 }
 
+// ConfigHasRemote reports whether an rclone.conf declares a remote of the given
+// name. rclone reads a section header with its surrounding whitespace trimmed, so
+// this does too.
+func ConfigHasRemote(conf, name string) bool {
+	for line := range strings.Lines(conf) {
+		line = strings.TrimSpace(line)
+
+		if section, ok := strings.CutPrefix(line, "["); ok {
+			if section, ok = strings.CutSuffix(section, "]"); ok && strings.TrimSpace(section) == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ValidateRemoteName rejects a remote name that cannot be the first part of a
+// remote path, where a colon ends the name and a slash would make rclone read
+// the whole path as a local one.
+func ValidateRemoteName(name string) error {
+	if name == "" {
+		return errors.New("remote name must not be empty")
+	}
+
+	if strings.ContainsAny(name, ":/\\\r\n\x00") {
+		return fmt.Errorf("remote name %q must not contain ':', '/', '\\' or line breaks", name)
+	}
+
+	return nil
+}
+
 // ReadConfigFile reads a raw rclone.conf file from disk.
 func ReadConfigFile(path string) (string, error) {
 	data, err := os.ReadFile(path)
@@ -137,7 +295,7 @@ func ReadConfigFile(path string) (string, error) {
 func generateS3Config(opts ConfigOptions) (string, error) {
 	var builder strings.Builder
 
-	fmt.Fprintf(&builder, "[%s]\n", remoteName)
+	fmt.Fprintf(&builder, "[%s]\n", opts.remoteName())
 	builder.WriteString("type = s3\n")
 
 	provider := opts.Provider
@@ -162,7 +320,7 @@ func generateS3Config(opts ConfigOptions) (string, error) {
 	case opts.AccessKey != "" || opts.SecretKey != "":
 		return "", errors.New("both access-key and secret-key must be provided together")
 	default:
-		builder.WriteString("env_auth = true\n")
+		writeEnvAuth(&builder, opts)
 	}
 
 	builder.WriteString("no_check_bucket = true\n")
@@ -173,7 +331,7 @@ func generateS3Config(opts ConfigOptions) (string, error) {
 func generateAzureConfig(opts ConfigOptions) string {
 	var builder strings.Builder
 
-	fmt.Fprintf(&builder, "[%s]\n", remoteName)
+	fmt.Fprintf(&builder, "[%s]\n", opts.remoteName())
 	builder.WriteString("type = azureblob\n")
 
 	if opts.StorageAccount != "" {
@@ -183,7 +341,7 @@ func generateAzureConfig(opts ConfigOptions) string {
 	if opts.StorageKey != "" {
 		fmt.Fprintf(&builder, "key = %s\n", opts.StorageKey)
 	} else {
-		builder.WriteString("env_auth = true\n")
+		writeEnvAuth(&builder, opts)
 	}
 
 	return builder.String()
@@ -192,7 +350,7 @@ func generateAzureConfig(opts ConfigOptions) string {
 func generateGCSConfig(opts ConfigOptions) (string, error) {
 	var builder strings.Builder
 
-	fmt.Fprintf(&builder, "[%s]\n", remoteName)
+	fmt.Fprintf(&builder, "[%s]\n", opts.remoteName())
 	builder.WriteString("type = google cloud storage\n")
 
 	if opts.GCSBucketPolicyOnly == nil || *opts.GCSBucketPolicyOnly {
@@ -207,7 +365,7 @@ func generateGCSConfig(opts ConfigOptions) (string, error) {
 
 		fmt.Fprintf(&builder, "service_account_credentials = %s\n", compacted)
 	} else {
-		builder.WriteString("env_auth = true\n")
+		writeEnvAuth(&builder, opts)
 	}
 
 	return builder.String(), nil
@@ -223,3 +381,272 @@ func compactJSON(input string) (string, error) {
 
 	return buf.String(), nil
 }
+
+func generateSFTPConfig(opts ConfigOptions) (string, error) {
+	if opts.SFTPHost == "" {
+		return "", errors.New("--sftp-host is required for the sftp backend")
+	}
+
+	if opts.SFTPPort < 0 || opts.SFTPPort > maxPort {
+		return "", fmt.Errorf("--sftp-port %d is out of range (1-%d, or 0 for the default)", opts.SFTPPort, maxPort)
+	}
+
+	// rclone would fall back to an SSH agent, which the job pod does not have, so
+	// a config without either credential fails only once the job is running.
+	if opts.SFTPPassword == "" && opts.SFTPKeyPEM == "" && !opts.ExternalCredentials {
+		return "", errors.New("either --sftp-password or --sftp-key-file must be provided for the sftp backend")
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "[%s]\n", opts.remoteName())
+	builder.WriteString("type = sftp\n")
+	fmt.Fprintf(&builder, "host = %s\n", opts.SFTPHost)
+
+	if opts.SFTPPort != 0 {
+		fmt.Fprintf(&builder, "port = %d\n", opts.SFTPPort)
+	}
+
+	if opts.SFTPUser != "" {
+		fmt.Fprintf(&builder, "user = %s\n", opts.SFTPUser)
+	}
+
+	if opts.SFTPPassword != "" {
+		obscured, err := obscure(opts.SFTPPassword)
+		if err != nil {
+			return "", fmt.Errorf("failed to obscure --sftp-password: %w", err)
+		}
+
+		fmt.Fprintf(&builder, "pass = %s\n", obscured)
+	}
+
+	if opts.SFTPKeyPEM != "" {
+		keyPEM, err := foldPEM(opts.SFTPKeyPEM)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&builder, "key_pem = %s\n", keyPEM)
+	}
+
+	return builder.String(), nil
+}
+
+func generateWebDAVConfig(opts ConfigOptions) (string, error) {
+	if opts.WebDAVURL == "" {
+		return "", errors.New("--webdav-url is required for the webdav backend")
+	}
+
+	if err := validateHTTPURL("webdav-url", opts.WebDAVURL); err != nil {
+		return "", err
+	}
+
+	vendor := opts.WebDAVVendor
+	if vendor == "" {
+		vendor = DefaultWebDAVVendor
+	}
+
+	if !slices.Contains(WebDAVVendors, vendor) {
+		return "", fmt.Errorf("--webdav-vendor %q is not supported, must be one of: %s",
+			vendor, strings.Join(WebDAVVendors, ", "))
+	}
+
+	if opts.WebDAVBearerToken != "" && (opts.WebDAVUser != "" || opts.WebDAVPassword != "") {
+		return "", errors.New("--webdav-bearer-token cannot be combined with --webdav-user or --webdav-password")
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "[%s]\n", opts.remoteName())
+	builder.WriteString("type = webdav\n")
+	fmt.Fprintf(&builder, "url = %s\n", opts.WebDAVURL)
+	fmt.Fprintf(&builder, "vendor = %s\n", vendor)
+
+	if opts.WebDAVUser != "" {
+		fmt.Fprintf(&builder, "user = %s\n", opts.WebDAVUser)
+	}
+
+	if opts.WebDAVPassword != "" {
+		obscured, err := obscure(opts.WebDAVPassword)
+		if err != nil {
+			return "", fmt.Errorf("failed to obscure --webdav-password: %w", err)
+		}
+
+		fmt.Fprintf(&builder, "pass = %s\n", obscured)
+	}
+
+	if opts.WebDAVBearerToken != "" {
+		fmt.Fprintf(&builder, "bearer_token = %s\n", opts.WebDAVBearerToken)
+	}
+
+	return builder.String(), nil
+}
+
+func generateSwiftConfig(opts ConfigOptions) (string, error) {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "[%s]\n", opts.remoteName())
+	builder.WriteString("type = swift\n")
+
+	switch {
+	case opts.SwiftAuthURL != "" && opts.SwiftUser != "" && opts.SwiftKey != "":
+		if err := validateHTTPURL("swift-auth-url", opts.SwiftAuthURL); err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&builder, "auth = %s\n", opts.SwiftAuthURL)
+		fmt.Fprintf(&builder, "user = %s\n", opts.SwiftUser)
+		fmt.Fprintf(&builder, "key = %s\n", opts.SwiftKey)
+	case opts.SwiftAuthURL != "" || opts.SwiftUser != "" || opts.SwiftKey != "":
+		return "", errors.New("swift-auth-url, swift-user and swift-key must be provided together")
+	default:
+		writeEnvAuth(&builder, opts)
+	}
+
+	if opts.SwiftTenant != "" {
+		fmt.Fprintf(&builder, "tenant = %s\n", opts.SwiftTenant)
+	}
+
+	if opts.SwiftDomain != "" {
+		fmt.Fprintf(&builder, "domain = %s\n", opts.SwiftDomain)
+	}
+
+	if opts.Region != "" {
+		fmt.Fprintf(&builder, "region = %s\n", opts.Region)
+	}
+
+	return builder.String(), nil
+}
+
+func generateB2Config(opts ConfigOptions) (string, error) {
+	// B2 has no ambient credentials rclone could pick up inside the pod.
+	if (opts.B2Account == "" || opts.B2Key == "") && !opts.ExternalCredentials {
+		return "", errors.New("both b2-account and b2-key must be provided for the b2 backend")
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "[%s]\n", opts.remoteName())
+	builder.WriteString("type = b2\n")
+	if opts.B2Account != "" {
+		fmt.Fprintf(&builder, "account = %s\n", opts.B2Account)
+	}
+
+	if opts.B2Key != "" {
+		fmt.Fprintf(&builder, "key = %s\n", opts.B2Key)
+	}
+
+	return builder.String(), nil
+}
+
+// writeEnvAuth enables rclone's ambient authentication for a backend that was
+// given no credentials. Credentials supplied from a secret take their place
+// instead: rclone prefers keys over ambient authentication only for some
+// backends, so both together would leave which one is used up to the backend.
+func writeEnvAuth(builder *strings.Builder, opts ConfigOptions) {
+	if opts.ExternalCredentials {
+		return
+	}
+
+	builder.WriteString("env_auth = true\n")
+}
+
+// EnvPrefix returns the prefix rclone reads per-remote options from in the
+// environment: an option of remote "my-remote" is looked up as
+// RCLONE_CONFIG_MY_REMOTE_<OPTION>, and it takes precedence over the config file.
+func EnvPrefix(remote string) string {
+	return "RCLONE_CONFIG_" + strings.ToUpper(strings.ReplaceAll(remote, "-", "_")) + "_"
+}
+
+// RedactedValue stands in for a credential in a redacted config.
+const RedactedValue = "<redacted>"
+
+// safeConfigOptions are the options whose values are kept in a redacted config:
+// the ones that tell where the data went and how rclone reached it, and none
+// that authenticates. A config given with --rclone-config can carry options of
+// any backend, and a credential can hide in one named like a location, such as
+// azureblob's sas_url, so every option not listed here is redacted.
+var safeConfigOptions = []string{
+	"type", "provider", "env_auth", "region", "location_constraint", "endpoint", "storage_class",
+	"account", "host", "port", "user", "url", "vendor", "auth", "tenant", "domain", "project_number",
+	"no_check_bucket", "bucket_policy_only",
+}
+
+// RedactConfig returns the rclone.conf with the values of all but the known safe
+// options replaced, keeping the remotes and what tells where the data went. A
+// URL among those keeps its location, but not the credentials it can carry in
+// its userinfo and query.
+func RedactConfig(config string) string {
+	lines := strings.Split(config, "\n")
+
+	for idx, line := range lines {
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(strings.TrimSpace(line), "[") {
+			continue
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if slices.Contains(safeConfigOptions, name) {
+			lines[idx] = name + " = " + redactURL(strings.TrimSpace(value))
+		} else {
+			lines[idx] = name + " = " + RedactedValue
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// redactURL replaces the userinfo and the query of a URL, either of which can
+// hold a credential. Any other value is returned as it is.
+func redactURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" || (parsed.User == nil && parsed.RawQuery == "") {
+		return value
+	}
+
+	hadUser := parsed.User != nil
+	parsed.User = nil
+
+	if parsed.RawQuery != "" {
+		parsed.RawQuery = RedactedValue
+	}
+
+	redacted := parsed.String()
+	if hadUser {
+		redacted = strings.Replace(redacted, "://", "://"+RedactedValue+"@", 1)
+	}
+
+	return redacted
+}
+
+// validateHTTPURL rejects a value rclone would only fail on once the job runs,
+// which is later and further away from the flag that caused it.
+func validateHTTPURL(name, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("--%s %q is not a valid URL: %w", name, value, err)
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("--%s %q must be an http or https URL", name, value)
+	}
+
+	return nil
+}
+
+// foldPEM puts a PEM block on the single line an rclone.conf entry allows, with
+// its line breaks written as the two characters rclone's key_pem option expects.
+func foldPEM(keyPEM string) (string, error) {
+	normalized := strings.TrimSpace(strings.ReplaceAll(keyPEM, "\r\n", "\n"))
+
+	if block, _ := pem.Decode([]byte(normalized)); block == nil {
+		return "", errors.New("--sftp-key-file does not contain a PEM-encoded private key")
+	}
+
+	if strings.ContainsAny(normalized, "\r\x00") {
+		return "", errors.New("--sftp-key-file must not contain carriage returns or NUL bytes")
+	}
+
+	return strings.ReplaceAll(normalized, "\n", `\n`), nil
+}
@@ -0,0 +1,129 @@
+package rclone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/utkuozdemir/pv-migrate/internal/shell"
+)
+
+// Filter selects the files a transfer covers, with rclone's filter flags. rclone
+// applies --include and --exclude in an order of its own rather than the order
+// they are given in, so the two are not combined: Rules say in which order they
+// apply instead.
+type Filter struct {
+	Include []string
+	Exclude []string
+	// Rules are filter rules as the lines of an rclone filter file hold them, an
+	// include as "+ pattern" and an exclude as "- pattern", applied in order.
+	Rules []string
+}
+
+// IsEmpty reports whether the filter lets every file through.
+func (f *Filter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.Rules) == 0
+}
+
+// Validate checks that the filter can be passed to rclone as it is.
+func (f *Filter) Validate() error {
+	if len(f.Include) > 0 && len(f.Exclude) > 0 {
+		return errors.New("--include and --exclude cannot be used together, " +
+			"since rclone does not apply them in the order given: write them as rules in a --filter-from file")
+	}
+
+	if len(f.Rules) > 0 && (len(f.Include) > 0 || len(f.Exclude) > 0) {
+		return errors.New("--filter-from cannot be combined with --include or --exclude")
+	}
+
+	for _, patterns := range []struct {
+		flag   string
+		values []string
+	}{
+		{"--include", f.Include},
+		{"--exclude", f.Exclude},
+	} {
+		for _, pattern := range patterns.values {
+			if err := validatePattern(patterns.flag, pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, rule := range f.Rules {
+		pattern, ok := cutRuleAction(rule)
+		if !ok {
+			return fmt.Errorf("invalid filter rule %q: must start with \"+ \" or \"- \"", rule)
+		}
+
+		if err := validatePattern("filter rule", pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// args renders the filter as rclone flags, each value a single shell word.
+func (f *Filter) args() string {
+	args := make([]string, 0, len(f.Include)+len(f.Exclude)+len(f.Rules))
+
+	for _, pattern := range f.Include {
+		args = append(args, "--include "+shell.Quote(pattern))
+	}
+
+	for _, pattern := range f.Exclude {
+		args = append(args, "--exclude "+shell.Quote(pattern))
+	}
+
+	for _, rule := range f.Rules {
+		args = append(args, "--filter "+shell.Quote(rule))
+	}
+
+	return strings.Join(args, " ")
+}
+
+// ParseFilterRules reads the rules of an rclone filter file, the way rclone's
+// --filter-from does: blank lines and comments starting with "#" or ";" are
+// skipped, and a line holding "!" clears the rules before it. The rules are
+// validated with the filter.
+func ParseFilterRules(content string) ([]string, error) {
+	var rules []string
+
+	for idx, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+			continue
+		case line == "!":
+			rules = nil
+
+			continue
+		}
+
+		if _, ok := cutRuleAction(line); !ok {
+			return nil, fmt.Errorf("line %d: invalid filter rule %q: must start with \"+ \" or \"- \"", idx+1, line)
+		}
+
+		rules = append(rules, line)
+	}
+
+	return rules, nil
+}
+
+func cutRuleAction(rule string) (string, bool) {
+	if pattern, ok := strings.CutPrefix(rule, "+ "); ok {
+		return pattern, true
+	}
+
+	return strings.CutPrefix(rule, "- ")
+}
+
+func validatePattern(name, pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("%s pattern must not be empty", name)
+	}
+
+	return shell.CheckSingleLine(name+" pattern", pattern)
+}
@@ -0,0 +1,116 @@
+package rclone_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestFilterValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		filter  rclone.Filter
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "include", filter: rclone.Filter{Include: []string{"db/**", "*.{jpg,png}"}}},
+		{name: "exclude", filter: rclone.Filter{Exclude: []string{"cache/**"}}},
+		{name: "rules", filter: rclone.Filter{Rules: []string{"- db/tmp/**", "+ db/**", "- **"}}},
+		{
+			name:    "include and exclude",
+			filter:  rclone.Filter{Include: []string{"db/**"}, Exclude: []string{"db/tmp/**"}},
+			wantErr: "--include and --exclude cannot be used together",
+		},
+		{
+			name:    "rules and include",
+			filter:  rclone.Filter{Include: []string{"db/**"}, Rules: []string{"- **"}},
+			wantErr: "--filter-from cannot be combined with --include or --exclude",
+		},
+		{
+			name:    "empty pattern",
+			filter:  rclone.Filter{Exclude: []string{" "}},
+			wantErr: "--exclude pattern must not be empty",
+		},
+		{
+			name:    "newline",
+			filter:  rclone.Filter{Include: []string{"db\n**"}},
+			wantErr: `--include pattern must not contain the character '\n'`,
+		},
+		{
+			name:    "rule without action",
+			filter:  rclone.Filter{Rules: []string{"db/**"}},
+			wantErr: `invalid filter rule "db/**"`,
+		},
+		{
+			name:    "rule without pattern",
+			filter:  rclone.Filter{Rules: []string{"+  "}},
+			wantErr: "filter rule pattern must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.filter.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseFilterRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := rclone.ParseFilterRules(`# a comment
++ old/**
+!
+; another comment
+
+- db/tmp/**
++ db/**
+  - **
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"- db/tmp/**", "+ db/**", "- **"}, rules)
+
+	_, err = rclone.ParseFilterRules("+ db/**\ndb/tmp/**\n")
+	require.ErrorContains(t, err, `line 2: invalid filter rule "db/tmp/**"`)
+}
+
+func TestBuildCommand_RestoreWithFilter(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.Cmd{
+		Direction:  rclone.DirectionRestore,
+		RemotePath: "remote:my-bucket/pv-migrate/my-backup/",
+		LocalPath:  "/data",
+		Filter:     rclone.Filter{Rules: []string{"+ it's/**", "- **"}},
+		ExtraArgs:  "--transfers 8",
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"rclone copy --stats 1s --stats-log-level NOTICE --use-json-log --stats-one-line "+
+			"'remote:my-bucket/pv-migrate/my-backup/' '/data' "+
+			`--filter '+ it'"'"'s/**' --filter '- **' --transfers 8`,
+		result,
+	)
+
+	cmd.Filter = rclone.Filter{Include: []string{"db/**"}, Exclude: []string{"db/tmp/**"}}
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "--include and --exclude cannot be used together")
+}
@@ -0,0 +1,181 @@
+package rclone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/utkuozdemir/pv-migrate/internal/shell"
+)
+
+// ManifestSuffix ends the name of the integrity manifest of a backup, next to its
+// metadata sidecar.
+const ManifestSuffix = ".manifest.sha256"
+
+// ManifestHash is the hash type the manifest lists. rclone computes it for local
+// files whatever the backend supports.
+const ManifestHash = "sha256"
+
+// manifestFile is where the manifest is assembled in the pod, and manifestPart
+// where the listing of one PVC is kept before it joins it. A check downloads the
+// manifest to manifestFile, and writes the sum file it reads to manifestSumFile.
+const (
+	manifestFile    = "/tmp/pv-migrate-manifest.sha256"
+	manifestPart    = "/tmp/pv-migrate-manifest.part"
+	manifestSumFile = "/tmp/pv-migrate-manifest.sum"
+)
+
+// manifestSeparator separates the fields of a manifest line. It is what
+// sha256sum puts between the hash and the path, so dropping the size leaves a
+// line in its format.
+const manifestSeparator = "  "
+
+// ManifestSource is a directory of the volume whose files the manifest lists.
+type ManifestSource struct {
+	LocalPath string
+	// Dir is where the files are in the backup, relative to its root, such as the
+	// directory of a PVC of a set. It is empty for a backup of one PVC.
+	Dir string
+}
+
+// ManifestCmd holds the parameters for building the integrity manifest of a
+// backup and uploading it.
+type ManifestCmd struct {
+	Sources    []ManifestSource
+	RemotePath string
+	ConfigPath string
+	ExtraArgs  string
+}
+
+// Build produces the manifest as a shell command that lists the files of every
+// source with rclone lsf and uploads the result to RemotePath. A line of the
+// manifest is the hash, the size in bytes and the path relative to the root of
+// the backup, separated by two spaces. The files are read from the volume, so
+// the manifest records them as they were when it was built, after the sync.
+func (c *ManifestCmd) Build() (string, error) {
+	if len(c.Sources) == 0 {
+		return "", errors.New("a manifest needs at least one source")
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"manifest remote path", c.RemotePath},
+		{"rclone config path", c.ConfigPath},
+	} {
+		if err := shell.CheckSingleLine(field.name, field.value); err != nil {
+			return "", err
+		}
+	}
+
+	config := ""
+	if c.ConfigPath != "" {
+		config = " --config " + shell.Quote(c.ConfigPath)
+	}
+
+	extraArgs := ""
+	if c.ExtraArgs != "" {
+		extraArgs = " " + c.ExtraArgs
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, ": > %s", manifestFile)
+
+	for _, source := range c.Sources {
+		if err := shell.CheckSingleLine("--path", source.LocalPath); err != nil {
+			return "", err
+		}
+
+		// The directory goes into a sed replacement, where these are special.
+		if strings.ContainsAny(source.Dir, "|\\&\n") {
+			return "", fmt.Errorf("invalid manifest directory: %q", source.Dir)
+		}
+
+		fmt.Fprintf(&builder, " && rclone lsf%s -R --files-only --format hsp --hash %s --separator %s %s%s > %s",
+			config, ManifestHash, shell.Quote(manifestSeparator), shell.Quote(source.LocalPath), extraArgs,
+			manifestPart)
+
+		if source.Dir == "" {
+			fmt.Fprintf(&builder, " && cat %s >> %s", manifestPart, manifestFile)
+
+			continue
+		}
+
+		// The hash and the size hold no spaces, so the path starts after the second
+		// separator.
+		fmt.Fprintf(&builder, " && sed %s %s >> %s",
+			shell.Quote(`s|^\([^ ]*  [0-9]*\)  |\1  `+strings.TrimSuffix(source.Dir, "/")+`/|`), manifestPart,
+			manifestFile)
+	}
+
+	fmt.Fprintf(&builder, " && rclone copyto%s %s %s%s",
+		config, manifestFile, shell.Quote(c.RemotePath), extraArgs)
+
+	return builder.String(), nil
+}
+
+// ManifestCheckCmd holds the parameters for checking the objects of a backup
+// against its integrity manifest.
+type ManifestCheckCmd struct {
+	// ManifestPath is the remote path of the manifest.
+	ManifestPath string
+	// RemotePath is the root of the backup, which the paths of the manifest are
+	// relative to.
+	RemotePath string
+	ConfigPath string
+	ExtraArgs  string
+}
+
+// Build produces the check as a shell command that downloads every object the
+// manifest lists and compares its hash with the one listed, with rclone checksum,
+// so that neither the backend's listing nor its hashes are trusted. It reports
+// like a CheckCmd. A backup without a manifest, as taken before manifests or with
+// --no-manifest, has nothing to check against and passes.
+func (c *ManifestCheckCmd) Build() (string, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"manifest remote path", c.ManifestPath},
+		{"remote path", c.RemotePath},
+		{"rclone config path", c.ConfigPath},
+	} {
+		if err := shell.CheckSingleLine(field.name, field.value); err != nil {
+			return "", err
+		}
+	}
+
+	config := ""
+	if c.ConfigPath != "" {
+		config = " --config " + shell.Quote(c.ConfigPath)
+	}
+
+	extraArgs := ""
+	if c.ExtraArgs != "" {
+		extraArgs = " " + c.ExtraArgs
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "{ rclone copyto%s %s %s%s; manifest_rc=$?; case $manifest_rc in 0) ",
+		config, shell.Quote(c.ManifestPath), manifestFile, extraArgs)
+
+	// rclone checksum reads the format of sha256sum, which the manifest is in once
+	// the size is taken out.
+	fmt.Fprintf(&builder, "sed %s %s > %s",
+		shell.Quote(`s|^\([^ ]*\)  [0-9][0-9]*  |\1  |`), manifestFile, manifestSumFile)
+	fmt.Fprintf(&builder, " && rclone checksum %s%s --download --use-json-log --one-way --combined %s %s %s%s",
+		ManifestHash, config, checkReportFile, manifestSumFile, shell.Quote(c.RemotePath), extraArgs)
+
+	// The manifest is the source of this check and the bucket the destination,
+	// so the symbols are turned into the kinds that say so.
+	writeCheckReport(&builder, "", `s|^- |? |; s|^\* |~ |; `)
+
+	// rclone copyto fails with 3 or 4 when there is no manifest to download.
+	builder.WriteString(" ;; 3|4) echo 'no integrity manifest, the bucket is not checked against one' ;; " +
+		"*) false ;; esac; }")
+
+	return builder.String(), nil
+}
@@ -0,0 +1,166 @@
+package rclone_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestBuildManifestCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.ManifestCmd{
+		Sources:    []rclone.ManifestSource{{LocalPath: "/data"}},
+		RemotePath: rclone.BuildManifestRemotePath("remote", "my-bucket", "pv-migrate", "app-data"),
+		ConfigPath: "/etc/rclone/rclone.conf",
+		ExtraArgs:  "--exclude cache/**",
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Equal(t, ": > /tmp/pv-migrate-manifest.sha256 && "+
+		"rclone lsf --config '/etc/rclone/rclone.conf' -R --files-only --format hsp --hash sha256 --separator '  ' "+
+		"'/data' --exclude cache/** > /tmp/pv-migrate-manifest.part && "+
+		"cat /tmp/pv-migrate-manifest.part >> /tmp/pv-migrate-manifest.sha256 && "+
+		"rclone copyto --config '/etc/rclone/rclone.conf' /tmp/pv-migrate-manifest.sha256 "+
+		"'remote:my-bucket/pv-migrate/app-data.manifest.sha256' --exclude cache/**", result)
+
+	_, err = (&rclone.ManifestCmd{RemotePath: "remote:b/p/n.manifest.sha256"}).Build()
+	require.ErrorContains(t, err, "at least one source")
+
+	cmd.Sources = []rclone.ManifestSource{{LocalPath: "/data/db", Dir: "a|b"}}
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "invalid manifest directory")
+}
+
+// TestManifestCommandListsEverySource runs the manifest under a real shell with a
+// stand-in rclone, to check that the files of a set are listed under the
+// directory of their PVC, as they are in the backup.
+func TestManifestCommandListsEverySource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	uploaded := filepath.Join(dir, "uploaded")
+	fakeRclone := `#!/bin/sh
+for arg; do
+  case "$arg" in
+    lsf|copyto) action=$arg ;;
+    /data/*) src=$arg ;;
+    /tmp/*) [ "$action" = copyto ] && manifest=$arg ;;
+  esac
+done
+case "$action" in
+  lsf)
+    case "$src" in
+      /data/db) printf 'aaa  3  base/PG_VERSION\n' ;;
+      /data/cache) printf 'bbb  10  two words.txt\nccc  0  x  y\n' ;;
+    esac ;;
+  copyto) cp "$manifest" "$UPLOADED" ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rclone"), []byte(fakeRclone), 0o755)) //nolint:gosec
+
+	cmd := rclone.ManifestCmd{
+		Sources: []rclone.ManifestSource{
+			{LocalPath: "/data/db", Dir: "db"},
+			{LocalPath: "/data/cache", Dir: "cache"},
+		},
+		RemotePath: "remote:my-bucket/pv-migrate/app.manifest.sha256",
+	}
+
+	script, err := cmd.Build()
+	require.NoError(t, err)
+
+	run := exec.CommandContext(t.Context(), "sh", "-c", script)
+	run.Env = append(os.Environ(),
+		"PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"), "UPLOADED="+uploaded)
+
+	out, err := run.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	manifest, err := os.ReadFile(uploaded)
+	require.NoError(t, err)
+	assert.Equal(t, "aaa  3  db/base/PG_VERSION\nbbb  10  cache/two words.txt\nccc  0  cache/x  y\n",
+		string(manifest))
+}
+
+// TestManifestCheckCommand runs the check under a real shell with a stand-in
+// rclone, to check that rclone checksum is given the manifest without its sizes,
+// and that what it finds is reported as differences from the manifest.
+//
+//nolint:paralleltest // it writes the manifest and the report to the same paths in /tmp as the other checks
+func TestManifestCheckCommand(t *testing.T) {
+	dir := t.TempDir()
+	fakeRclone := `#!/bin/sh
+prev=""
+for arg; do
+  case "$arg" in
+    copyto|checksum) action=$arg ;;
+  esac
+  case "$prev" in
+    --combined) report=$arg ;;
+  esac
+  prev=$arg
+done
+case "$action" in
+  copyto)
+    [ -n "$NO_MANIFEST" ] && exit 3
+    printf 'aaa  3  db/base/PG_VERSION\nbbb  10  db/two  words.txt\n' > /tmp/pv-migrate-manifest.sha256 ;;
+  checksum)
+    cp /tmp/pv-migrate-manifest.sum "$SUMS"
+    printf '= db/base/PG_VERSION\n* db/two  words.txt\n- db/gone.txt\n' > "$report"
+    exit 1 ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rclone"), []byte(fakeRclone), 0o755)) //nolint:gosec
+
+	cmd := rclone.ManifestCheckCmd{
+		ManifestPath: "remote:my-bucket/pv-migrate/app.manifest.sha256",
+		RemotePath:   "remote:my-bucket/pv-migrate/app/",
+	}
+
+	script, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Contains(t, script, "rclone checksum sha256 --download --use-json-log --one-way --combined "+
+		"/tmp/pv-migrate-check.txt /tmp/pv-migrate-manifest.sum 'remote:my-bucket/pv-migrate/app/'")
+
+	t.Cleanup(func() {
+		for _, file := range []string{
+			"/tmp/pv-migrate-manifest.sha256", "/tmp/pv-migrate-manifest.sum", "/tmp/pv-migrate-check.txt",
+		} {
+			_ = os.Remove(file)
+		}
+	})
+
+	sums := filepath.Join(dir, "sums")
+	env := append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"), "SUMS="+sums)
+
+	run := exec.CommandContext(t.Context(), "sh", "-c", script)
+	run.Env = env
+
+	out, err := run.CombinedOutput()
+	require.Error(t, err, "the check should fail when the bucket differs from the manifest")
+
+	written, err := os.ReadFile(sums)
+	require.NoError(t, err)
+	assert.Equal(t, "aaa  db/base/PG_VERSION\nbbb  db/two  words.txt\n", string(written))
+
+	assert.Equal(t, rclone.CheckReport{Total: 2, Differences: []rclone.CheckDifference{
+		{Kind: rclone.DifferenceManifestContent, Path: "db/two  words.txt"},
+		{Kind: rclone.DifferenceManifestMissing, Path: "db/gone.txt"},
+	}}, rclone.ParseCheckReport(string(out)))
+
+	run = exec.CommandContext(t.Context(), "sh", "-c", script)
+	run.Env = append(env, "NO_MANIFEST=1")
+
+	out, err = run.CombinedOutput()
+	require.NoError(t, err, "a backup without a manifest has nothing to be checked against: %s", out)
+	assert.Contains(t, string(out), "no integrity manifest")
+}
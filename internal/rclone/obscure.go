@@ -0,0 +1,40 @@
+package rclone
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// obscureKey is the fixed key rclone uses to obscure passwords in its config.
+// It is public, so obscuring is not encryption: it only keeps a password from
+// being read at a glance. rclone refuses a plain-text password for the options
+// that take one, which is the reason to do it at all.
+var obscureKey = []byte{
+	0x9c, 0x93, 0x5b, 0x48, 0x73, 0x0a, 0x55, 0x4d,
+	0x6b, 0xfd, 0x7c, 0x63, 0xc8, 0x86, 0xa9, 0x2b,
+	0xd3, 0x90, 0x19, 0x8e, 0xb8, 0x12, 0x8a, 0xfb,
+	0xf4, 0xde, 0x16, 0x2b, 0x8b, 0x95, 0xf6, 0x38,
+}
+
+// obscure produces the same encoding as `rclone obscure`: AES-CTR under the
+// fixed key with a random IV, the IV prepended, base64url without padding.
+func obscure(plaintext string) (string, error) {
+	block, err := aes.NewCipher(obscureKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	ciphertext := make([]byte, aes.BlockSize+len(plaintext))
+	iv := ciphertext[:aes.BlockSize]
+
+	if _, err = rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate IV: %w", err)
+	}
+
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext[aes.BlockSize:], []byte(plaintext))
+
+	return base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
@@ -0,0 +1,155 @@
+package rclone
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/utkuozdemir/pv-migrate/internal/shell"
+)
+
+// MetadataSuffix ends the name of the metadata sidecar of a backup, next to its
+// directory.
+const MetadataSuffix = ".meta.yaml"
+
+// BackupListPrefix marks the lines of a backup listing in the job's log.
+const BackupListPrefix = "pv-migrate backup: "
+
+// backupListFile is where the listing of the metadata files is kept in the pod.
+const backupListFile = "/tmp/pv-migrate-backups.txt"
+
+// ListBackupsCmd holds the parameters for listing the backups in a directory of
+// the bucket, by their metadata sidecars, along with the time each was taken.
+type ListBackupsCmd struct {
+	// DirPath is the directory holding the backups, <remote>:<bucket>/<prefix>/.
+	DirPath    string
+	ConfigPath string
+	// Name narrows the listing to the versions of one backup: the backup of that
+	// name, and those named <name>-<version>, where the version starts with a
+	// digit. The filter rclone applies is coarser than a version, so the listing
+	// still has to go through VersionsOf. It lists every backup when empty.
+	Name      string
+	ExtraArgs string
+}
+
+// Build produces the listing as a shell command that prints a report line with
+// the backup time and the metadata file of every backup found. A backup whose
+// metadata cannot be read is listed without a time.
+func (c *ListBackupsCmd) Build() (string, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"remote path", c.DirPath},
+		{"rclone config path", c.ConfigPath},
+		{"--name", c.Name},
+	} {
+		if err := shell.CheckSingleLine(field.name, field.value); err != nil {
+			return "", err
+		}
+	}
+
+	config := ""
+	if c.ConfigPath != "" {
+		config = " --config " + shell.Quote(c.ConfigPath)
+	}
+
+	includes := " --include " + shell.Quote("*"+MetadataSuffix)
+	if c.Name != "" {
+		includes = fmt.Sprintf(" --include %s --include %s",
+			shell.Quote(c.Name+MetadataSuffix), shell.Quote(c.Name+"-[0-9]*"+MetadataSuffix))
+	}
+
+	extraArgs := ""
+	if c.ExtraArgs != "" {
+		extraArgs = " " + c.ExtraArgs
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "dir=%s; rclone%s lsf --files-only%s%s \"$dir\" > %s; list_rc=$?",
+		shell.Quote(c.DirPath), config, includes, extraArgs, backupListFile)
+	fmt.Fprintf(&builder, "; while IFS= read -r file; do "+
+		"time=$(rclone%s cat \"$dir$file\" | sed -n 's/^backupTime: *//p' | head -n 1); "+
+		"echo %s\"$time $file\"; done < %s",
+		config, shell.Quote(BackupListPrefix), backupListFile)
+	builder.WriteString(`; [ "$list_rc" -eq 0 ]`)
+
+	return builder.String(), nil
+}
+
+// BackupVersion is a backup found by a listing.
+type BackupVersion struct {
+	Name string
+	// BackupTime is zero when the metadata could not be read.
+	BackupTime time.Time
+}
+
+// VersionsOf keeps the backups that are versions of the backup named name: the
+// backup itself, and those named <name>-<version>, where the version is all
+// digits, as in the names of the runs of a schedule. A suffix of anything else
+// names another backup, such as data-app-0 next to data, so it is left out.
+func VersionsOf(name string, versions []BackupVersion) []BackupVersion {
+	var kept []BackupVersion
+
+	for _, version := range versions {
+		if isVersionOf(name, version.Name) {
+			kept = append(kept, version)
+		}
+	}
+
+	return kept
+}
+
+func isVersionOf(name, backup string) bool {
+	if backup == name {
+		return true
+	}
+
+	suffix, found := strings.CutPrefix(backup, name+"-")
+	if !found || suffix == "" {
+		return false
+	}
+
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseBackupList collects the backups a listing reported in a job's log.
+func ParseBackupList(log string) []BackupVersion {
+	var versions []BackupVersion
+
+	for line := range strings.SplitSeq(log, "\n") {
+		// Anchored at the start, as for the verification report, to leave out the
+		// shell's trace of the command that prints a line.
+		entry, found := strings.CutPrefix(strings.TrimRight(line, "\r"), BackupListPrefix)
+		if !found {
+			continue
+		}
+
+		backupTime, file, found := strings.Cut(entry, " ")
+		if !found {
+			continue
+		}
+
+		name, found := strings.CutSuffix(file, MetadataSuffix)
+		if !found || name == "" {
+			continue
+		}
+
+		version := BackupVersion{Name: name}
+
+		if parsed, err := time.Parse(time.RFC3339Nano, strings.Trim(backupTime, `"'`)); err == nil {
+			version.BackupTime = parsed
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions
+}
@@ -0,0 +1,118 @@
+package rclone_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rclone"
+)
+
+func TestBuildListBackupsCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := rclone.ListBackupsCmd{
+		DirPath:    rclone.BuildBackupsDirPath("remote", "my-bucket", "pv-migrate"),
+		ConfigPath: "/etc/rclone/rclone.conf",
+		Name:       "app-data",
+	}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Contains(t, result, "dir='remote:my-bucket/pv-migrate/'; rclone --config '/etc/rclone/rclone.conf' "+
+		"lsf --files-only --include 'app-data.meta.yaml' --include 'app-data-[0-9]*.meta.yaml' \"$dir\"")
+
+	cmd.Name = ""
+
+	result, err = cmd.Build()
+	require.NoError(t, err)
+	assert.Contains(t, result, "--include '*.meta.yaml' \"$dir\"")
+
+	cmd.Name = "app\ndata"
+
+	_, err = cmd.Build()
+	require.Error(t, err)
+}
+
+// TestListBackupsCommandReportsBackups runs the listing under a real shell with a
+// stand-in rclone, so the report lines the job prints are the ones
+// ParseBackupList reads.
+func TestListBackupsCommandReportsBackups(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fakeRclone := `#!/bin/sh
+eval last=\${$#}
+for arg; do
+  case "$arg" in
+    lsf) printf 'app-data-2026-04-10.meta.yaml\napp-data-2026-04-11.meta.yaml\napp-data-3.meta.yaml\n'; exit 0 ;;
+    cat) break ;;
+  esac
+done
+case "$last" in
+  *04-10*) echo 'sourcePvc: app-data'; echo 'backupTime: 2026-04-10T02:00:00Z' ;;
+  *04-11*) echo 'backupTime: "2026-04-11T02:00:00.5Z"' ;;
+  *) exit 1 ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rclone"), []byte(fakeRclone), 0o755)) //nolint:gosec
+
+	cmd := rclone.ListBackupsCmd{DirPath: "remote:my-bucket/pv-migrate/", Name: "app-data"}
+
+	script, err := cmd.Build()
+	require.NoError(t, err)
+
+	run := exec.CommandContext(t.Context(), "sh", "-c", script)
+	run.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	out, err := run.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	assert.Equal(t, []rclone.BackupVersion{
+		{Name: "app-data-2026-04-10", BackupTime: time.Date(2026, 4, 10, 2, 0, 0, 0, time.UTC)},
+		{Name: "app-data-2026-04-11", BackupTime: time.Date(2026, 4, 11, 2, 0, 0, 500000000, time.UTC)},
+		{Name: "app-data-3"},
+	}, rclone.ParseBackupList(string(out)))
+}
+
+func TestVersionsOf(t *testing.T) {
+	t.Parallel()
+
+	versions := []rclone.BackupVersion{
+		{Name: "data"},
+		{Name: "data-29541600"},
+		{Name: "data-app-0"},
+		{Name: "data-0a"},
+		{Name: "data-"},
+		{Name: "data-2026-04-10"},
+		{Name: "database-1"},
+		{Name: "pg-1"},
+	}
+
+	assert.Equal(t, []rclone.BackupVersion{{Name: "data"}, {Name: "data-29541600"}},
+		rclone.VersionsOf("data", versions))
+	assert.Equal(t, []rclone.BackupVersion{{Name: "data-app-0"}}, rclone.VersionsOf("data-app", versions),
+		"a backup with a version-like suffix is still a backup of its own")
+	assert.Empty(t, rclone.VersionsOf("pg-2", versions))
+}
+
+func TestParseBackupList(t *testing.T) {
+	t.Parallel()
+
+	log := `+ echo 'pv-migrate backup: 2026-04-09T02:00:00Z traced.meta.yaml'
+pv-migrate backup: 2026-04-10T02:00:00Z a.meta.yaml
+pv-migrate backup:  b.meta.yaml
+pv-migrate backup: 2026-04-10T02:00:00Z not-metadata.txt
+pv-migrate backup: no-space
+`
+
+	assert.Equal(t, []rclone.BackupVersion{
+		{Name: "a", BackupTime: time.Date(2026, 4, 10, 2, 0, 0, 0, time.UTC)},
+		{Name: "b"},
+	}, rclone.ParseBackupList(log))
+}
@@ -0,0 +1,42 @@
+package rsync
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// bwLimitRate matches what rsync's --bwlimit takes: a rate in KiB per second, or
+// with a size suffix, as in 512, 1.5M or 10MiB. Zero means no limit.
+var bwLimitRate = regexp.MustCompile(`^\d+(\.\d+)?([KMGTPkmgtp]([Ii]?[Bb])?)?$`)
+
+// ValidateBWLimit checks a bandwidth limit for rsync, which takes a single rate
+// and, unlike rclone, no timetable.
+func ValidateBWLimit(limit string) error {
+	if limit == "" || bwLimitRate.MatchString(limit) {
+		return nil
+	}
+
+	if strings.ContainsAny(limit, " ,") {
+		return fmt.Errorf("invalid --bwlimit %q: rsync takes a single rate such as 10M, not a timetable", limit)
+	}
+
+	return fmt.Errorf("invalid --bwlimit %q: must be a rate such as 512 (KiB/s), 1.5M or 10MiB", limit)
+}
+
+// checkBWLimitArgs rejects a bandwidth limit that the extra arguments set as
+// well, which would leave two to pick from.
+func checkBWLimitArgs(limit, extraArgs string) error {
+	if limit == "" {
+		return nil
+	}
+
+	for arg := range strings.FieldsSeq(extraArgs) {
+		if arg == "--bwlimit" || strings.HasPrefix(arg, "--bwlimit=") {
+			return errors.New("--bwlimit is set both as a flag and in the extra rsync arguments")
+		}
+	}
+
+	return nil
+}
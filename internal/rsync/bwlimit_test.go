@@ -0,0 +1,50 @@
+package rsync_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/utkuozdemir/pv-migrate/internal/rsync"
+)
+
+func TestValidateBWLimit(t *testing.T) {
+	t.Parallel()
+
+	for _, limit := range []string{"", "0", "512", "1.5M", "10m", "2G", "10MiB", "100KB"} {
+		assert.NoError(t, rsync.ValidateBWLimit(limit), limit)
+	}
+
+	tests := map[string]string{
+		"08:00,512k 18:00,off": "rsync takes a single rate such as 10M, not a timetable",
+		"10M:100k":             "must be a rate such as 512 (KiB/s), 1.5M or 10MiB",
+		"off":                  "must be a rate",
+		"-1":                   "must be a rate",
+		"10M; reboot":          "not a timetable",
+	}
+
+	for limit, wantErr := range tests {
+		require.ErrorContains(t, rsync.ValidateBWLimit(limit), wantErr, limit)
+	}
+}
+
+func TestBuildWithBWLimit(t *testing.T) {
+	t.Parallel()
+
+	cmd := rsync.Cmd{SrcPath: "/source/", DestPath: "/dest/", BWLimit: "10M"}
+
+	result, err := cmd.Build()
+	require.NoError(t, err)
+	assert.Contains(t, result, " --bwlimit=10M '/source/' '/dest/'")
+
+	cmd.ExtraArgs = "--bwlimit=5M"
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "--bwlimit is set both as a flag and in the extra rsync arguments")
+
+	cmd.BWLimit = "08:00,10M"
+
+	_, err = cmd.Build()
+	require.ErrorContains(t, err, "not a timetable")
+}
@@ -23,7 +23,12 @@ type Cmd struct {
 	DestSSHHost string
 	DestPath    string
 	Compress    bool
-	ExtraArgs   string
+	// BWLimit caps the transfer rate, as rsync's --bwlimit does.
+	BWLimit string
+	// KnownHostsFile is the known_hosts file holding the host key of the remote
+	// sshd. With it, ssh refuses any other key; without it, the key is not checked.
+	KnownHostsFile string
+	ExtraArgs      string
 }
 
 func (c *Cmd) Build() (string, error) {
@@ -70,6 +75,10 @@ func (c *Cmd) args() []string {
 		args = append(args, "--delete")
 	}
 
+	if c.BWLimit != "" {
+		args = append(args, "--bwlimit="+c.BWLimit)
+	}
+
 	if c.ExtraArgs != "" {
 		args = append(args, c.ExtraArgs)
 	}
@@ -78,16 +87,27 @@ func (c *Cmd) args() []string {
 }
 
 func (c *Cmd) sshArgs() []string {
-	args := []string{
-		"ssh",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
+	args := []string{"ssh"}
+
+	if c.KnownHostsFile != "" {
+		args = append(args,
+			"-o", "StrictHostKeyChecking=yes",
+			"-o", "UserKnownHostsFile="+c.KnownHostsFile,
+		)
+	} else {
+		args = append(args,
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+		)
+	}
+
+	args = append(args,
 		"-o", "ConnectTimeout=5",
 		// ServerAliveInterval/CountMax prevent intermediate load balancers and proxies
 		// from dropping idle SSH connections during long file-list-building phases.
 		"-o", "ServerAliveInterval=10",
 		"-o", "ServerAliveCountMax=3",
-	}
+	)
 
 	if c.Port != 0 {
 		args = append(args, "-p", strconv.Itoa(c.Port))
@@ -115,7 +135,11 @@ func (c *Cmd) validate() error {
 		}
 	}
 
-	return nil
+	if err := ValidateBWLimit(c.BWLimit); err != nil {
+		return err
+	}
+
+	return checkBWLimitArgs(c.BWLimit, c.ExtraArgs)
 }
 
 // buildSrc returns the rsync source spec, either a bare path or a
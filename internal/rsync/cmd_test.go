@@ -43,6 +43,24 @@ func TestBuildRemoteSpecs(t *testing.T) {
 		"push mode reads locally and writes to the remote")
 }
 
+func TestBuildPinsTheHostKey(t *testing.T) {
+	t.Parallel()
+
+	pinned, err := (&rsync.Cmd{
+		SrcUseSSH: true, SrcSSHHost: "rel-sshd.ns", SrcPath: "/source/", DestPath: "/dest/",
+		KnownHostsFile: "/tmp/known_hosts",
+	}).Build()
+	require.NoError(t, err)
+	assert.Contains(t, pinned, "-o StrictHostKeyChecking=yes -o UserKnownHostsFile=/tmp/known_hosts ")
+	assert.NotContains(t, pinned, "StrictHostKeyChecking=no")
+
+	unpinned, err := (&rsync.Cmd{
+		SrcUseSSH: true, SrcSSHHost: "rel-sshd.ns", SrcPath: "/source/", DestPath: "/dest/",
+	}).Build()
+	require.NoError(t, err)
+	assert.Contains(t, unpinned, "-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null ")
+}
+
 // TestBuildRejectsUnrenderableCharacters pins the reason these are refused rather
 // than quoted: the built command is interpolated into a YAML block scalar in the
 // Job template, so such a character does not produce a broken command, it produces
@@ -0,0 +1,370 @@
+// Package schedule builds the manifests that run pv-migrate backup from a
+// Kubernetes CronJob: the CronJob itself, a service account with the permissions
+// a backup needs in its namespace, and a secret with the backup's credentials.
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// FilesMountPath is where the files in the credentials secret are mounted in
+	// the pv-migrate container.
+	FilesMountPath = "/etc/pv-migrate"
+
+	// BackupNameEnv is set to the name of the Job of each run, so that args can
+	// refer to it as $(BACKUP_NAME).
+	BackupNameEnv = "BACKUP_NAME"
+
+	// maxCronJobNameLength leaves room for the 11 characters the CronJob
+	// controller appends to name each Job.
+	maxCronJobNameLength = 52
+
+	historyLimit   = 3
+	containerName  = "pv-migrate"
+	filesVolume    = "credentials"
+	componentLabel = "backup-schedule"
+)
+
+// Options describes a scheduled backup.
+type Options struct {
+	// Name is the name of the CronJob and of the objects created along with it.
+	Name      string
+	Namespace string
+	// Schedule is the cron schedule of the CronJob, e.g. "0 2 * * *".
+	Schedule string
+	// TimeZone is the IANA time zone the schedule is evaluated in, or empty for
+	// the controller's.
+	TimeZone string
+	Image    string
+	// Args are the arguments of the pv-migrate container, starting with "backup".
+	Args []string
+	// Env maps environment variables of the container to keys of the credentials
+	// secret.
+	Env map[string]string
+	// Files maps file names under FilesMountPath to keys of the credentials secret.
+	Files map[string]string
+	// SecretData is the content of the credentials secret, which is only created
+	// when it is not empty.
+	SecretData map[string]string
+	// Exec grants the backup exec into pods, which its hooks need.
+	Exec bool
+}
+
+// cronMacros are the macros the CronJob controller accepts in place of five
+// fields.
+var cronMacros = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"}
+
+// Validate checks the options the API server would otherwise reject on apply.
+func (o *Options) Validate() error {
+	if errs := validation.IsDNS1123Subdomain(o.Name); len(errs) > 0 {
+		return fmt.Errorf("schedule name %q is invalid: %s", o.Name, strings.Join(errs, "; "))
+	}
+
+	if len(o.Name) > maxCronJobNameLength {
+		return fmt.Errorf("schedule name %q is longer than %d characters", o.Name, maxCronJobNameLength)
+	}
+
+	if o.Namespace == "" {
+		return errors.New("namespace is required")
+	}
+
+	if fields := strings.Fields(o.Schedule); len(fields) != 5 && !slices.Contains(cronMacros, o.Schedule) { //nolint:mnd
+		return fmt.Errorf("schedule %q must be a cron expression of five fields or one of %s",
+			o.Schedule, strings.Join(cronMacros, ", "))
+	}
+
+	if o.Image == "" {
+		return errors.New("image is required")
+	}
+
+	return nil
+}
+
+// SecretName returns the name of the credentials secret.
+func (o *Options) SecretName() string {
+	return o.Name + "-credentials"
+}
+
+// Objects returns the objects of the scheduled backup in the order they should
+// be applied.
+func (o *Options) Objects() []runtime.Object {
+	var objects []runtime.Object
+
+	if len(o.SecretData) > 0 {
+		objects = append(objects, o.secret())
+	}
+
+	return append(objects, o.serviceAccount(), o.role(), o.roleBinding(), o.cronJob())
+}
+
+// Render returns the objects as a multi-document YAML stream.
+func (o *Options) Render() ([]byte, error) {
+	var buf bytes.Buffer
+
+	for i, obj := range o.Objects() {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %T: %w", obj, err)
+		}
+
+		delete(content, "status")
+
+		data, err := yaml.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %T: %w", obj, err)
+		}
+
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Apply creates the objects of the scheduled backup, or updates them if they exist.
+func (o *Options) Apply(ctx context.Context, cli kubernetes.Interface) error {
+	for _, obj := range o.Objects() {
+		var err error
+
+		switch obj := obj.(type) {
+		case *corev1.Secret:
+			err = createOrUpdate(ctx, cli.CoreV1().Secrets(o.Namespace), obj)
+		case *corev1.ServiceAccount:
+			err = createOrUpdate(ctx, cli.CoreV1().ServiceAccounts(o.Namespace), obj)
+		case *rbacv1.Role:
+			err = createOrUpdate(ctx, cli.RbacV1().Roles(o.Namespace), obj)
+		case *rbacv1.RoleBinding:
+			err = createOrUpdate(ctx, cli.RbacV1().RoleBindings(o.Namespace), obj)
+		case *batchv1.CronJob:
+			err = createOrUpdate(ctx, cli.BatchV1().CronJobs(o.Namespace), obj)
+		default:
+			err = fmt.Errorf("unexpected object type %T", obj)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type namespacedClient[T metav1.Object] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error)
+	Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+}
+
+func createOrUpdate[T metav1.Object](ctx context.Context, cli namespacedClient[T], obj T) error {
+	_, err := cli.Create(ctx, obj, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	}
+
+	if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create %T %s: %w", obj, obj.GetName(), err)
+	}
+
+	existing, err := cli.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get %T %s: %w", obj, obj.GetName(), err)
+	}
+
+	obj.SetResourceVersion(existing.GetResourceVersion())
+
+	if _, err = cli.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update %T %s: %w", obj, obj.GetName(), err)
+	}
+
+	return nil
+}
+
+func (o *Options) objectMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: o.Namespace,
+		Labels:    o.labels(),
+	}
+}
+
+func (o *Options) labels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "pv-migrate",
+		"app.kubernetes.io/instance":   o.Name,
+		"app.kubernetes.io/component":  componentLabel,
+		"app.kubernetes.io/managed-by": "pv-migrate",
+	}
+}
+
+func (o *Options) secret() *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: o.objectMeta(o.SecretName()),
+		Type:       corev1.SecretTypeOpaque,
+		StringData: o.SecretData,
+	}
+}
+
+func (o *Options) serviceAccount() *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: o.objectMeta(o.Name),
+	}
+}
+
+// role grants what a backup does in the PVC's namespace: read the PVC and the
+// pods mounting it, install the chart as a Helm release (stored in secrets), and
+// follow the rclone job. With Exec, it may also run its hooks in the pods.
+func (o *Options) role() *rbacv1.Role {
+	readOnly := []string{"get", "list", "watch"}
+	readWrite := []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"persistentvolumeclaims"}, Verbs: readOnly},
+		{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: readOnly},
+		{APIGroups: []string{""}, Resources: []string{"secrets", "serviceaccounts"}, Verbs: readWrite},
+		{APIGroups: []string{"batch"}, Resources: []string{"jobs"}, Verbs: readWrite},
+		{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"list", "watch"}},
+	}
+
+	if o.Exec {
+		// The websocket protocol opens an exec with a GET, and SPDY with a POST.
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"get", "create"},
+		})
+	}
+
+	return &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+		ObjectMeta: o.objectMeta(o.Name),
+		Rules:      rules,
+	}
+}
+
+func (o *Options) roleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: o.objectMeta(o.Name),
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      o.Name,
+			Namespace: o.Namespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     o.Name,
+		},
+	}
+}
+
+func (o *Options) cronJob() *batchv1.CronJob {
+	var timeZone *string
+	if o.TimeZone != "" {
+		timeZone = &o.TimeZone
+	}
+
+	return &batchv1.CronJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+		ObjectMeta: o.objectMeta(o.Name),
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   o.Schedule,
+			TimeZone:                   timeZone,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: ptr.To[int32](historyLimit),
+			FailedJobsHistoryLimit:     ptr.To[int32](historyLimit),
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					// pv-migrate cleans up after a failed attempt, and a retry of a
+					// failed backup is better left to the next run.
+					BackoffLimit: ptr.To[int32](0),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: o.labels()},
+						Spec:       o.podSpec(),
+					},
+				},
+			},
+		},
+	}
+}
+
+func (o *Options) podSpec() corev1.PodSpec {
+	container := corev1.Container{
+		Name:  containerName,
+		Image: o.Image,
+		Args:  o.Args,
+		Env: []corev1.EnvVar{{
+			Name: BackupNameEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.labels['" + batchv1.JobNameLabel + "']",
+				},
+			},
+		}},
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(o.Env)) {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: o.SecretName()},
+					Key:                  o.Env[name],
+				},
+			},
+		})
+	}
+
+	spec := corev1.PodSpec{
+		ServiceAccountName: o.Name,
+		RestartPolicy:      corev1.RestartPolicyNever,
+		Containers:         []corev1.Container{container},
+	}
+
+	if len(o.Files) == 0 {
+		return spec
+	}
+
+	items := make([]corev1.KeyToPath, 0, len(o.Files))
+	for _, path := range slices.Sorted(maps.Keys(o.Files)) {
+		items = append(items, corev1.KeyToPath{Key: o.Files[path], Path: path})
+	}
+
+	spec.Volumes = []corev1.Volume{{
+		Name: filesVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: o.SecretName(),
+				Items:      items,
+			},
+		},
+	}}
+	spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{
+		Name:      filesVolume,
+		MountPath: FilesMountPath,
+		ReadOnly:  true,
+	}}
+
+	return spec
+}
@@ -0,0 +1,172 @@
+package schedule_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/utkuozdemir/pv-migrate/internal/schedule"
+)
+
+func testOptions() schedule.Options {
+	return schedule.Options{
+		Name:       "app-data",
+		Namespace:  "app",
+		Schedule:   "0 2 * * *",
+		Image:      "docker.io/utkuozdemir/pv-migrate:v2.0.0",
+		Args:       []string{"backup", "--source=app-data", "--name=$(BACKUP_NAME)"},
+		Env:        map[string]string{"PV_MIGRATE_S3_SECRET_KEY": "PV_MIGRATE_S3_SECRET_KEY"},
+		Files:      map[string]string{"rclone.conf": "rclone.conf"},
+		SecretData: map[string]string{"PV_MIGRATE_S3_SECRET_KEY": "secret", "rclone.conf": "[remote]\n"},
+	}
+}
+
+func TestObjects(t *testing.T) {
+	t.Parallel()
+
+	opts := testOptions()
+	objects := opts.Objects()
+	require.Len(t, objects, 5)
+
+	secret, ok := objects[0].(*corev1.Secret)
+	require.True(t, ok)
+	assert.Equal(t, "app-data-credentials", secret.Name)
+
+	binding, ok := objects[3].(*rbacv1.RoleBinding)
+	require.True(t, ok)
+	assert.Equal(t, "app-data", binding.Subjects[0].Name)
+
+	cronJob, ok := objects[4].(*batchv1.CronJob)
+	require.True(t, ok)
+	assert.Equal(t, batchv1.ForbidConcurrent, cronJob.Spec.ConcurrencyPolicy)
+
+	pod := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, "app-data", pod.ServiceAccountName)
+	assert.Equal(t, opts.Args, pod.Containers[0].Args)
+
+	env := pod.Containers[0].Env
+	require.Len(t, env, 2)
+	assert.Equal(t, schedule.BackupNameEnv, env[0].Name)
+	assert.Equal(t, "metadata.labels['batch.kubernetes.io/job-name']", env[0].ValueFrom.FieldRef.FieldPath)
+	assert.Equal(t, "app-data-credentials", env[1].ValueFrom.SecretKeyRef.Name)
+
+	require.Len(t, pod.Volumes, 1)
+	assert.Equal(t, "app-data-credentials", pod.Volumes[0].Secret.SecretName)
+	assert.Equal(t, schedule.FilesMountPath, pod.Containers[0].VolumeMounts[0].MountPath)
+}
+
+func TestObjects_NoSecretWithoutCredentials(t *testing.T) {
+	t.Parallel()
+
+	opts := testOptions()
+	opts.Env, opts.Files, opts.SecretData = nil, nil, nil
+
+	objects := opts.Objects()
+	require.Len(t, objects, 4)
+
+	_, ok := objects[0].(*corev1.ServiceAccount)
+	assert.True(t, ok)
+
+	cronJob, ok := objects[3].(*batchv1.CronJob)
+	require.True(t, ok)
+	assert.Empty(t, cronJob.Spec.JobTemplate.Spec.Template.Spec.Volumes)
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	opts := testOptions()
+
+	out, err := opts.Render()
+	require.NoError(t, err)
+
+	manifests := string(out)
+	assert.Contains(t, manifests, "kind: CronJob\n")
+	assert.Contains(t, manifests, "schedule: 0 2 * * *\n")
+	assert.Contains(t, manifests, "PV_MIGRATE_S3_SECRET_KEY: secret\n")
+	assert.NotContains(t, manifests, "status:")
+	assert.Equal(t, 4, strings.Count(manifests, "---\n"))
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mutate  func(*schedule.Options)
+		wantErr string
+	}{
+		{name: "valid", mutate: func(*schedule.Options) {}},
+		{name: "macro", mutate: func(o *schedule.Options) { o.Schedule = "@daily" }},
+		{
+			name:    "invalid name",
+			mutate:  func(o *schedule.Options) { o.Name = "App_Data" },
+			wantErr: `schedule name "App_Data" is invalid`,
+		},
+		{
+			name:    "name too long",
+			mutate:  func(o *schedule.Options) { o.Name = "a-very-long-name-for-a-backup-schedule-of-app-data-pvc" },
+			wantErr: "is longer than 52 characters",
+		},
+		{name: "hourly", mutate: func(o *schedule.Options) { o.Schedule = "@hourly" }},
+		{
+			name:    "bad schedule",
+			mutate:  func(o *schedule.Options) { o.Schedule = "every night" },
+			wantErr: "must be a cron expression",
+		},
+		{
+			name:    "unknown macro",
+			mutate:  func(o *schedule.Options) { o.Schedule = "@nightly" },
+			wantErr: `schedule "@nightly" must be a cron expression of five fields or one of @yearly, @annually`,
+		},
+		{
+			name:    "every is not a macro",
+			mutate:  func(o *schedule.Options) { o.Schedule = "@every 1h" },
+			wantErr: "must be a cron expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := testOptions()
+			tt.mutate(&opts)
+
+			err := opts.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestApply_UpdatesExisting(t *testing.T) {
+	t.Parallel()
+
+	cli := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-data-credentials", Namespace: "app", ResourceVersion: "1"},
+		StringData: map[string]string{"PV_MIGRATE_S3_SECRET_KEY": "old"},
+	})
+
+	opts := testOptions()
+	require.NoError(t, opts.Apply(t.Context(), cli))
+
+	secret, err := cli.CoreV1().Secrets("app").Get(t.Context(), "app-data-credentials", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", secret.StringData["PV_MIGRATE_S3_SECRET_KEY"])
+
+	_, err = cli.BatchV1().CronJobs("app").Get(t.Context(), "app-data", metav1.GetOptions{})
+	require.NoError(t, err)
+}
@@ -0,0 +1,45 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// HostKey is the host key the sshd pods of a migration present. It is generated
+// by the client rather than by sshd at startup, so that every ssh client of the
+// migration can be told the one key to accept.
+type HostKey struct {
+	// PrivateKey is the key in OpenSSH PEM format, as sshd's HostKey option reads it.
+	PrivateKey string
+	// PublicKey is the key in authorized_keys format.
+	PublicKey string
+}
+
+func CreateHostKey() (*HostKey, error) {
+	publicKey, privateKey, err := createSSHEd25519KeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host key: %w", err)
+	}
+
+	return &HostKey{PrivateKey: privateKey, PublicKey: publicKey}, nil
+}
+
+// KnownHosts returns a known_hosts file that accepts the key for any host. The
+// name an sshd pod is reached by depends on the strategy, and may be an address
+// given by the user, but the key is unique to the migration, so pinning the key
+// alone is what keeps another server from answering in its place.
+func (k *HostKey) KnownHosts() string {
+	return "* " + strings.TrimSpace(k.PublicKey) + "\n"
+}
+
+// HostKeyCallback returns a callback that accepts the key and nothing else.
+func (k *HostKey) HostKeyCallback() (ssh.HostKeyCallback, error) {
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host key: %w", err)
+	}
+
+	return ssh.FixedHostKey(publicKey), nil
+}
@@ -0,0 +1,57 @@
+package ssh_test
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/utkuozdemir/pv-migrate/internal/ssh"
+)
+
+func TestHostKey(t *testing.T) {
+	t.Parallel()
+
+	hostKey, err := ssh.CreateHostKey()
+	require.NoError(t, err)
+
+	signer, err := gossh.ParsePrivateKey([]byte(hostKey.PrivateKey))
+	require.NoError(t, err, "sshd must be able to load the private key")
+
+	other, err := ssh.CreateHostKey()
+	require.NoError(t, err)
+
+	otherSigner, err := gossh.ParsePrivateKey([]byte(other.PrivateKey))
+	require.NoError(t, err)
+
+	callback, err := hostKey.HostKeyCallback()
+	require.NoError(t, err)
+
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 22}
+
+	require.NoError(t, callback("sshd:22", addr, signer.PublicKey()))
+	require.Error(t, callback("sshd:22", addr, otherSigner.PublicKey()),
+		"a server presenting another key must be refused")
+
+	knownHostsFile := t.TempDir() + "/known_hosts"
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(hostKey.KnownHosts()), 0o600))
+
+	knownHostsCallback, err := knownhosts.New(knownHostsFile)
+	require.NoError(t, err)
+
+	// Only port 22 is tried: Go's parser ties a pattern without a port to it,
+	// while OpenSSH, which reads this file, matches "*" against "[host]:port" too.
+	for _, host := range []string{"pv-migrate-abc-src-sshd.default:22", "203.0.113.7:22"} {
+		assert.NoError(t, knownHostsCallback(host, addr, signer.PublicKey()),
+			"the known_hosts entry should accept the key for %s", host)
+		assert.Error(t, knownHostsCallback(host, addr, otherSigner.PublicKey()),
+			"the known_hosts entry should refuse another key for %s", host)
+	}
+
+	assert.True(t, strings.HasPrefix(hostKey.KnownHosts(), "* ssh-ed25519 "))
+}
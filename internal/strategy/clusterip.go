@@ -59,7 +59,7 @@ func buildClusterIPHelmVals(
 	helmReleaseName string,
 	logger *slog.Logger,
 ) (map[string]any, error) {
-	publicKey, privateKey, privateKeyMountPath, err := generateSSHKeys(mig.Request.KeyAlgorithm, logger)
+	keys, err := generateSSHKeys(mig.Request.KeyAlgorithm, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +75,7 @@ func buildClusterIPHelmVals(
 	}
 
 	return map[string]any{
-		rsyncComponent: buildRsyncHelmValues(topo.rsync, rsyncCmdStr, privateKey, privateKeyMountPath),
-		sshdComponent:  buildSshdHelmValues(topo.sshd, publicKey),
+		rsyncComponent: buildRsyncHelmValues(topo.rsync, rsyncCmdStr, keys),
+		sshdComponent:  buildSshdHelmValues(topo.sshd, keys),
 	}, nil
 }
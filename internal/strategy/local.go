@@ -30,8 +30,8 @@ func (r *Local) Run(ctx context.Context, attempt *migration.Attempt, logger *slo
 	mig := attempt.Migration
 	req := mig.Request
 
-	if req.Detach {
-		return Declined("local strategy requires a persistent connection through the local machine")
+	if reason := r.cannotDoReason(mig); reason != "" {
+		return Declined(reason)
 	}
 
 	if hasHelmOverrides(req) {
@@ -39,7 +39,7 @@ func (r *Local) Run(ctx context.Context, attempt *migration.Attempt, logger *slo
 			"rsync-related Helm values (e.g. rsync.*) will have no effect")
 	}
 
-	publicKey, privateKey, privateKeyMountPath, err := generateSSHKeys(req.KeyAlgorithm, logger)
+	keys, err := generateSSHKeys(req.KeyAlgorithm, logger)
 	if err != nil {
 		return err
 	}
@@ -48,13 +48,11 @@ func (r *Local) Run(ctx context.Context, attempt *migration.Attempt, logger *slo
 	destReleaseName := attempt.HelmReleaseNamePrefix + "-dest"
 	attempt.ReleaseNames = []string{srcReleaseName, destReleaseName}
 
-	if err = installLocalOnSource(
-		ctx, attempt, srcReleaseName, publicKey, privateKey, privateKeyMountPath, logger,
-	); err != nil {
+	if err = installLocalOnSource(ctx, attempt, srcReleaseName, keys, logger); err != nil {
 		return fmt.Errorf("failed to install on source: %w", err)
 	}
 
-	if err = installLocalOnDest(ctx, attempt, destReleaseName, publicKey, logger); err != nil {
+	if err = installLocalOnDest(ctx, attempt, destReleaseName, keys, logger); err != nil {
 		return fmt.Errorf("failed to install on dest: %w", err)
 	}
 
@@ -68,14 +66,22 @@ func (r *Local) Run(ctx context.Context, attempt *migration.Attempt, logger *slo
 		return fmt.Errorf("failed to get dest sshd pod: %w", err)
 	}
 
-	return runLocalMigration(ctx, attempt, mig, privateKey, srcPod, destPod, sshPort(mig.Request), logger)
+	return runLocalMigration(ctx, attempt, mig, keys, srcPod, destPod, sshPort(mig.Request), logger)
+}
+
+func (r *Local) cannotDoReason(t *migration.Migration) string {
+	if t.Request.Detach {
+		return "local strategy requires a persistent connection through the local machine"
+	}
+
+	return ""
 }
 
 func runLocalMigration(
 	ctx context.Context,
 	attempt *migration.Attempt,
 	mig *migration.Migration,
-	privateKey string,
+	keys *sshKeys,
 	srcPod, destPod *corev1.Pod,
 	containerSSHPort int,
 	logger *slog.Logger,
@@ -118,7 +124,7 @@ func runLocalMigration(
 	eg.Go(func() error {
 		defer fwdCancel()
 
-		return waitAndRunRsync(ctx, attempt, privateKey, srcPortCh, destPortCh, logger)
+		return waitAndRunRsync(ctx, attempt, keys, srcPortCh, destPortCh, logger)
 	})
 
 	return eg.Wait() //nolint:wrapcheck
@@ -132,7 +138,7 @@ func hasHelmOverrides(req *migration.Request) bool {
 func waitAndRunRsync(
 	ctx context.Context,
 	attempt *migration.Attempt,
-	privateKey string,
+	keys *sshKeys,
 	srcPortCh, destPortCh <-chan int,
 	logger *slog.Logger,
 ) error {
@@ -156,25 +162,30 @@ func waitAndRunRsync(
 	case destFwdPort = <-destPortCh:
 	}
 
-	return runRsyncOverSSH(ctx, attempt, privateKey, srcFwdPort, destFwdPort, logger)
+	return runRsyncOverSSH(ctx, attempt, keys, srcFwdPort, destFwdPort, logger)
 }
 
 func runRsyncOverSSH(
 	ctx context.Context,
 	attempt *migration.Attempt,
-	privateKey string,
+	keys *sshKeys,
 	srcFwdPort, destFwdPort int,
 	logger *slog.Logger,
 ) error {
-	signer, err := gossh.ParsePrivateKey([]byte(privateKey))
+	signer, err := gossh.ParsePrivateKey([]byte(keys.privateKey))
 	if err != nil {
 		return fmt.Errorf("failed to parse private key: %w", err)
 	}
 
+	hostKeyCallback, err := keys.hostKey.HostKeyCallback()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
 	sshConfig := &gossh.ClientConfig{
 		User:            sshUser(attempt.Migration.Request),
 		Auth:            []gossh.AuthMethod{gossh.PublicKeys(signer)},
-		HostKeyCallback: gossh.InsecureIgnoreHostKey(), //nolint:gosec
+		HostKeyCallback: hostKeyCallback,
 	}
 
 	sshClient, err := gossh.Dial("tcp", fmt.Sprintf("localhost:%d", srcFwdPort), sshConfig)
@@ -472,7 +483,10 @@ func buildRsyncCmdLocal(mig *migration.Migration) (string, error) {
 		DestSSHHost: "localhost",
 		DestSSHUser: sshUser(mig.Request),
 		Compress:    !mig.Request.NoCompress,
+		BWLimit:     mig.Request.BWLimit,
 		ExtraArgs:   mig.Request.RsyncExtraArgs,
+
+		KnownHostsFile: knownHostsMountPath,
 	}
 
 	cmd, err := rsyncCmd.Build()
@@ -508,7 +522,8 @@ func getSshdPodForHelmRelease(
 func installLocalOnSource(
 	ctx context.Context,
 	attempt *migration.Attempt,
-	releaseName, publicKey, privateKey, privateKeyMountPath string,
+	releaseName string,
+	keys *sshKeys,
 	logger *slog.Logger,
 ) error {
 	mig := attempt.Migration
@@ -518,23 +533,26 @@ func installLocalOnSource(
 		readOnly:  !mig.Request.SourceMountReadWrite,
 	}
 
-	sshdVals := buildSshdHelmValues(side, publicKey)
+	// rsync runs in this pod and reaches the dest sshd through the tunnel, so it
+	// needs the client key and the known_hosts file here.
+	sshdVals := buildSshdHelmValues(side, keys)
 	sshdVals["privateKeyMount"] = true
-	sshdVals["privateKey"] = privateKey
-	sshdVals["privateKeyMountPath"] = privateKeyMountPath
+	sshdVals["privateKey"] = keys.privateKey
+	sshdVals["privateKeyMountPath"] = keys.privateKeyMountPath
+	addKnownHostsValues(sshdVals, keys)
 
 	return installHelmChart(ctx, attempt, mig.SourceInfo, releaseName, map[string]any{sshdComponent: sshdVals}, logger)
 }
 
 func installLocalOnDest(
-	ctx context.Context, attempt *migration.Attempt, releaseName, publicKey string, logger *slog.Logger,
+	ctx context.Context, attempt *migration.Attempt, releaseName string, keys *sshKeys, logger *slog.Logger,
 ) error {
 	mig := attempt.Migration
 	side := componentSide{info: mig.DestInfo, mountPath: destMountPath}
 
 	return installHelmChart(
 		ctx, attempt, mig.DestInfo, releaseName,
-		map[string]any{sshdComponent: buildSshdHelmValues(side, publicKey)}, logger,
+		map[string]any{sshdComponent: buildSshdHelmValues(side, keys)}, logger,
 	)
 }
 
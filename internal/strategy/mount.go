@@ -97,6 +97,7 @@ func buildRsyncCmdMount(mig *migration.Migration) (string, error) {
 		SrcPath:   srcPath,
 		DestPath:  destPath,
 		Compress:  !mig.Request.NoCompress,
+		BWLimit:   mig.Request.BWLimit,
 		ExtraArgs: mig.Request.RsyncExtraArgs,
 	}
 
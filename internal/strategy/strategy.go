@@ -47,6 +47,11 @@ const (
 	keyReadOnly  = "readOnly"
 	keyAffinity  = "affinity"
 
+	// knownHostsMountPath is where the known_hosts file pinning the sshd host key
+	// is mounted, in the rsync pod and in the sshd pod the local strategy runs
+	// rsync from.
+	knownHostsMountPath = "/tmp/known_hosts"
+
 	rootSSHUser    = "root"
 	rootSSHPort    = 22
 	nonRootSSHUser = "pvmigrate"
@@ -95,6 +100,24 @@ type Strategy interface {
 	Run(ctx context.Context, attempt *migration.Attempt, logger *slog.Logger) error
 }
 
+// decliner is implemented by the strategies that can tell from the PVCs alone,
+// before installing anything, that they cannot handle a migration.
+type decliner interface {
+	cannotDoReason(t *migration.Migration) string
+}
+
+// CannotDoReason returns why the strategy declines the migration without trying
+// it, or "" when it would try. A strategy that would try can still fail, or
+// decline later for a reason only the cluster can tell, such as a load balancer
+// that never gets an address.
+func CannotDoReason(s Strategy, mig *migration.Migration) string {
+	if d, ok := s.(decliner); ok {
+		return d.cannotDoReason(mig)
+	}
+
+	return ""
+}
+
 func GetStrategiesMapForNames(names []string) (map[string]Strategy, error) {
 	sts := make(map[string]Strategy)
 
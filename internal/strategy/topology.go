@@ -65,15 +65,34 @@ func (t topology) releaseNames(prefix string) [2]string {
 	return [2]string{prefix + "-src", prefix + "-dest"}
 }
 
-func generateSSHKeys(keyAlgorithm string, logger *slog.Logger) (string, string, string, error) {
+// sshKeys are the keys generated for one migration: the key pair rsync logs in
+// with, and the host key its sshd pods present, which every client pins.
+type sshKeys struct {
+	publicKey           string
+	privateKey          string
+	privateKeyMountPath string
+	hostKey             *ssh.HostKey
+}
+
+func generateSSHKeys(keyAlgorithm string, logger *slog.Logger) (*sshKeys, error) {
 	logger.Info("🔑 Generating SSH key pair", "algorithm", keyAlgorithm)
 
 	publicKey, privateKey, err := ssh.CreateSSHKeyPair(keyAlgorithm)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to create ssh key pair: %w", err)
+		return nil, fmt.Errorf("failed to create ssh key pair: %w", err)
+	}
+
+	hostKey, err := ssh.CreateHostKey()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
 	}
 
-	return publicKey, privateKey, "/tmp/id_" + keyAlgorithm, nil
+	return &sshKeys{
+		publicKey:           publicKey,
+		privateKey:          privateKey,
+		privateKeyMountPath: "/tmp/id_" + keyAlgorithm,
+		hostKey:             hostKey,
+	}, nil
 }
 
 type resolveTargetFunc func(ctx context.Context, attempt *migration.Attempt,
@@ -91,7 +110,7 @@ func runTwoReleaseStrategy(
 	mig := attempt.Migration
 	topo := resolveTopology(mig)
 
-	publicKey, privateKey, privateKeyMountPath, err := generateSSHKeys(mig.Request.KeyAlgorithm, logger)
+	keys, err := generateSSHKeys(mig.Request.KeyAlgorithm, logger)
 	if err != nil {
 		return err
 	}
@@ -100,7 +119,7 @@ func runTwoReleaseStrategy(
 	sshdRelease, rsyncRelease := releases[0], releases[1]
 	attempt.ReleaseNames = releases[:]
 
-	if err = installSshd(ctx, attempt, topo, sshdRelease, publicKey, serviceType, logger); err != nil {
+	if err = installSshd(ctx, attempt, topo, sshdRelease, keys, serviceType, logger); err != nil {
 		return fmt.Errorf("failed to install sshd: %w", err)
 	}
 
@@ -114,8 +133,7 @@ func runTwoReleaseStrategy(
 		sshTargetHost = formatSSHTargetHost(mig.Request.DestHostOverride)
 	}
 
-	if err = installRsyncJob(ctx, attempt, topo, rsyncRelease, privateKey, privateKeyMountPath,
-		sshTargetHost, target.port, logger); err != nil {
+	if err = installRsyncJob(ctx, attempt, topo, rsyncRelease, keys, sshTargetHost, target.port, logger); err != nil {
 		return fmt.Errorf("failed to install rsync job: %w", err)
 	}
 
@@ -138,7 +156,10 @@ func buildRsyncCmdString(req *migration.Request, push bool, sshHost string, port
 		SrcPath:   srcPath,
 		DestPath:  destPath,
 		Compress:  !req.NoCompress,
+		BWLimit:   req.BWLimit,
 		ExtraArgs: req.RsyncExtraArgs,
+
+		KnownHostsFile: knownHostsMountPath,
 	}
 
 	if push {
@@ -159,11 +180,13 @@ func buildRsyncCmdString(req *migration.Request, push bool, sshHost string, port
 	return built, nil
 }
 
-func buildSshdHelmValues(side componentSide, publicKey string) map[string]any {
+func buildSshdHelmValues(side componentSide, keys *sshKeys) map[string]any {
 	return map[string]any{
-		keyEnabled:   true,
-		keyNamespace: side.info.Claim.Namespace,
-		keyPublicKey: publicKey,
+		keyEnabled:     true,
+		keyNamespace:   side.info.Claim.Namespace,
+		keyPublicKey:   keys.publicKey,
+		"hostKeyMount": true,
+		"hostKey":      keys.hostKey.PrivateKey,
 		keyPVCMounts: []map[string]any{
 			{
 				keyName:      side.info.Claim.Name,
@@ -175,13 +198,13 @@ func buildSshdHelmValues(side componentSide, publicKey string) map[string]any {
 	}
 }
 
-func buildRsyncHelmValues(side componentSide, rsyncCmd, privateKey, privateKeyMountPath string) map[string]any {
-	return map[string]any{
+func buildRsyncHelmValues(side componentSide, rsyncCmd string, keys *sshKeys) map[string]any {
+	vals := map[string]any{
 		keyEnabled:            true,
 		keyNamespace:          side.info.Claim.Namespace,
 		"privateKeyMount":     true,
-		"privateKey":          privateKey,
-		"privateKeyMountPath": privateKeyMountPath,
+		"privateKey":          keys.privateKey,
+		"privateKeyMountPath": keys.privateKeyMountPath,
 		keyPVCMounts: []map[string]any{
 			{
 				keyName:      side.info.Claim.Name,
@@ -192,16 +215,30 @@ func buildRsyncHelmValues(side componentSide, rsyncCmd, privateKey, privateKeyMo
 		"command":   rsyncCmd,
 		keyAffinity: side.info.AffinityHelmValues,
 	}
+
+	addKnownHostsValues(vals, keys)
+
+	return vals
+}
+
+// addKnownHostsValues mounts the known_hosts file that pins the host key into
+// the pod of vals, at the path the rsync command reads it from.
+func addKnownHostsValues(vals map[string]any, keys *sshKeys) {
+	vals["knownHostsMount"] = true
+	vals["knownHosts"] = keys.hostKey.KnownHosts()
+	vals["knownHostsMountPath"] = knownHostsMountPath
 }
 
 func installSshd(
 	ctx context.Context,
 	attempt *migration.Attempt,
 	topo topology,
-	releaseName, publicKey, serviceType string,
+	releaseName string,
+	keys *sshKeys,
+	serviceType string,
 	logger *slog.Logger,
 ) error {
-	sshdVals := buildSshdHelmValues(topo.sshd, publicKey)
+	sshdVals := buildSshdHelmValues(topo.sshd, keys)
 	sshdVals["service"] = map[string]any{"type": serviceType}
 
 	return installHelmChart(ctx, attempt, topo.sshd.info, releaseName, map[string]any{sshdComponent: sshdVals}, logger)
@@ -211,7 +248,9 @@ func installRsyncJob(
 	ctx context.Context,
 	attempt *migration.Attempt,
 	topo topology,
-	releaseName, privateKey, privateKeyMountPath, sshHost string,
+	releaseName string,
+	keys *sshKeys,
+	sshHost string,
 	sshPort int,
 	logger *slog.Logger,
 ) error {
@@ -222,7 +261,7 @@ func installRsyncJob(
 		return err
 	}
 
-	rsyncVals := buildRsyncHelmValues(topo.rsync, rsyncCmdStr, privateKey, privateKeyMountPath)
+	rsyncVals := buildRsyncHelmValues(topo.rsync, rsyncCmdStr, keys)
 	rsyncVals["sshRemoteHost"] = sshHost
 
 	if sshPort != 0 {
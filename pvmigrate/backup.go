@@ -28,9 +28,17 @@ type Backup struct {
 
 	PVC PVC
 
-	// Backend is the storage backend: "s3", "azure", or "gcs".
+	// PVCNames backs up several PVCs of PVC.Namespace as one set instead of PVC.Name,
+	// which must be empty then. Each PVC is stored under
+	// <prefix>/<name>/<pvc>/, and one metadata file lists them all.
+	PVCNames []string
+	// PVCSelector selects the PVCs of the set by label instead of naming them.
+	PVCSelector string
+
+	// Backend is the storage backend: "s3", "azure", "gcs", "sftp", "webdav", "swift", or "b2".
 	Backend string
-	// Bucket is the bucket (or container) name.
+	// Bucket is the bucket (or container) name. For the sftp and webdav backends
+	// it is the top-level directory the backups are stored under.
 	Bucket string
 
 	// S3-specific options
@@ -50,7 +58,35 @@ type Backup struct {
 	// Nil uses rclone config generation's default of true.
 	GCSBucketPolicyOnly *bool
 
-	// Name is the backup identity in the bucket. Required.
+	// SFTP-specific options
+	SFTPHost string
+	// SFTPPort is the SSH port of the server. Zero uses rclone's default of 22.
+	SFTPPort     int
+	SFTPUser     string
+	SFTPPassword string
+	// SFTPKeyPEM is a PEM-encoded private key, as read from a key file.
+	SFTPKeyPEM string
+
+	// WebDAV-specific options
+	WebDAVURL string
+	// WebDAVVendor selects rclone's server-specific behaviour (default: other).
+	WebDAVVendor      string
+	WebDAVUser        string
+	WebDAVPassword    string
+	WebDAVBearerToken string
+
+	// Swift-specific options. The region is taken from Region.
+	SwiftAuthURL string
+	SwiftUser    string
+	SwiftKey     string
+	SwiftTenant  string
+	SwiftDomain  string
+
+	// Backblaze B2-specific options
+	B2Account string
+	B2Key     string
+
+	// Name is the backup identity in the bucket. Required unless Remote is set.
 	Name string
 	// Prefix is the global prefix in the bucket (default: pv-migrate).
 	Prefix string
@@ -59,12 +95,72 @@ type Backup struct {
 
 	// RcloneConfigFile is the path to a raw rclone.conf file (power-user escape hatch).
 	RcloneConfigFile string
+	// RcloneConfigRemote names a remote defined in RcloneConfigFile to build the
+	// managed <bucket>/<prefix>/<name> layout on, so that the config file's own
+	// authentication can be combined with Bucket, Prefix, Name and the metadata
+	// sidecar. Mutually exclusive with Remote.
+	RcloneConfigRemote string
 	// Remote is the remote spec for raw config mode (e.g., "myremote:bucket/path").
 	Remote string
 
+	// CredentialsSecret names an existing secret in the PVC's namespace to take the
+	// backend credentials from instead of the credential fields, so they never
+	// leave the cluster. Each key becomes the rclone option of the same name on the
+	// remote in use, as the environment variable RCLONE_CONFIG_<REMOTE>_<KEY>: a key
+	// SECRET_ACCESS_KEY sets secret_access_key. Passwords must be stored obscured
+	// (`rclone obscure`), as rclone reads them. Ambient authentication (env_auth) is
+	// not enabled when this is set.
+	CredentialsSecret string
+
+	// ServiceAccountName runs the rclone job as this existing service account in the
+	// PVC's namespace instead of the one the chart creates. The identity it carries is
+	// used as-is, so it cannot be combined with the annotation and identity fields.
+	ServiceAccountName string
+
+	// ServiceAccountAnnotations are set on the service account the chart creates for
+	// the rclone job, for identity integrations without a dedicated field.
+	ServiceAccountAnnotations map[string]string
+
+	// Workload identity for the rclone job, each set as the annotation its cloud reads
+	// off the service account: an IAM role for IRSA on EKS (s3), a managed identity
+	// client ID and optionally its tenant for Azure Workload Identity (azure), and a
+	// Google service account for GKE Workload Identity (gcs).
+	AWSRoleARN        string
+	AzureClientID     string
+	AzureTenantID     string
+	GCPServiceAccount string
+
+	// EnvAuth allows a generated config without credentials, CredentialsSecret or a
+	// workload identity, for nodes that grant bucket access themselves (such as an
+	// EC2 instance profile). Without it such a config is rejected before anything is
+	// deployed.
+	EnvAuth bool
+
 	// RcloneExtraArgs are extra flags appended to the rclone command after the built-in progress flags.
 	RcloneExtraArgs string
 
+	// BWLimit caps the rclone transfer rate, as rclone's --bwlimit does: a rate such
+	// as 10M, or a timetable such as "08:00,512k 18:00,off".
+	BWLimit string
+
+	// Archive stores the backup as one tarball per PVC, compressed with zstd and
+	// streamed to <name>/archive.tar.zst (<name>/<pvc>/archive.tar.zst for a set),
+	// instead of as a tree of files, for volumes of many small files. The format
+	// is recorded in the metadata, so a restore extracts it without being told.
+	// It needs the managed layout.
+	Archive bool
+
+	// NoManifest leaves out the integrity manifest, <name>.manifest.sha256 next to
+	// the metadata, which lists the SHA-256 of every file backed up so that the
+	// bucket or a restored volume can be checked against it with rclone checksum.
+	// Building it reads all of the data a second time. A backup without metadata,
+	// such as one to a raw Remote, has no manifest either.
+	NoManifest bool
+
+	// Hooks run in the pods of the application in Namespace. They cannot be used
+	// with Detach.
+	Hooks Hooks
+
 	IgnoreMounted      bool
 	NonRoot            bool
 	Detach             bool
@@ -129,44 +225,76 @@ func applyBackupDefaults(backup *Backup) {
 
 func toBackupRequest(backup *Backup, direction string) *bucketstorage.Request {
 	return &bucketstorage.Request{
-		ID:                    backup.ID,
-		ImageTag:              backup.ImageTag,
-		ChartVersion:          backup.ChartVersion,
-		Direction:             direction,
-		KubeconfigPath:        backup.PVC.KubeconfigPath,
-		Context:               backup.PVC.Context,
-		Namespace:             backup.PVC.Namespace,
-		PVCName:               backup.PVC.Name,
-		IgnoreMounted:         backup.IgnoreMounted,
-		NonRoot:               backup.NonRoot,
-		Detach:                backup.Detach,
-		NoCleanup:             backup.NoCleanup,
-		NoCleanupOnFailure:    backup.NoCleanupOnFailure,
-		Backend:               backup.Backend,
-		Bucket:                backup.Bucket,
-		S3Provider:            backup.S3Provider,
-		Endpoint:              backup.Endpoint,
-		Region:                backup.Region,
-		AccessKey:             backup.AccessKey,
-		SecretKey:             backup.SecretKey,
-		StorageAccount:        backup.StorageAccount,
-		StorageKey:            backup.StorageKey,
-		GCSServiceAccountJSON: backup.GCSServiceAccountJSON,
-		GCSBucketPolicyOnly:   backup.GCSBucketPolicyOnly,
-		Name:                  backup.Name,
-		Prefix:                backup.Prefix,
-		Path:                  backup.Path,
-		RcloneConfigFile:      backup.RcloneConfigFile,
-		Remote:                backup.Remote,
-		RcloneExtraArgs:       backup.RcloneExtraArgs,
-		HelmTimeout:           backup.HelmTimeout,
-		HelmValuesFiles:       backup.HelmValuesFiles,
-		HelmValues:            backup.HelmValues,
-		HelmFileValues:        backup.HelmFileValues,
-		HelmStringValues:      backup.HelmStringValues,
-		Writer:                backup.Writer,
-		StructuredLogs:        backup.StructuredLogs,
-		ColorOutput:           backup.ColorOutput,
-		Logger:                backup.Logger,
+		ID:                        backup.ID,
+		ImageTag:                  backup.ImageTag,
+		ChartVersion:              backup.ChartVersion,
+		Direction:                 direction,
+		KubeconfigPath:            backup.PVC.KubeconfigPath,
+		Context:                   backup.PVC.Context,
+		Namespace:                 backup.PVC.Namespace,
+		PVCName:                   backup.PVC.Name,
+		PVCNames:                  backup.PVCNames,
+		PVCSelector:               backup.PVCSelector,
+		IgnoreMounted:             backup.IgnoreMounted,
+		NonRoot:                   backup.NonRoot,
+		Detach:                    backup.Detach,
+		NoCleanup:                 backup.NoCleanup,
+		NoCleanupOnFailure:        backup.NoCleanupOnFailure,
+		Backend:                   backup.Backend,
+		Bucket:                    backup.Bucket,
+		S3Provider:                backup.S3Provider,
+		Endpoint:                  backup.Endpoint,
+		Region:                    backup.Region,
+		AccessKey:                 backup.AccessKey,
+		SecretKey:                 backup.SecretKey,
+		StorageAccount:            backup.StorageAccount,
+		StorageKey:                backup.StorageKey,
+		GCSServiceAccountJSON:     backup.GCSServiceAccountJSON,
+		GCSBucketPolicyOnly:       backup.GCSBucketPolicyOnly,
+		SFTPHost:                  backup.SFTPHost,
+		SFTPPort:                  backup.SFTPPort,
+		SFTPUser:                  backup.SFTPUser,
+		SFTPPassword:              backup.SFTPPassword,
+		SFTPKeyPEM:                backup.SFTPKeyPEM,
+		WebDAVURL:                 backup.WebDAVURL,
+		WebDAVVendor:              backup.WebDAVVendor,
+		WebDAVUser:                backup.WebDAVUser,
+		WebDAVPassword:            backup.WebDAVPassword,
+		WebDAVBearerToken:         backup.WebDAVBearerToken,
+		SwiftAuthURL:              backup.SwiftAuthURL,
+		SwiftUser:                 backup.SwiftUser,
+		SwiftKey:                  backup.SwiftKey,
+		SwiftTenant:               backup.SwiftTenant,
+		SwiftDomain:               backup.SwiftDomain,
+		B2Account:                 backup.B2Account,
+		B2Key:                     backup.B2Key,
+		Name:                      backup.Name,
+		Prefix:                    backup.Prefix,
+		Path:                      backup.Path,
+		RcloneConfigFile:          backup.RcloneConfigFile,
+		RcloneConfigRemote:        backup.RcloneConfigRemote,
+		Remote:                    backup.Remote,
+		RcloneExtraArgs:           backup.RcloneExtraArgs,
+		BWLimit:                   backup.BWLimit,
+		Archive:                   backup.Archive,
+		NoManifest:                backup.NoManifest,
+		Hooks:                     toHookConfig(&backup.Hooks),
+		CredentialsSecret:         backup.CredentialsSecret,
+		ServiceAccountName:        backup.ServiceAccountName,
+		ServiceAccountAnnotations: backup.ServiceAccountAnnotations,
+		AWSRoleARN:                backup.AWSRoleARN,
+		AzureClientID:             backup.AzureClientID,
+		AzureTenantID:             backup.AzureTenantID,
+		GCPServiceAccount:         backup.GCPServiceAccount,
+		EnvAuth:                   backup.EnvAuth,
+		HelmTimeout:               backup.HelmTimeout,
+		HelmValuesFiles:           backup.HelmValuesFiles,
+		HelmValues:                backup.HelmValues,
+		HelmFileValues:            backup.HelmFileValues,
+		HelmStringValues:          backup.HelmStringValues,
+		Writer:                    backup.Writer,
+		StructuredLogs:            backup.StructuredLogs,
+		ColorOutput:               backup.ColorOutput,
+		Logger:                    backup.Logger,
 	}
 }
@@ -0,0 +1,244 @@
+package pvmigrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/utkuozdemir/pv-migrate/internal/bucketstorage"
+	"github.com/utkuozdemir/pv-migrate/internal/opid"
+)
+
+// Storage is a backup in one backend, as the source or the destination of a Copy.
+// The fields mean what the fields of the same name on Backup do.
+type Storage struct {
+	// Backend is the storage backend: "s3", "azure", "gcs", "sftp", "webdav", "swift", or "b2".
+	Backend string
+	Bucket  string
+
+	// S3-specific options
+	S3Provider string
+	Endpoint   string
+	Region     string
+	AccessKey  string
+	SecretKey  string
+
+	// Azure-specific options
+	StorageAccount string
+	StorageKey     string
+
+	// GCS-specific options
+	GCSServiceAccountJSON string
+	GCSBucketPolicyOnly   *bool
+
+	// SFTP-specific options
+	SFTPHost     string
+	SFTPPort     int
+	SFTPUser     string
+	SFTPPassword string
+	SFTPKeyPEM   string
+
+	// WebDAV-specific options
+	WebDAVURL         string
+	WebDAVVendor      string
+	WebDAVUser        string
+	WebDAVPassword    string
+	WebDAVBearerToken string
+
+	// Swift-specific options. The region is taken from Region.
+	SwiftAuthURL string
+	SwiftUser    string
+	SwiftKey     string
+	SwiftTenant  string
+	SwiftDomain  string
+
+	// Backblaze B2-specific options
+	B2Account string
+	B2Key     string
+
+	// Name is the backup identity in the bucket. A destination without one keeps
+	// the source's name.
+	Name string
+	// Prefix is the global prefix in the bucket (default: pv-migrate).
+	Prefix string
+
+	// CredentialsSecret names an existing secret in the job's namespace to take the
+	// backend credentials from, as on Backup.
+	CredentialsSecret string
+
+	// Workload identity for this backend's cloud, as on Backup. The job has one
+	// service account, which carries the identities of both backends.
+	AWSRoleARN        string
+	AzureClientID     string
+	AzureTenantID     string
+	GCPServiceAccount string
+
+	// EnvAuth allows this backend to authenticate with what the node provides.
+	EnvAuth bool
+}
+
+// Copy holds all configuration for copying a backup from one backend to another,
+// such as from S3 to GCS. The data is copied first and the metadata last, so the
+// copy can be restored from once it is complete. Raw rclone configs are not
+// supported, since a copy needs the managed <prefix>/<name> layout on both sides.
+type Copy struct {
+	// ID is an optional custom identifier. When empty, a petname-style identifier
+	// is generated automatically.
+	ID string
+
+	// ImageTag is the Docker image tag for the rclone container.
+	ImageTag string
+
+	// ChartVersion overrides the embedded Helm chart version metadata.
+	ChartVersion string
+
+	// KubeconfigPath, Context and Namespace say where the rclone job runs. The job
+	// mounts no PVC, so any namespace will do.
+	KubeconfigPath string
+	Context        string
+	Namespace      string
+
+	Source Storage
+	Dest   Storage
+
+	// RcloneExtraArgs are extra flags appended to the rclone command after the built-in progress flags.
+	RcloneExtraArgs string
+
+	// ServiceAccountName and ServiceAccountAnnotations configure the job's service
+	// account, as on Backup.
+	ServiceAccountName        string
+	ServiceAccountAnnotations map[string]string
+
+	NonRoot            bool
+	Detach             bool
+	NoCleanup          bool
+	NoCleanupOnFailure bool
+
+	HelmTimeout      time.Duration
+	HelmValuesFiles  []string
+	HelmValues       []string
+	HelmFileValues   []string
+	HelmStringValues []string
+
+	Writer io.Writer
+	Logger *slog.Logger
+
+	// StructuredLogs reports that Logger writes machine-readable records to the
+	// same stream as Writer.
+	StructuredLogs bool
+
+	// ColorOutput colors the plain-text report blocks semantically. Set it only
+	// when Writer is a terminal.
+	ColorOutput bool
+}
+
+// RunCopy executes the copy.
+func RunCopy(ctx context.Context, cp Copy) error {
+	applyCopyDefaults(&cp)
+
+	if cp.ID != "" {
+		if err := opid.Validate(cp.ID); err != nil {
+			return err
+		}
+	}
+
+	req := toStorageRequest(&cp.Source)
+	req.ID = cp.ID
+	req.ImageTag = cp.ImageTag
+	req.ChartVersion = cp.ChartVersion
+	req.KubeconfigPath = cp.KubeconfigPath
+	req.Context = cp.Context
+	req.Namespace = cp.Namespace
+	req.RcloneExtraArgs = cp.RcloneExtraArgs
+	req.ServiceAccountName = cp.ServiceAccountName
+	req.ServiceAccountAnnotations = cp.ServiceAccountAnnotations
+	req.NonRoot = cp.NonRoot
+	req.Detach = cp.Detach
+	req.NoCleanup = cp.NoCleanup
+	req.NoCleanupOnFailure = cp.NoCleanupOnFailure
+	req.HelmTimeout = cp.HelmTimeout
+	req.HelmValuesFiles = cp.HelmValuesFiles
+	req.HelmValues = cp.HelmValues
+	req.HelmFileValues = cp.HelmFileValues
+	req.HelmStringValues = cp.HelmStringValues
+	req.Writer = cp.Writer
+	req.Logger = cp.Logger
+	req.StructuredLogs = cp.StructuredLogs
+	req.ColorOutput = cp.ColorOutput
+
+	if err := bucketstorage.Copy(ctx, req, toStorageRequest(&cp.Dest)); err != nil {
+		return fmt.Errorf("copy failed: %w", err)
+	}
+
+	return nil
+}
+
+func applyCopyDefaults(cp *Copy) {
+	if cp.Source.Prefix == "" {
+		cp.Source.Prefix = DefaultPrefix
+	}
+
+	if cp.Dest.Prefix == "" {
+		cp.Dest.Prefix = DefaultPrefix
+	}
+
+	if cp.Dest.Name == "" {
+		cp.Dest.Name = cp.Source.Name
+	}
+
+	if cp.HelmTimeout == 0 {
+		cp.HelmTimeout = defaultHelmTimeout
+	}
+
+	if cp.Writer == nil {
+		cp.Writer = os.Stderr
+	}
+
+	if cp.Logger == nil {
+		cp.Logger = slog.New(slog.DiscardHandler)
+	}
+}
+
+func toStorageRequest(storage *Storage) *bucketstorage.Request {
+	return &bucketstorage.Request{
+		Backend:               storage.Backend,
+		Bucket:                storage.Bucket,
+		S3Provider:            storage.S3Provider,
+		Endpoint:              storage.Endpoint,
+		Region:                storage.Region,
+		AccessKey:             storage.AccessKey,
+		SecretKey:             storage.SecretKey,
+		StorageAccount:        storage.StorageAccount,
+		StorageKey:            storage.StorageKey,
+		GCSServiceAccountJSON: storage.GCSServiceAccountJSON,
+		GCSBucketPolicyOnly:   storage.GCSBucketPolicyOnly,
+		SFTPHost:              storage.SFTPHost,
+		SFTPPort:              storage.SFTPPort,
+		SFTPUser:              storage.SFTPUser,
+		SFTPPassword:          storage.SFTPPassword,
+		SFTPKeyPEM:            storage.SFTPKeyPEM,
+		WebDAVURL:             storage.WebDAVURL,
+		WebDAVVendor:          storage.WebDAVVendor,
+		WebDAVUser:            storage.WebDAVUser,
+		WebDAVPassword:        storage.WebDAVPassword,
+		WebDAVBearerToken:     storage.WebDAVBearerToken,
+		SwiftAuthURL:          storage.SwiftAuthURL,
+		SwiftUser:             storage.SwiftUser,
+		SwiftKey:              storage.SwiftKey,
+		SwiftTenant:           storage.SwiftTenant,
+		SwiftDomain:           storage.SwiftDomain,
+		B2Account:             storage.B2Account,
+		B2Key:                 storage.B2Key,
+		Name:                  storage.Name,
+		Prefix:                storage.Prefix,
+		CredentialsSecret:     storage.CredentialsSecret,
+		AWSRoleARN:            storage.AWSRoleARN,
+		AzureClientID:         storage.AzureClientID,
+		AzureTenantID:         storage.AzureTenantID,
+		GCPServiceAccount:     storage.GCPServiceAccount,
+		EnvAuth:               storage.EnvAuth,
+	}
+}
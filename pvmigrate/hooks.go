@@ -0,0 +1,53 @@
+package pvmigrate
+
+import (
+	"time"
+
+	"github.com/utkuozdemir/pv-migrate/internal/hook"
+)
+
+// HookOnError is what an operation does when a hook fails.
+type HookOnError string
+
+const (
+	// HookOnErrorFail fails the operation. A failed pre hook stops it before
+	// anything is deployed.
+	HookOnErrorFail HookOnError = hook.OnErrorFail
+	// HookOnErrorContinue logs the failure and carries on.
+	HookOnErrorContinue HookOnError = hook.OnErrorContinue
+)
+
+// Hooks are commands run in the pods of an application around the transfer of
+// its volume, such as to quiesce a database while its files are copied. Each
+// runs with sh -c in every running pod that Selector matches, in the namespace of
+// the PVC, or of the source PVC for a migration. What they print is part of the
+// failure report.
+type Hooks struct {
+	// Pre runs before the data mover is installed, and Post once it finishes,
+	// whatever its outcome. When Pre stops the operation, Post still runs in
+	// the pods Pre succeeded in, so that none is left quiesced.
+	Pre  string
+	Post string
+
+	// Selector is a label selector for the pods, and Container the container to
+	// run in, which can be left out for pods with a single container.
+	Selector  string
+	Container string
+
+	// OnError defaults to HookOnErrorFail.
+	OnError HookOnError
+
+	// Timeout bounds each run of a hook (default: 5m).
+	Timeout time.Duration
+}
+
+func toHookConfig(hooks *Hooks) hook.Config {
+	return hook.Config{
+		Pre:       hooks.Pre,
+		Post:      hooks.Post,
+		Selector:  hooks.Selector,
+		Container: hooks.Container,
+		OnError:   string(hooks.OnError),
+		Timeout:   hooks.Timeout,
+	}
+}
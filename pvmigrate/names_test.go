@@ -33,11 +33,12 @@ var migrationComponents = []string{"", "-rsync", "-sshd"}
 // operationComponents are the chart's rclone resources. Only a backup or restore
 // release contains them, and such a release has no per-side suffix, which is why
 // this family is enumerated separately rather than crossed with the strategies.
-var operationComponents = []string{"", "-rclone"}
+var operationComponents = []string{"", "-rclone", "-rclone-credentials"}
 
-// operationMiddles are what the backup and restore commands use in the position
-// where a migration uses a strategy name.
-var operationMiddles = []string{"backup", "restore"}
+// operationMiddles are what the backup, restore, backups copy and backups verify
+// commands use in the position where a migration uses a strategy name, and the
+// release a restore of the latest backup looks it up with.
+var operationMiddles = []string{"backup", "restore", "copy", "verify", "resolve"}
 
 // TestDerivedNamesFitTheirLimits is the reason the ID length limit is what it is.
 // The ID is embedded in the name of the Helm release and, through it, in every
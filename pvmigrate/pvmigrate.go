@@ -11,6 +11,7 @@ import (
 	"github.com/utkuozdemir/pv-migrate/internal/migration"
 	"github.com/utkuozdemir/pv-migrate/internal/migrator"
 	"github.com/utkuozdemir/pv-migrate/internal/opid"
+	"github.com/utkuozdemir/pv-migrate/internal/rsync"
 	"github.com/utkuozdemir/pv-migrate/internal/strategy"
 	"github.com/utkuozdemir/pv-migrate/internal/util"
 )
@@ -104,6 +105,15 @@ type Migration struct {
 	NonRoot               bool
 	RsyncExtraArgs        string
 
+	// BWLimit caps the rsync transfer rate, as rsync's --bwlimit does: KiB/s, or a
+	// rate with a suffix such as 10M. rsync takes no timetable.
+	BWLimit string
+
+	// Hooks run in the pods of the application that uses the source PVC, which
+	// needs IgnoreMounted while the application is running. They cannot be used
+	// with Detach.
+	Hooks Hooks
+
 	KeyAlgorithm         KeyAlgorithm
 	SSHReverseTunnelPort int
 	Strategies           []Strategy
@@ -136,6 +146,57 @@ type Migration struct {
 func Run(ctx context.Context, migration Migration) error {
 	migration.ApplyDefaults()
 
+	if err := validate(&migration); err != nil {
+		return err
+	}
+
+	req := toInternalRequest(&migration)
+
+	if err := migrator.New().Run(ctx, req, migration.Logger); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	return nil
+}
+
+// StrategyPlan tells whether a strategy would try a migration.
+type StrategyPlan struct {
+	Strategy Strategy
+
+	// DeclineReason is why the strategy would skip the migration without trying
+	// it, empty when it would try it.
+	DeclineReason string
+}
+
+// Plan looks up the PVCs of the migration and checks it the way Run does, then
+// reports for each of its strategies, in the order Run tries them, whether it
+// would be tried. Nothing is installed in the cluster.
+//
+// A strategy that would be tried can still fail, or decline once it finds out
+// something only the cluster can tell, so Run does not necessarily succeed with
+// the first strategy Plan reports as tried.
+func Plan(ctx context.Context, migration Migration) ([]StrategyPlan, error) {
+	migration.ApplyDefaults()
+
+	if err := validate(&migration); err != nil {
+		return nil, err
+	}
+
+	verdicts, err := migrator.New().Plan(ctx, toInternalRequest(&migration), migration.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan migration: %w", err)
+	}
+
+	plans := make([]StrategyPlan, 0, len(verdicts))
+	for _, verdict := range verdicts {
+		plans = append(plans, StrategyPlan{Strategy: Strategy(verdict.Strategy), DeclineReason: verdict.Reason})
+	}
+
+	return plans, nil
+}
+
+// validate checks the fields of the migration that need no cluster to check.
+func validate(migration *Migration) error {
 	if migration.ID != "" {
 		if err := opid.Validate(migration.ID); err != nil {
 			return err
@@ -150,10 +211,8 @@ func Run(ctx context.Context, migration Migration) error {
 		return err
 	}
 
-	req := toInternalRequest(&migration)
-
-	if err := migrator.New().Run(ctx, req, migration.Logger); err != nil {
-		return fmt.Errorf("migration failed: %w", err)
+	if err := rsync.ValidateBWLimit(migration.BWLimit); err != nil {
+		return err
 	}
 
 	return nil
@@ -231,6 +290,8 @@ func toInternalRequest(mig *Migration) *migration.Request {
 		NoCompress:            mig.NoCompress,
 		NonRoot:               mig.NonRoot,
 		RsyncExtraArgs:        mig.RsyncExtraArgs,
+		BWLimit:               mig.BWLimit,
+		Hooks:                 toHookConfig(&mig.Hooks),
 		KeyAlgorithm:          string(mig.KeyAlgorithm),
 		SSHReverseTunnelPort:  mig.SSHReverseTunnelPort,
 		Strategies:            util.ConvertStrings[string](mig.Strategies),
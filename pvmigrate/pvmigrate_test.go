@@ -101,3 +101,12 @@ func TestValidateID(t *testing.T) {
 		})
 	}
 }
+
+// TestRunRejectsBWLimitTimetable pins that a migration refuses rclone's timetable
+// syntax before anything is deployed, since rsync would only fail on it in the job.
+func TestRunRejectsBWLimitTimetable(t *testing.T) {
+	t.Parallel()
+
+	err := pvmigrate.Run(t.Context(), pvmigrate.Migration{BWLimit: "08:00,512k 18:00,off"})
+	require.ErrorContains(t, err, "rsync takes a single rate such as 10M, not a timetable")
+}
@@ -28,9 +28,17 @@ type Restore struct {
 	// PVC is the destination PVC to restore data into.
 	PVC PVC
 
-	// Backend is the storage backend: "s3", "azure", or "gcs".
+	// PVCNames restores several PVCs of PVC.Namespace as one set instead of PVC.Name,
+	// which must be empty then. Each PVC is restored from
+	// <prefix>/<name>/<pvc>/, so the names must match the PVCs of the backed-up set.
+	PVCNames []string
+	// PVCSelector selects the PVCs of the set by label instead of naming them.
+	PVCSelector string
+
+	// Backend is the storage backend: "s3", "azure", "gcs", "sftp", "webdav", "swift", or "b2".
 	Backend string
-	// Bucket is the bucket (or container) name.
+	// Bucket is the bucket (or container) name. For the sftp and webdav backends
+	// it is the top-level directory the backups are stored under.
 	Bucket string
 
 	// S3-specific options
@@ -50,27 +58,132 @@ type Restore struct {
 	// Nil uses rclone config generation's default of true.
 	GCSBucketPolicyOnly *bool
 
-	// Name is the backup identity in the bucket. Required.
+	// SFTP-specific options
+	SFTPHost string
+	// SFTPPort is the SSH port of the server. Zero uses rclone's default of 22.
+	SFTPPort     int
+	SFTPUser     string
+	SFTPPassword string
+	// SFTPKeyPEM is a PEM-encoded private key, as read from a key file.
+	SFTPKeyPEM string
+
+	// WebDAV-specific options
+	WebDAVURL string
+	// WebDAVVendor selects rclone's server-specific behaviour (default: other).
+	WebDAVVendor      string
+	WebDAVUser        string
+	WebDAVPassword    string
+	WebDAVBearerToken string
+
+	// Swift-specific options. The region is taken from Region.
+	SwiftAuthURL string
+	SwiftUser    string
+	SwiftKey     string
+	SwiftTenant  string
+	SwiftDomain  string
+
+	// Backblaze B2-specific options
+	B2Account string
+	B2Key     string
+
+	// Name is the backup identity in the bucket. Required unless Remote is set.
 	Name string
+	// FromLatest restores the newest of the backups named Name or Name-<version>,
+	// such as those a backup schedule takes, by the backup time their metadata
+	// records. It is looked up in a job of its own before the restore starts.
+	FromLatest bool
 	// Prefix is the global prefix in the bucket (default: pv-migrate).
 	Prefix string
 	// Path is a subdirectory inside the PVC to restore into instead of the volume root.
 	Path string
+	// SourcePath is a subdirectory inside the backup to restore from instead of all
+	// of it. It cannot be used with a set of PVCs.
+	SourcePath string
+
+	// Include and Exclude restore only the files matching, or not matching, the
+	// rclone filter patterns, as its --include and --exclude flags do. FilterRules
+	// are rules as an rclone filter file holds them ("+ pattern" or "- pattern"),
+	// applied in order. Only one of the three can be used.
+	Include     []string
+	Exclude     []string
+	FilterRules []string
 
 	// RcloneConfigFile is the path to a raw rclone.conf file (power-user escape hatch).
 	RcloneConfigFile string
+	// RcloneConfigRemote names a remote defined in RcloneConfigFile to build the
+	// managed <bucket>/<prefix>/<name> layout on, so that the config file's own
+	// authentication can be combined with Bucket, Prefix, Name and the metadata
+	// sidecar. Mutually exclusive with Remote.
+	RcloneConfigRemote string
 	// Remote is the remote spec for raw config mode (e.g., "myremote:bucket/path").
 	Remote string
 
+	// CredentialsSecret names an existing secret in the PVC's namespace to take the
+	// backend credentials from instead of the credential fields, so they never
+	// leave the cluster. Each key becomes the rclone option of the same name on the
+	// remote in use, as the environment variable RCLONE_CONFIG_<REMOTE>_<KEY>: a key
+	// SECRET_ACCESS_KEY sets secret_access_key. Passwords must be stored obscured
+	// (`rclone obscure`), as rclone reads them. Ambient authentication (env_auth) is
+	// not enabled when this is set.
+	CredentialsSecret string
+
+	// CredentialsKubeconfigPath and CredentialsContext read CredentialsSecret from
+	// another cluster than the PVC's, such as the one the backup was taken in, from
+	// CredentialsNamespace or the namespace of the context. Its contents are copied
+	// into a secret of the rclone job then, which is removed with it.
+	CredentialsKubeconfigPath string
+	CredentialsContext        string
+	CredentialsNamespace      string
+
+	// ServiceAccountName runs the rclone job as this existing service account in the
+	// PVC's namespace instead of the one the chart creates. The identity it carries is
+	// used as-is, so it cannot be combined with the annotation and identity fields.
+	ServiceAccountName string
+
+	// ServiceAccountAnnotations are set on the service account the chart creates for
+	// the rclone job, for identity integrations without a dedicated field.
+	ServiceAccountAnnotations map[string]string
+
+	// Workload identity for the rclone job, each set as the annotation its cloud reads
+	// off the service account: an IAM role for IRSA on EKS (s3), a managed identity
+	// client ID and optionally its tenant for Azure Workload Identity (azure), and a
+	// Google service account for GKE Workload Identity (gcs).
+	AWSRoleARN        string
+	AzureClientID     string
+	AzureTenantID     string
+	GCPServiceAccount string
+
+	// EnvAuth allows a generated config without credentials, CredentialsSecret or a
+	// workload identity, for nodes that grant bucket access themselves (such as an
+	// EC2 instance profile). Without it such a config is rejected before anything is
+	// deployed.
+	EnvAuth bool
+
 	// RcloneExtraArgs are extra flags appended to the rclone command after the built-in progress flags.
 	RcloneExtraArgs string
 
+	// BWLimit caps the rclone transfer rate, as on Backup.
+	BWLimit string
+
+	// Hooks run in the pods of the application in Namespace, as on Backup.
+	Hooks Hooks
+
 	DeleteExtraneousFiles bool
-	IgnoreMounted         bool
-	NonRoot               bool
-	Detach                bool
-	NoCleanup             bool
-	NoCleanupOnFailure    bool
+
+	// Verify checks the PVC against the backup once the restore is done, with
+	// rclone check in the same job. Files the PVC has on top of the backup are
+	// only reported with DeleteExtraneousFiles, which is meant to remove them.
+	Verify bool
+	// VerifyMode is "size" to compare sizes only (default), or "checksum" to compare
+	// hashes as well. Checksums fall back to sizes for a backend that shares no hash
+	// type with the local filesystem.
+	VerifyMode string
+
+	IgnoreMounted      bool
+	NonRoot            bool
+	Detach             bool
+	NoCleanup          bool
+	NoCleanupOnFailure bool
 
 	HelmTimeout      time.Duration
 	HelmValuesFiles  []string
@@ -110,6 +223,30 @@ func RunRestore(ctx context.Context, restore Restore) error {
 	return nil
 }
 
+// RunVerify checks the PVC against the backup the restore describes without
+// restoring anything, as Verify does after a restore. The PVC is mounted
+// read-only, and files it has on top of the backup are not reported.
+func RunVerify(ctx context.Context, restore Restore) error {
+	applyRestoreDefaults(&restore)
+
+	if restore.ID != "" {
+		if err := opid.Validate(restore.ID); err != nil {
+			return err
+		}
+	}
+
+	req := toRestoreRequest(&restore)
+	req.Direction = rclone.DirectionVerify
+	req.Verify = false
+	req.DeleteExtraneousFiles = false
+
+	if err := bucketstorage.Run(ctx, req); err != nil {
+		return fmt.Errorf("verify failed: %w", err)
+	}
+
+	return nil
+}
+
 func applyRestoreDefaults(restore *Restore) {
 	if restore.Prefix == "" {
 		restore.Prefix = DefaultPrefix
@@ -130,45 +267,91 @@ func applyRestoreDefaults(restore *Restore) {
 
 func toRestoreRequest(restore *Restore) *bucketstorage.Request {
 	return &bucketstorage.Request{
-		ID:                    restore.ID,
-		ImageTag:              restore.ImageTag,
-		ChartVersion:          restore.ChartVersion,
-		Direction:             rclone.DirectionRestore,
-		KubeconfigPath:        restore.PVC.KubeconfigPath,
-		Context:               restore.PVC.Context,
-		Namespace:             restore.PVC.Namespace,
-		PVCName:               restore.PVC.Name,
-		IgnoreMounted:         restore.IgnoreMounted,
-		NonRoot:               restore.NonRoot,
-		Detach:                restore.Detach,
-		NoCleanup:             restore.NoCleanup,
-		NoCleanupOnFailure:    restore.NoCleanupOnFailure,
-		DeleteExtraneousFiles: restore.DeleteExtraneousFiles,
-		Backend:               restore.Backend,
-		Bucket:                restore.Bucket,
-		S3Provider:            restore.S3Provider,
-		Endpoint:              restore.Endpoint,
-		Region:                restore.Region,
-		AccessKey:             restore.AccessKey,
-		SecretKey:             restore.SecretKey,
-		StorageAccount:        restore.StorageAccount,
-		StorageKey:            restore.StorageKey,
-		GCSServiceAccountJSON: restore.GCSServiceAccountJSON,
-		GCSBucketPolicyOnly:   restore.GCSBucketPolicyOnly,
-		Name:                  restore.Name,
-		Prefix:                restore.Prefix,
-		Path:                  restore.Path,
-		RcloneConfigFile:      restore.RcloneConfigFile,
-		Remote:                restore.Remote,
-		RcloneExtraArgs:       restore.RcloneExtraArgs,
-		HelmTimeout:           restore.HelmTimeout,
-		HelmValuesFiles:       restore.HelmValuesFiles,
-		HelmValues:            restore.HelmValues,
-		HelmFileValues:        restore.HelmFileValues,
-		HelmStringValues:      restore.HelmStringValues,
-		Writer:                restore.Writer,
-		StructuredLogs:        restore.StructuredLogs,
-		ColorOutput:           restore.ColorOutput,
-		Logger:                restore.Logger,
+		ID:                        restore.ID,
+		ImageTag:                  restore.ImageTag,
+		ChartVersion:              restore.ChartVersion,
+		Direction:                 rclone.DirectionRestore,
+		KubeconfigPath:            restore.PVC.KubeconfigPath,
+		Context:                   restore.PVC.Context,
+		Namespace:                 restore.PVC.Namespace,
+		PVCName:                   restore.PVC.Name,
+		PVCNames:                  restore.PVCNames,
+		PVCSelector:               restore.PVCSelector,
+		IgnoreMounted:             restore.IgnoreMounted,
+		NonRoot:                   restore.NonRoot,
+		Detach:                    restore.Detach,
+		NoCleanup:                 restore.NoCleanup,
+		NoCleanupOnFailure:        restore.NoCleanupOnFailure,
+		DeleteExtraneousFiles:     restore.DeleteExtraneousFiles,
+		Verify:                    restore.Verify,
+		VerifyMode:                restore.VerifyMode,
+		Backend:                   restore.Backend,
+		Bucket:                    restore.Bucket,
+		S3Provider:                restore.S3Provider,
+		Endpoint:                  restore.Endpoint,
+		Region:                    restore.Region,
+		AccessKey:                 restore.AccessKey,
+		SecretKey:                 restore.SecretKey,
+		StorageAccount:            restore.StorageAccount,
+		StorageKey:                restore.StorageKey,
+		GCSServiceAccountJSON:     restore.GCSServiceAccountJSON,
+		GCSBucketPolicyOnly:       restore.GCSBucketPolicyOnly,
+		SFTPHost:                  restore.SFTPHost,
+		SFTPPort:                  restore.SFTPPort,
+		SFTPUser:                  restore.SFTPUser,
+		SFTPPassword:              restore.SFTPPassword,
+		SFTPKeyPEM:                restore.SFTPKeyPEM,
+		WebDAVURL:                 restore.WebDAVURL,
+		WebDAVVendor:              restore.WebDAVVendor,
+		WebDAVUser:                restore.WebDAVUser,
+		WebDAVPassword:            restore.WebDAVPassword,
+		WebDAVBearerToken:         restore.WebDAVBearerToken,
+		SwiftAuthURL:              restore.SwiftAuthURL,
+		SwiftUser:                 restore.SwiftUser,
+		SwiftKey:                  restore.SwiftKey,
+		SwiftTenant:               restore.SwiftTenant,
+		SwiftDomain:               restore.SwiftDomain,
+		B2Account:                 restore.B2Account,
+		B2Key:                     restore.B2Key,
+		Name:                      restore.Name,
+		FromLatest:                restore.FromLatest,
+		Prefix:                    restore.Prefix,
+		Path:                      restore.Path,
+		SourcePath:                restore.SourcePath,
+		Filter:                    restoreFilter(restore),
+		RcloneConfigFile:          restore.RcloneConfigFile,
+		RcloneConfigRemote:        restore.RcloneConfigRemote,
+		Remote:                    restore.Remote,
+		RcloneExtraArgs:           restore.RcloneExtraArgs,
+		BWLimit:                   restore.BWLimit,
+		Hooks:                     toHookConfig(&restore.Hooks),
+		CredentialsSecret:         restore.CredentialsSecret,
+		CredentialsKubeconfigPath: restore.CredentialsKubeconfigPath,
+		CredentialsContext:        restore.CredentialsContext,
+		CredentialsNamespace:      restore.CredentialsNamespace,
+		ServiceAccountName:        restore.ServiceAccountName,
+		ServiceAccountAnnotations: restore.ServiceAccountAnnotations,
+		AWSRoleARN:                restore.AWSRoleARN,
+		AzureClientID:             restore.AzureClientID,
+		AzureTenantID:             restore.AzureTenantID,
+		GCPServiceAccount:         restore.GCPServiceAccount,
+		EnvAuth:                   restore.EnvAuth,
+		HelmTimeout:               restore.HelmTimeout,
+		HelmValuesFiles:           restore.HelmValuesFiles,
+		HelmValues:                restore.HelmValues,
+		HelmFileValues:            restore.HelmFileValues,
+		HelmStringValues:          restore.HelmStringValues,
+		Writer:                    restore.Writer,
+		StructuredLogs:            restore.StructuredLogs,
+		ColorOutput:               restore.ColorOutput,
+		Logger:                    restore.Logger,
+	}
+}
+
+func restoreFilter(restore *Restore) rclone.Filter {
+	return rclone.Filter{
+		Include: restore.Include,
+		Exclude: restore.Exclude,
+		Rules:   restore.FilterRules,
 	}
 }